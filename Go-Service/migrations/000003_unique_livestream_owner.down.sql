@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_livestreams_owner;
+CREATE INDEX IF NOT EXISTS idx_livestreams_owner ON livestreams(owner_user_id);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS broadcast_sessions (
+    id              TEXT         PRIMARY KEY,
+    livestream_uuid TEXT         NOT NULL REFERENCES livestreams(uuid) ON DELETE CASCADE,
+    started_at      TIMESTAMPTZ  NOT NULL,
+    ended_at        TIMESTAMPTZ,
+    peak_viewers    INTEGER      NOT NULL DEFAULT 0,
+    publisher_ip    TEXT         NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_broadcast_sessions_livestream ON broadcast_sessions(livestream_uuid, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_broadcast_sessions_open ON broadcast_sessions(livestream_uuid) WHERE ended_at IS NULL;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS restream_targets (
+    id              TEXT         PRIMARY KEY,
+    livestream_uuid TEXT         NOT NULL REFERENCES livestreams(uuid) ON DELETE CASCADE,
+    name            TEXT         NOT NULL DEFAULT '',
+    url             TEXT         NOT NULL,
+    enabled         BOOLEAN      NOT NULL DEFAULT true,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_restream_targets_livestream ON restream_targets(livestream_uuid);
@@ -0,0 +1 @@
+ALTER TABLE livestreams ADD COLUMN IF NOT EXISTS low_latency BOOLEAN NOT NULL DEFAULT false;
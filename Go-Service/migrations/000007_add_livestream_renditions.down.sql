@@ -0,0 +1 @@
+ALTER TABLE livestreams DROP COLUMN IF EXISTS renditions;
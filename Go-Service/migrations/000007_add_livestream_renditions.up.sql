@@ -0,0 +1 @@
+ALTER TABLE livestreams ADD COLUMN IF NOT EXISTS renditions TEXT[] NOT NULL DEFAULT '{}';
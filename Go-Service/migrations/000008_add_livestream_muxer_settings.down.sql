@@ -0,0 +1,3 @@
+ALTER TABLE livestreams DROP COLUMN IF EXISTS cleanup_mode;
+ALTER TABLE livestreams DROP COLUMN IF EXISTS fragment_num;
+ALTER TABLE livestreams DROP COLUMN IF EXISTS fragment_duration_ms;
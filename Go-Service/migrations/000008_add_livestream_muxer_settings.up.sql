@@ -0,0 +1,3 @@
+ALTER TABLE livestreams ADD COLUMN IF NOT EXISTS fragment_duration_ms INTEGER NOT NULL DEFAULT 500;
+ALTER TABLE livestreams ADD COLUMN IF NOT EXISTS fragment_num INTEGER NOT NULL DEFAULT 5;
+ALTER TABLE livestreams ADD COLUMN IF NOT EXISTS cleanup_mode TEXT NOT NULL DEFAULT 'asap';
@@ -0,0 +1 @@
+ALTER TABLE livestreams ADD COLUMN IF NOT EXISTS dvr_window_seconds INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS share_links (
+    id              TEXT         PRIMARY KEY,
+    livestream_uuid TEXT         NOT NULL REFERENCES livestreams(uuid) ON DELETE CASCADE,
+    name            TEXT         NOT NULL DEFAULT '',
+    expires_at      TIMESTAMPTZ  NOT NULL,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_share_links_livestream ON share_links(livestream_uuid);
@@ -0,0 +1 @@
+ALTER TABLE livestreams ADD COLUMN IF NOT EXISTS encrypted BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS recordings (
+    id               TEXT             PRIMARY KEY,
+    livestream_uuid  TEXT             NOT NULL REFERENCES livestreams(uuid) ON DELETE CASCADE,
+    started_at       TIMESTAMPTZ      NOT NULL,
+    ended_at         TIMESTAMPTZ,
+    duration_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+    size_bytes       BIGINT           NOT NULL DEFAULT 0,
+    status           TEXT             NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_recordings_livestream ON recordings(livestream_uuid, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_recordings_status ON recordings(status);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS conversion_jobs (
+    id              TEXT             PRIMARY KEY,
+    recording_id    TEXT             NOT NULL UNIQUE REFERENCES recordings(id) ON DELETE CASCADE,
+    livestream_uuid TEXT             NOT NULL,
+    status          TEXT             NOT NULL,
+    progress        DOUBLE PRECISION NOT NULL DEFAULT 0,
+    attempts        INTEGER          NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ      NOT NULL,
+    last_error      TEXT             NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ      NOT NULL,
+    updated_at      TIMESTAMPTZ      NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_conversion_jobs_due ON conversion_jobs(next_attempt_at) WHERE status = 'queued';
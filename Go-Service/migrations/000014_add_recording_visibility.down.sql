@@ -0,0 +1 @@
+ALTER TABLE recordings DROP COLUMN IF EXISTS visibility;
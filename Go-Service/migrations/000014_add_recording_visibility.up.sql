@@ -0,0 +1 @@
+ALTER TABLE recordings ADD COLUMN IF NOT EXISTS visibility TEXT NOT NULL DEFAULT 'private';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_conversion_jobs_lease;
+ALTER TABLE conversion_jobs DROP COLUMN IF EXISTS locked_until;
@@ -0,0 +1,2 @@
+ALTER TABLE conversion_jobs ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_conversion_jobs_lease ON conversion_jobs(locked_until) WHERE status = 'running';
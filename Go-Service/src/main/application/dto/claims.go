@@ -6,3 +6,10 @@ type AnonymousViewerClaims struct {
 	ViewerID string `json:"viewer_id"`
 	jwt.RegisteredClaims
 }
+
+// PlaybackClaims is the signed token of a share link. The subject is the
+// livestream UUID and the expiry is the link's.
+type PlaybackClaims struct {
+	LinkID string `json:"link_id"`
+	jwt.RegisteredClaims
+}
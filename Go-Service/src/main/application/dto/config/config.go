@@ -4,11 +4,26 @@ type Config struct {
 	Server struct {
 		Port         int    `mapstructure:"port"`
 		Domain       string `mapstructure:"domain"`
-		RTMPHost     string `mapstructure:"rtmp_host"`
 		HTTPS        bool   `mapstructure:"https" default:"false"`
 		EnableGinLog bool   `mapstructure:"enable_gin_log" default:"true"`
 		LogLevel     string `mapstructure:"log_level" default:"INFO"`
 	} `mapstructure:"server"`
+	RTMP struct {
+		// ListenAddr is the address the RTMP ingest binds to
+		ListenAddr string `mapstructure:"listen_addr"`
+		// AdvertisedHost and AdvertisedPort are what publishers connect to,
+		// e.g. a load balancer in front of ListenAddr
+		AdvertisedHost string `mapstructure:"advertised_host"`
+		AdvertisedPort int    `mapstructure:"advertised_port"`
+		// RTMPS listener; when enabled push URLs are advertised as rtmps://
+		TLSEnabled         bool   `mapstructure:"tls_enabled"`
+		TLSListenAddr      string `mapstructure:"tls_listen_addr"`
+		TLSAdvertisedPort  int    `mapstructure:"tls_advertised_port"`
+		TLSCertFile        string `mapstructure:"tls_cert_file"`
+		TLSKeyFile         string `mapstructure:"tls_key_file"`
+		OnPublishURL       string `mapstructure:"on_publish_url"`
+		OnPublishTimeoutMs int64  `mapstructure:"on_publish_timeout_ms"`
+	} `mapstructure:"rtmp"`
 	Frontend struct {
 		Domain string `mapstructure:"domain"`
 		Port   int    `mapstructure:"port"`
@@ -23,6 +38,22 @@ type Config struct {
 	Redis struct {
 		URI string `mapstructure:"uri"`
 	} `mapstructure:"redis"`
+	Cache struct {
+		// HLSMaxBytes is the memory budget of the HLS file cache
+		HLSMaxBytes int64 `mapstructure:"hls_max_bytes"`
+		// HLSPlaylistMaxAge is how many seconds caches may reuse a playlist
+		HLSPlaylistMaxAge int `mapstructure:"hls_playlist_max_age"`
+		// HLSVaryOnAuth makes HLS responses vary on the credentials, for
+		// caches that must not share files across viewers
+		HLSVaryOnAuth bool `mapstructure:"hls_vary_on_auth"`
+	} `mapstructure:"cache"`
+	Recording struct {
+		// ConversionWorkers bounds how many MP4 conversions run at once
+		ConversionWorkers int `mapstructure:"conversion_workers"`
+		// ConversionMaxAttempts is how often a failing conversion is run
+		// before it is given up
+		ConversionMaxAttempts int `mapstructure:"conversion_max_attempts"`
+	} `mapstructure:"recording"`
 	RateLimit struct {
 		Enabled bool `json:"enabled"`
 
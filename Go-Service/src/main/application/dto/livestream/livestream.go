@@ -2,36 +2,71 @@ package dto
 
 import (
 	"Go-Service/src/main/domain/entity/livestream"
+	"time"
 )
 
 type LivestreamCreateDTO struct {
-	Name        string                `json:"name"`
-	Visibility  livestream.Visibility `json:"visibility"`
-	Title       string                `json:"title"`
-	Information string                `json:"information"`
-	IsRecord    bool                  `json:"is_record"`
+	Name        string                 `json:"name"`
+	Visibility  livestream.Visibility  `json:"visibility"`
+	Title       string                 `json:"title"`
+	Information string                 `json:"information"`
+	IsRecord    bool                   `json:"is_record"`
+	LowLatency  bool                   `json:"low_latency"`
+	Renditions  []livestream.Rendition `json:"renditions"`
+	// Muxer settings; zero values take the defaults
+	FragmentDurationMs int                    `json:"fragment_duration_ms"`
+	FragmentNum        int                    `json:"fragment_num"`
+	CleanupMode        livestream.CleanupMode `json:"cleanup_mode"`
+	DVRWindowSeconds   int                    `json:"dvr_window_seconds"`
+	Encrypted          bool                   `json:"encrypted"`
+	OwnerUserID        string                 `json:"owner_user_id"`
 }
 type LivestreamCreateResponseDTO struct {
+	UUID          string `json:"uuid"`
 	StreamPushURL string `json:"streamPushURL"`
 }
+type LivestreamRotateKeyRequestDTO struct {
+	DropPublisher bool `json:"drop_publisher"`
+}
+type LivestreamRotateKeyResponseDTO struct {
+	StreamPushURL string `json:"streamPushURL"`
+}
+type LivestreamKickPublisherRequestDTO struct {
+	BlockSeconds int `json:"block_seconds"`
+}
 type LivestreamGetOneResponseDTO struct {
-	UUID        string                `json:"uuid"`
-	Name        string                `json:"name"`
-	Title       string                `json:"title"`
-	Information string                `json:"information"`
-	StreamURL   string                `json:"streamURL"`
-	Visibility  livestream.Visibility `json:"visibility"`
+	UUID        string `json:"uuid"`
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Information string `json:"information"`
+	StreamURL   string `json:"streamURL"`
+	// MasterURL is the ABR master playlist, set when a ladder is configured
+	MasterURL string `json:"masterURL,omitempty"`
+	// DVRURL is the rewindable playlist, set when DVR is enabled
+	DVRURL string `json:"dvrURL,omitempty"`
+	// ThumbnailURL is the latest snapshot while live, the poster otherwise
+	ThumbnailURL string                `json:"thumbnailURL"`
+	Visibility   livestream.Visibility `json:"visibility"`
+	IsLive       bool                  `json:"is_live"`
+	LiveSince    *time.Time            `json:"live_since"`
 }
 type LivestreamGetByOwnerIDResponseDTO struct {
-	UUID          string                `json:"uuid"`
-	Name          string                `json:"name"`
-	Visibility    livestream.Visibility `json:"visibility"`
-	Title         string                `json:"title"`
-	Information   string                `json:"information"`
-	StreamPushURL string                `json:"streamPushURL"`
-	BanList       []string              `json:"ban_list"`
-	MuteList      []string              `json:"mute_list"`
-	IsRecord      bool                  `json:"is_record"`
+	UUID               string                 `json:"uuid"`
+	Name               string                 `json:"name"`
+	Visibility         livestream.Visibility  `json:"visibility"`
+	Title              string                 `json:"title"`
+	Information        string                 `json:"information"`
+	StreamPushURL      string                 `json:"streamPushURL"`
+	BanList            []string               `json:"ban_list"`
+	MuteList           []string               `json:"mute_list"`
+	IsRecord           bool                   `json:"is_record"`
+	LowLatency         bool                   `json:"low_latency"`
+	Renditions         []livestream.Rendition `json:"renditions"`
+	FragmentDurationMs int                    `json:"fragment_duration_ms"`
+	FragmentNum        int                    `json:"fragment_num"`
+	CleanupMode        livestream.CleanupMode `json:"cleanup_mode"`
+	DVRWindowSeconds   int                    `json:"dvr_window_seconds"`
+	Encrypted          bool                   `json:"encrypted"`
 }
 type LivestreamAddChatRequestDTO struct {
 	StreamUUID string `json:"stream_uuid"`
@@ -41,3 +76,34 @@ type LivestreamMuteUserRequestDTO struct {
 	StreamUUID string `json:"stream_uuid"`
 	ChatID     string `json:"chat_id"`
 }
+
+// LivestreamPlaylistReloadDTO carries the LL-HLS blocking reload query
+// parameters _HLS_msn and _HLS_part; nil means absent
+type LivestreamPlaylistReloadDTO struct {
+	MSN  *int
+	Part *int
+}
+
+// LivestreamFileDTO is an HLS file as served. Public files may be kept by
+// shared caches.
+type LivestreamFileDTO struct {
+	Data    []byte
+	ModTime time.Time
+	Public  bool
+}
+
+// VODFileDTO is a file of a recording played back over HLS. It is served
+// from disk, so the player can seek with range requests.
+type VODFileDTO struct {
+	Path   string
+	Public bool
+}
+
+// RecordingVisibilityDTO sets who may play a recording back
+type RecordingVisibilityDTO struct {
+	Visibility livestream.Visibility `json:"visibility"`
+}
+
+type ThumbnailPosterDTO struct {
+	ThumbnailID string `json:"thumbnail_id"`
+}
@@ -0,0 +1,20 @@
+package dto
+
+import (
+	"Go-Service/src/main/domain/entity/livestream"
+	"time"
+)
+
+type RestreamTargetRequestDTO struct {
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Enabled *bool  `json:"enabled"`
+}
+type RestreamTargetResponseDTO struct {
+	ID        string                    `json:"id"`
+	Name      string                    `json:"name"`
+	URL       string                    `json:"url"`
+	Enabled   bool                      `json:"enabled"`
+	CreatedAt time.Time                 `json:"created_at"`
+	Status    livestream.RestreamStatus `json:"status"`
+}
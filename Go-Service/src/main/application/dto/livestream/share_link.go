@@ -0,0 +1,17 @@
+package dto
+
+import "time"
+
+type ShareLinkCreateDTO struct {
+	Name string `json:"name"`
+	// ExpiresInSeconds defaults to a day when zero
+	ExpiresInSeconds int `json:"expires_in_seconds"`
+}
+type ShareLinkResponseDTO struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	Token       string    `json:"token"`
+	PlaybackURL string    `json:"playback_url"`
+}
@@ -2,9 +2,12 @@ package jwt
 
 import (
 	"Go-Service/src/main/application/dto"
+	"time"
 )
 
 type JWTGenerator interface {
 	GenerateAnonymousViewerToken(viewerID, secretKey string) (string, error)
 	ParseAnonymousViewerToken(tokenString, secretKey string) (*dto.AnonymousViewerClaims, error)
+	GeneratePlaybackToken(linkID, livestreamUUID string, expiresAt time.Time, secretKey string) (string, error)
+	ParsePlaybackToken(tokenString, secretKey string) (*dto.PlaybackClaims, error)
 }
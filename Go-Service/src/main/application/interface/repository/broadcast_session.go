@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"Go-Service/src/main/domain/entity/livestream"
+	"time"
+)
+
+type BroadcastSessionRepository interface {
+	Create(session *livestream.BroadcastSession) error
+	End(id string, endedAt time.Time) error
+	// UpdatePeakViewers raises the peak of the open session of a livestream
+	UpdatePeakViewers(livestreamUUID string, viewers int) error
+	// CloseOpen ends every session still marked live, e.g. after a crash
+	CloseOpen(endedAt time.Time) error
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"Go-Service/src/main/domain/entity/livestream"
+	"time"
+)
+
+type ConversionJobRepository interface {
+	GetByRecordingID(recordingID string) (*livestream.ConversionJob, error)
+	// Enqueue queues the job, or queues the existing job of the recording
+	// again from scratch if it is done or failed. A job that is queued or
+	// running already is left alone.
+	Enqueue(job *livestream.ConversionJob) error
+	// Claim marks the queued job that has been due the longest as running,
+	// counts the attempt and returns it; ErrNotFound when none is due. The
+	// job is leased until lockedUntil; a running job whose lease expired,
+	// because its worker died, is claimed like a queued one. Two callers
+	// never claim the same job.
+	Claim(now, lockedUntil time.Time) (*livestream.ConversionJob, error)
+	// RenewLease extends the lease of the given attempt. It returns
+	// ErrNotFound when the attempt lost the job.
+	RenewLease(id string, attempt int, lockedUntil time.Time) error
+	UpdateProgress(id string, progress float64) error
+	// Update ends the running attempt the job was claimed for. It returns
+	// ErrNotFound when the attempt lost the job.
+	Update(job *livestream.ConversionJob) error
+	// Release puts a running attempt back in the queue without counting it
+	Release(id string, attempt int, now time.Time) error
+}
@@ -5,9 +5,10 @@ import "Go-Service/src/main/domain/entity/livestream"
 type LivestreamRepository interface {
 	GetByID(id string) (*livestream.Livestream, error)
 	GetByOwnerID(ownerID string) (*livestream.Livestream, error)
-	GetOne() (*livestream.Livestream, error)
+	List() ([]*livestream.Livestream, error)
 	Create(livestream *livestream.Livestream) error
 	Update(livestream *livestream.Livestream) error
 	Delete(id string) error
+	UpdateAPIKey(id string, apiKey string) error
 	MuteUser(identityProvider string, livestreamUUID string, userID string) error
 }
@@ -0,0 +1,16 @@
+package repository
+
+import "Go-Service/src/main/domain/entity/livestream"
+
+type RecordingRepository interface {
+	GetByID(id string) (*livestream.Recording, error)
+	// ListByLivestream returns the recordings of a livestream, newest first
+	ListByLivestream(livestreamUUID string) ([]*livestream.Recording, error)
+	ListByStatus(status livestream.RecordingStatus) ([]*livestream.Recording, error)
+	Create(recording *livestream.Recording) error
+	Update(recording *livestream.Recording) error
+	// UpdateVisibility changes who may play a recording back, leaving the
+	// rest of it to Update
+	UpdateVisibility(id string, visibility livestream.Visibility) error
+	Delete(id string) error
+}
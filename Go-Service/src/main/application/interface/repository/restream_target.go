@@ -0,0 +1,11 @@
+package repository
+
+import "Go-Service/src/main/domain/entity/livestream"
+
+type RestreamTargetRepository interface {
+	GetByID(id string) (*livestream.RestreamTarget, error)
+	ListByLivestream(livestreamUUID string) ([]*livestream.RestreamTarget, error)
+	Create(target *livestream.RestreamTarget) error
+	Update(target *livestream.RestreamTarget) error
+	Delete(id string) error
+}
@@ -0,0 +1,10 @@
+package repository
+
+import "Go-Service/src/main/domain/entity/livestream"
+
+type ShareLinkRepository interface {
+	GetByID(id string) (*livestream.ShareLink, error)
+	ListByLivestream(livestreamUUID string) ([]*livestream.ShareLink, error)
+	Create(link *livestream.ShareLink) error
+	Delete(id string) error
+}
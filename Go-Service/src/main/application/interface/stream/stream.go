@@ -1,9 +1,92 @@
 package stream
 
+import (
+	"Go-Service/src/main/domain/entity/livestream"
+	"context"
+	"time"
+)
+
+// StreamOptions are the per-stream ingest settings. Changes take effect when
+// the next publish starts.
+type StreamOptions struct {
+	IsRecord bool
+	// LowLatency switches the HLS output to LL-HLS with partial segments
+	LowLatency bool
+	// HLS muxer settings; zero values take the defaults
+	FragmentDurationMs int
+	FragmentNum        int
+	CleanupMode        livestream.CleanupMode
+	// DVRWindowSeconds keeps segments for dvr.m3u8; zero disables DVR
+	DVRWindowSeconds int
+}
+
+type PublishEventType string
+
+const (
+	PublishStarted PublishEventType = "publish_start"
+	PublishStopped PublishEventType = "publish_stop"
+)
+
+// PublishEvent is emitted when an RTMP publisher starts or stops a broadcast.
+// The start and stop events of one publish share the same SessionID.
+type PublishEvent struct {
+	Type       PublishEventType
+	SessionID  string
+	UUID       string
+	RemoteAddr string
+	At         time.Time
+	// Recorded publishes are recorded into a session directory named after
+	// SessionID
+	Recorded bool
+}
+
+type PublishEventHandler func(event PublishEvent)
+
+// PlaylistUpdateHandler is called with the path of a live playlist every time
+// the muxer rewrites it
+type PlaylistUpdateHandler func(uuid, playlistPath string)
+
+// RestreamTargetSource returns the enabled restream targets of a livestream.
+// It is consulted whenever a publish starts or the targets are refreshed.
+type RestreamTargetSource func(uuid string) ([]livestream.RestreamTarget, error)
+
+// FlvSubscription is one HTTP-FLV viewer of a live stream. Data yields the
+// FLV header followed by FLV tags, and is closed when the publish ends or the
+// viewer falls too far behind.
+type FlvSubscription interface {
+	Data() <-chan []byte
+	Close()
+}
+
 type ILivestreamService interface {
-	OpenStream(name, uuid, apiKey string, isRecord bool) error
+	OpenStream(name, uuid, apiKey string, options StreamOptions) error
+	UpdateStreamOptions(uuid string, options StreamOptions) error
 	CloseStream(uuid string) error
+	UpdateStreamKey(uuid, apiKey string, dropPublisher bool) error
+	KickPublisher(uuid string, blockFor time.Duration) error
+	OnPublishEvent(handler PublishEventHandler)
+	OnPlaylistUpdate(handler PlaylistUpdateHandler)
+	// WaitLowLatencyPart holds a blocking playlist reload until the LL-HLS
+	// playlist has part `part` of media segment `msn`, or the whole segment
+	// when part is negative. It returns ErrNotFound when the stream is not
+	// publishing in low-latency mode, ErrInvalidInput when msn is too far
+	// ahead and ErrTimeout when the part does not show up in time.
+	WaitLowLatencyPart(ctx context.Context, uuid string, msn, part int) error
+	// WaitPreloadHint holds a request for the part advertised by
+	// EXT-X-PRELOAD-HINT until it has been written. Other files return
+	// immediately.
+	WaitPreloadHint(ctx context.Context, uuid, filename string) error
+	SetRestreamTargetSource(source RestreamTargetSource)
+	// RefreshRestreamTargets reloads the targets of a live stream so relays
+	// start or stop without waiting for the next publish
+	RefreshRestreamTargets(uuid string) error
+	// GetRestreamStatus returns the relay status by target ID; targets that
+	// are not being relayed are absent
+	GetRestreamStatus(uuid string) map[string]livestream.RestreamStatus
+	// SubscribeFLV returns ErrNotFound unless the stream is being published
+	SubscribeFLV(uuid string) (FlvSubscription, error)
 	StartService() error
 	RunLoop() error
 	IsLiveStreamExist(uuid string) bool
+	GetLiveStatus(uuid string) (livestream.LiveStatus, bool)
 }
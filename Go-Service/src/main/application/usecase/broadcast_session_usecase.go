@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"Go-Service/src/main/application/interface/repository"
+	"Go-Service/src/main/application/interface/stream"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/domain/interface/logger"
+	"context"
+	"net"
+	"time"
+)
+
+// BroadcastSessionUsecase persists the publish history of every livestream
+// from the ingest service's publish events.
+type BroadcastSessionUsecase struct {
+	BroadcastSessionRepo repository.BroadcastSessionRepository
+	Log                  logger.Logger
+}
+
+func NewBroadcastSessionUsecase(broadcastSessionRepo repository.BroadcastSessionRepository, log logger.Logger) *BroadcastSessionUsecase {
+	return &BroadcastSessionUsecase{
+		BroadcastSessionRepo: broadcastSessionRepo,
+		Log:                  log,
+	}
+}
+
+// CloseDanglingSessions ends sessions left open by a previous process. It
+// must run before the ingest service accepts publishers.
+func (u *BroadcastSessionUsecase) CloseDanglingSessions(ctx context.Context) error {
+	if err := u.BroadcastSessionRepo.CloseOpen(time.Now()); err != nil {
+		u.Log.Error(ctx, "Error closing dangling broadcast sessions: "+err.Error())
+		return err
+	}
+	return nil
+}
+
+// HandlePublishEvent records a session when a publisher starts and ends it
+// when the publisher stops
+func (u *BroadcastSessionUsecase) HandlePublishEvent(event stream.PublishEvent) {
+	ctx := context.Background()
+	switch event.Type {
+	case stream.PublishStarted:
+		session := &livestream.BroadcastSession{
+			ID:             event.SessionID,
+			LivestreamUUID: event.UUID,
+			StartedAt:      event.At,
+			PublisherIP:    publisherIP(event.RemoteAddr),
+		}
+		if err := u.BroadcastSessionRepo.Create(session); err != nil {
+			u.Log.Error(ctx, "Error creating broadcast session: "+err.Error())
+		}
+	case stream.PublishStopped:
+		if err := u.BroadcastSessionRepo.End(event.SessionID, event.At); err != nil {
+			u.Log.Error(ctx, "Error ending broadcast session "+event.SessionID+": "+err.Error())
+		}
+	}
+}
+
+// RecordViewerCount raises the peak viewers of the live session, if any
+func (u *BroadcastSessionUsecase) RecordViewerCount(ctx context.Context, livestreamUUID string, viewers int) error {
+	if err := u.BroadcastSessionRepo.UpdatePeakViewers(livestreamUUID, viewers); err != nil {
+		u.Log.Error(ctx, "Error updating peak viewers: "+err.Error())
+		return err
+	}
+	return nil
+}
+
+func publisherIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
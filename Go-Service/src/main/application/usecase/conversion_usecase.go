@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"Go-Service/src/main/application/interface/repository"
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/domain/interface/libarary/ffmpeg"
+	"Go-Service/src/main/domain/interface/logger"
+	"Go-Service/src/main/infrastructure/util"
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// conversionPollInterval is how long an idle worker waits before it
+	// looks at the queue again
+	conversionPollInterval = 2 * time.Second
+	// conversionRetryBackoff is the wait before the second attempt, doubled
+	// for every attempt after it
+	conversionRetryBackoff = 30 * time.Second
+	// conversionProgressInterval throttles progress writes to the database
+	conversionProgressInterval = time.Second
+	// conversionLease is how long a claimed job stays with its worker
+	// without being renewed. A job of a worker that died is claimed again
+	// once it expires.
+	conversionLease = 2 * time.Minute
+	// conversionLeaseRenewInterval leaves a few renewals to fail before the
+	// lease expires
+	conversionLeaseRenewInterval = 30 * time.Second
+)
+
+// ConversionUsecase runs the queued recording conversions on a bounded pool
+// of workers. The queue lives in Postgres, so jobs survive a restart and
+// several instances can share it: a worker leases the job it runs and keeps
+// renewing the lease until it is done.
+type ConversionUsecase struct {
+	JobRepo        repository.ConversionJobRepository
+	RecordingRepo  repository.RecordingRepository
+	LivestreamRepo repository.LivestreamRepository
+	Log            logger.Logger
+	ffmpegLibrary  ffmpeg.FfmpegLibrary
+	hlsRoot        string
+	workers        int
+	maxAttempts    int
+	running        sync.WaitGroup
+}
+
+func NewConversionUsecase(jobRepo repository.ConversionJobRepository, recordingRepo repository.RecordingRepository, livestreamRepo repository.LivestreamRepository, log logger.Logger, ffmpegLibrary ffmpeg.FfmpegLibrary, hlsRoot string, workers, maxAttempts int) *ConversionUsecase {
+	return &ConversionUsecase{
+		JobRepo:        jobRepo,
+		RecordingRepo:  recordingRepo,
+		LivestreamRepo: livestreamRepo,
+		Log:            log,
+		ffmpegLibrary:  ffmpegLibrary,
+		hlsRoot:        hlsRoot,
+		workers:        max(workers, 1),
+		maxAttempts:    max(maxAttempts, 1),
+	}
+}
+
+// Start runs the workers until ctx is done. Jobs running then are put back
+// in the queue.
+func (u *ConversionUsecase) Start(ctx context.Context) {
+	u.running.Add(u.workers)
+	for i := 0; i < u.workers; i++ {
+		go u.work(ctx)
+	}
+}
+
+// Wait returns once the workers stopped after ctx is done
+func (u *ConversionUsecase) Wait() {
+	u.running.Wait()
+}
+
+func (u *ConversionUsecase) work(ctx context.Context) {
+	defer u.running.Done()
+	for {
+		for u.RunNext(ctx) {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(conversionPollInterval):
+		}
+	}
+}
+
+// RunNext claims the next due job and runs it to the end. It reports whether
+// there was a job to run.
+func (u *ConversionUsecase) RunNext(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	now := time.Now()
+	job, err := u.JobRepo.Claim(now, now.Add(conversionLease))
+	if err != nil {
+		if err != errors.ErrNotFound {
+			u.Log.Error(ctx, "Error claiming conversion job: "+err.Error())
+		}
+		return false
+	}
+
+	convertCtx, cancel := context.WithCancel(ctx)
+	leased := make(chan bool, 1)
+	go func() {
+		leased <- u.holdLease(convertCtx, cancel, job)
+	}()
+	err = u.convert(convertCtx, job)
+	cancel()
+	if !<-leased {
+		u.Log.Warn(ctx, "Conversion of recording "+job.RecordingID+" lost its lease to another worker")
+		return true
+	}
+	if err != nil && ctx.Err() != nil {
+		// Shutting down; another worker picks the job up right away
+		if err := u.JobRepo.Release(job.ID, job.Attempts, time.Now()); err != nil {
+			u.Log.Error(ctx, "Error releasing conversion job: "+err.Error())
+		}
+		return false
+	}
+	now = time.Now()
+	job.UpdatedAt = now
+	switch {
+	case err == nil:
+		job.Status = livestream.ConversionStatusDone
+		job.Progress = 1
+		job.LastError = ""
+		u.Log.Info(ctx, "Converted recording "+job.RecordingID)
+	case job.Attempts < u.maxAttempts:
+		job.Status = livestream.ConversionStatusQueued
+		job.NextAttemptAt = now.Add(conversionRetryBackoff << (job.Attempts - 1))
+		job.LastError = err.Error()
+		u.Log.Warn(ctx, "Conversion of recording "+job.RecordingID+" failed, retrying: "+err.Error())
+	default:
+		job.Status = livestream.ConversionStatusFailed
+		job.LastError = err.Error()
+		u.Log.Error(ctx, "Conversion of recording "+job.RecordingID+" failed: "+err.Error())
+	}
+	if err := u.JobRepo.Update(job); err == errors.ErrNotFound {
+		u.Log.Warn(ctx, "Conversion of recording "+job.RecordingID+" lost its lease to another worker")
+	} else if err != nil {
+		u.Log.Error(ctx, "Error updating conversion job: "+err.Error())
+	}
+	return true
+}
+
+// holdLease renews the lease of a job until ctx is done. It cancels the
+// conversion and reports false when the job was claimed by another worker,
+// because its lease expired in the meantime.
+func (u *ConversionUsecase) holdLease(ctx context.Context, cancel context.CancelFunc, job *livestream.ConversionJob) bool {
+	ticker := time.NewTicker(conversionLeaseRenewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return true
+		case <-ticker.C:
+		}
+		err := u.JobRepo.RenewLease(job.ID, job.Attempts, time.Now().Add(conversionLease))
+		if err == errors.ErrNotFound {
+			cancel()
+			return false
+		}
+		if err != nil {
+			// Retried on the next tick, long before the lease expires
+			u.Log.Error(ctx, "Error renewing conversion lease: "+err.Error())
+		}
+	}
+}
+
+func (u *ConversionUsecase) convert(ctx context.Context, job *livestream.ConversionJob) error {
+	recording, err := u.RecordingRepo.GetByID(job.RecordingID)
+	if err != nil {
+		return err
+	}
+	ls, err := u.LivestreamRepo.GetByID(job.LivestreamUUID)
+	if err != nil {
+		return err
+	}
+	recordingDir := util.RecordingDir(filepath.Join(u.hlsRoot, job.LivestreamUUID), recording.ID)
+	var reported time.Time
+	return u.ffmpegLibrary.ConvertToMp4(ctx, ffmpeg.ConvertJob{
+		PlaylistPath:    filepath.Join(recordingDir, util.RecordPlaylistName),
+		OutputPath:      filepath.Join(recordingDir, mp4FileName(ls.Title)),
+		DurationSeconds: recording.DurationSeconds,
+		OnProgress: func(progress float64) {
+			if time.Since(reported) < conversionProgressInterval {
+				return
+			}
+			reported = time.Now()
+			if err := u.JobRepo.UpdateProgress(job.ID, progress); err != nil {
+				u.Log.Error(ctx, "Error updating conversion progress: "+err.Error())
+			}
+		},
+	})
+}
+
+// newConversionJob returns a job converting a recording right away
+func newConversionJob(recording *livestream.Recording, now time.Time) *livestream.ConversionJob {
+	return &livestream.ConversionJob{
+		ID:             uuid.New().String(),
+		RecordingID:    recording.ID,
+		LivestreamUUID: recording.LivestreamUUID,
+		Status:         livestream.ConversionStatusQueued,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+// mp4FileName names the MP4 after the livestream title, which must stay in
+// the recording directory
+func mp4FileName(title string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(title)
+	if name == "" || name == "." || name == ".." {
+		name = "record"
+	}
+	return name + ".mp4"
+}
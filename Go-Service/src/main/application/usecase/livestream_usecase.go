@@ -4,18 +4,20 @@ import (
 	"Go-Service/src/main/application/dto/config"
 	livestreamDTO "Go-Service/src/main/application/dto/livestream"
 	"Go-Service/src/main/application/interface/cache"
+	"Go-Service/src/main/application/interface/jwt"
 	"Go-Service/src/main/application/interface/repository"
 	"Go-Service/src/main/application/interface/stream"
 	"Go-Service/src/main/domain/entity/chat"
 	"Go-Service/src/main/domain/entity/errors"
 	"Go-Service/src/main/domain/entity/livestream"
-	"github.com/cool9850311/StreamPlatformLite-Core/pkg/role"
 	"Go-Service/src/main/domain/interface/file_cache"
-	"Go-Service/src/main/domain/interface/libarary/ffmpeg"
+	"Go-Service/src/main/domain/interface/hls_key"
 	"Go-Service/src/main/domain/interface/logger"
 	"Go-Service/src/main/infrastructure/util"
 	"context"
 	goErrors "errors"
+	"net"
+	"net/url"
 	"path/filepath"
 	"slices"
 	"strconv"
@@ -23,35 +25,109 @@ import (
 	"sync"
 	"time"
 
+	"github.com/cool9850311/StreamPlatformLite-Core/pkg/role"
 	"github.com/google/uuid"
 )
 
+// maxPublishBlockSeconds caps how long a kicked publisher can be locked out
+const maxPublishBlockSeconds = 24 * 60 * 60
+
 type LivestreamUsecase struct {
-	LivestreamRepo   repository.LivestreamRepository
-	Log              logger.Logger
-	config           config.Config
-	streamService    stream.ILivestreamService
-	viewerCountCache cache.ViewerCount
-	chatCache        cache.Chat
-	fileCache        file_cache.IFileCache
-	ffmpegLibrary    ffmpeg.FfmpegLibrary
-	m3u8Lock         sync.Mutex
-	convertTaskLock  sync.Mutex
-}
-
-func NewLivestreamUsecase(livestreamRepo repository.LivestreamRepository, log logger.Logger, config config.Config, streamService stream.ILivestreamService, viewerCountCache cache.ViewerCount, chatCache cache.Chat, fileCache file_cache.IFileCache, ffmpegLibrary ffmpeg.FfmpegLibrary) *LivestreamUsecase {
-	u := &LivestreamUsecase{
-		LivestreamRepo:   livestreamRepo,
-		Log:              log,
-		config:           config,
-		streamService:    streamService,
-		viewerCountCache: viewerCountCache,
-		chatCache:        chatCache,
-		fileCache:        fileCache,
-		ffmpegLibrary:    ffmpegLibrary,
-	}
-	go u.startCacheCleanup()
-	return u
+	LivestreamRepo    repository.LivestreamRepository
+	Log               logger.Logger
+	config            config.Config
+	streamService     stream.ILivestreamService
+	viewerCountCache  cache.ViewerCount
+	chatCache         cache.Chat
+	fileCache         file_cache.IFileCache
+	ShareLinkRepo     repository.ShareLinkRepository
+	jwtGenerator      jwt.JWTGenerator
+	hlsKeyStore       hls_key.KeyStore
+	RecordingRepo     repository.RecordingRepository
+	ConversionJobRepo repository.ConversionJobRepository
+	playback          *playbackAccess
+	streamKeyLock     sync.Mutex
+}
+
+func NewLivestreamUsecase(livestreamRepo repository.LivestreamRepository, log logger.Logger, config config.Config, streamService stream.ILivestreamService, viewerCountCache cache.ViewerCount, chatCache cache.Chat, fileCache file_cache.IFileCache, shareLinkRepo repository.ShareLinkRepository, jwtGenerator jwt.JWTGenerator, hlsKeyStore hls_key.KeyStore, recordingRepo repository.RecordingRepository, conversionJobRepo repository.ConversionJobRepository) *LivestreamUsecase {
+	return &LivestreamUsecase{
+		LivestreamRepo:    livestreamRepo,
+		Log:               log,
+		config:            config,
+		streamService:     streamService,
+		viewerCountCache:  viewerCountCache,
+		chatCache:         chatCache,
+		fileCache:         fileCache,
+		ShareLinkRepo:     shareLinkRepo,
+		jwtGenerator:      jwtGenerator,
+		hlsKeyStore:       hlsKeyStore,
+		RecordingRepo:     recordingRepo,
+		ConversionJobRepo: conversionJobRepo,
+		playback:          newPlaybackAccess(shareLinkRepo, jwtGenerator, config.JWT.SecretKey),
+	}
+}
+
+// HandlePlaylistUpdate 在播放列表重写时清除缓存，下次读取会拿到新的内容
+func (u *LivestreamUsecase) HandlePlaylistUpdate(uuid, playlistPath string) {
+	unlock := u.fileCache.LockFile(playlistPath)
+	defer unlock()
+	u.fileCache.DeleteFile(playlistPath)
+}
+
+// validateRenditions rejects unknown and repeated rungs of the ladder
+func validateRenditions(renditions []livestream.Rendition) error {
+	seen := make(map[livestream.Rendition]bool, len(renditions))
+	for _, rendition := range renditions {
+		if !rendition.IsValid() || seen[rendition] {
+			return errors.ErrInvalidInput
+		}
+		seen[rendition] = true
+	}
+	return nil
+}
+
+// applyMuxerSettings fills unset HLS muxer settings with the defaults and
+// rejects values out of range
+func applyMuxerSettings(ls *livestream.Livestream) error {
+	if ls.FragmentDurationMs == 0 {
+		ls.FragmentDurationMs = livestream.DefaultFragmentDurationMs
+	}
+	if ls.FragmentNum == 0 {
+		ls.FragmentNum = livestream.DefaultFragmentNum
+	}
+	if ls.CleanupMode == "" {
+		ls.CleanupMode = livestream.DefaultCleanupMode
+	}
+	if ls.FragmentDurationMs < livestream.MinFragmentDurationMs || ls.FragmentDurationMs > livestream.MaxFragmentDurationMs {
+		return errors.ErrInvalidInput
+	}
+	if ls.FragmentNum < livestream.MinFragmentNum || ls.FragmentNum > livestream.MaxFragmentNum {
+		return errors.ErrInvalidInput
+	}
+	if !ls.CleanupMode.IsValid() {
+		return errors.ErrInvalidInput
+	}
+	if ls.DVRWindowSeconds != 0 && (ls.DVRWindowSeconds < livestream.MinDVRWindowSeconds || ls.DVRWindowSeconds > livestream.MaxDVRWindowSeconds) {
+		return errors.ErrInvalidInput
+	}
+	// A key tag covers the parts of an LL-HLS segment too, which are
+	// encrypted as separate files
+	if ls.Encrypted && ls.LowLatency {
+		return errors.ErrInvalidInput
+	}
+	return nil
+}
+
+// StreamOptions returns the ingest options stored on a livestream
+func StreamOptions(ls *livestream.Livestream) stream.StreamOptions {
+	return stream.StreamOptions{
+		IsRecord:           ls.IsRecord,
+		LowLatency:         ls.LowLatency,
+		FragmentDurationMs: ls.FragmentDurationMs,
+		FragmentNum:        ls.FragmentNum,
+		CleanupMode:        ls.CleanupMode,
+		DVRWindowSeconds:   ls.DVRWindowSeconds,
+	}
 }
 
 func (u *LivestreamUsecase) checkAdminRole(userRole role.Role) error {
@@ -70,7 +146,7 @@ func (u *LivestreamUsecase) checkEditorRole(userRole role.Role) error {
 
 // checkViewAccess 检查用户是否有权限观看直播
 // 根据直播的Visibility和用户角色判断
-func (u *LivestreamUsecase) checkViewAccess(userRole role.Role, visibility livestream.Visibility) error {
+func checkViewAccess(userRole role.Role, visibility livestream.Visibility) error {
 	switch visibility {
 	case livestream.Public:
 		// Public模式：所有人都可以观看（包括Anonymous）
@@ -88,8 +164,8 @@ func (u *LivestreamUsecase) checkViewAccess(userRole role.Role, visibility lives
 		}
 		return nil
 	case livestream.Link:
-		// Link模式：需要特定token（暂时按User及以上处理）
-		if userRole > role.User {
+		// Link模式：仅Admin可直接访问，其他人需持有分享链接的token（见checkPlaybackAccess）
+		if userRole != role.Admin {
 			return errors.ErrUnauthorized
 		}
 		return nil
@@ -98,12 +174,23 @@ func (u *LivestreamUsecase) checkViewAccess(userRole role.Role, visibility lives
 	}
 }
 
+// checkPlaybackAccess 在checkViewAccess之外，允许持有有效分享链接token的人观看Link直播。
+// 返回true表示是通过分享链接获得的权限
+func (u *LivestreamUsecase) checkPlaybackAccess(ls *livestream.Livestream, playbackToken string, userRole role.Role) (bool, error) {
+	return u.playback.check(ls, playbackToken, userRole)
+}
+
+// isPublicPlayback 判断文件是否可以被共享缓存（CDN）保存：只有Public直播，且不是通过分享链接访问
+func isPublicPlayback(ls *livestream.Livestream, viaShareLink bool) bool {
+	return ls.Visibility == livestream.Public && !viaShareLink
+}
+
 // checkChatAccess 检查用户是否有权限发送聊天
 // Public直播：Guest及以上可聊天（排除Anonymous）
 // MemberOnly直播：User及以上可聊天（排除Anonymous和Guest）
-func (u *LivestreamUsecase) checkChatAccess(userRole role.Role, visibility livestream.Visibility) error {
+func (u *LivestreamUsecase) checkChatAccess(ls *livestream.Livestream, playbackToken string, userRole role.Role) error {
 	// 首先检查是否有观看权限（这会自动拦截Guest访问MemberOnly）
-	if err := u.checkViewAccess(userRole, visibility); err != nil {
+	if _, err := u.checkPlaybackAccess(ls, playbackToken, userRole); err != nil {
 		return err
 	}
 
@@ -115,6 +202,18 @@ func (u *LivestreamUsecase) checkChatAccess(userRole role.Role, visibility lives
 	return nil
 }
 
+// streamPushURL builds the RTMP push URL, preferring RTMPS when it is
+// enabled; a revoked key has no push URL
+func (u *LivestreamUsecase) streamPushURL(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	if u.config.RTMP.TLSEnabled {
+		return "rtmps://" + net.JoinHostPort(u.config.RTMP.AdvertisedHost, strconv.Itoa(u.config.RTMP.TLSAdvertisedPort)) + "/" + apiKey
+	}
+	return "rtmp://" + net.JoinHostPort(u.config.RTMP.AdvertisedHost, strconv.Itoa(u.config.RTMP.AdvertisedPort)) + "/" + apiKey
+}
+
 func (u *LivestreamUsecase) GetLivestreamByID(ctx context.Context, id string, userRole role.Role) (*livestreamDTO.LivestreamGetByOwnerIDResponseDTO, error) {
 	if err := u.checkAdminRole(userRole); err != nil {
 		u.Log.Error(ctx, "Unauthorized access to GetLivestreamByID")
@@ -125,18 +224,7 @@ func (u *LivestreamUsecase) GetLivestreamByID(ctx context.Context, id string, us
 		u.Log.Error(ctx, "Error getting livestream by ID: "+err.Error())
 		return nil, err
 	}
-	livestreamResponse := livestreamDTO.LivestreamGetByOwnerIDResponseDTO{
-		UUID:          livestream.UUID,
-		Name:          livestream.Name,
-		Visibility:    livestream.Visibility,
-		Title:         livestream.Title,
-		Information:   livestream.Information,
-		StreamPushURL: "rtmp://" + u.config.Server.RTMPHost + ":1935/" + livestream.APIKey,
-		BanList:       livestream.BanList,
-		MuteList:      livestream.MuteList,
-		IsRecord:      livestream.IsRecord,
-	}
-	return &livestreamResponse, nil
+	return u.toGetByOwnerIDResponse(livestream), nil
 }
 
 func (u *LivestreamUsecase) GetLivestreamByOwnerID(ctx context.Context, ownerID string, userRole role.Role) (*livestreamDTO.LivestreamGetByOwnerIDResponseDTO, error) {
@@ -149,48 +237,135 @@ func (u *LivestreamUsecase) GetLivestreamByOwnerID(ctx context.Context, ownerID
 		u.Log.Error(ctx, "Error getting livestream by owner ID: "+err.Error())
 		return nil, err
 	}
-	livestreamResponse := livestreamDTO.LivestreamGetByOwnerIDResponseDTO{
-		UUID:          livestream.UUID,
-		Name:          livestream.Name,
-		Visibility:    livestream.Visibility,
-		Title:         livestream.Title,
-		Information:   livestream.Information,
-		StreamPushURL: "rtmp://" + u.config.Server.RTMPHost + ":1935/" + livestream.APIKey,
-		BanList:       livestream.BanList,
-		MuteList:      livestream.MuteList,
-		IsRecord:      livestream.IsRecord,
+	return u.toGetByOwnerIDResponse(livestream), nil
+}
+
+// GetFirst backs the deprecated GET /livestream/one of the single stream
+// model: the first listed livestream the user may watch
+func (u *LivestreamUsecase) GetFirst(ctx context.Context, userRole role.Role) (*livestreamDTO.LivestreamGetOneResponseDTO, error) {
+	livestreams, err := u.LivestreamRepo.List()
+	if err != nil {
+		u.Log.Error(ctx, "Error listing livestreams: "+err.Error())
+		return nil, err
+	}
+	if len(livestreams) == 0 {
+		return nil, errors.ErrNotFound
+	}
+	for _, ls := range livestreams {
+		if checkViewAccess(userRole, ls.Visibility) == nil {
+			return u.toGetOneResponse(ls), nil
+		}
 	}
-	return &livestreamResponse, nil
+	u.Log.Warn(ctx, "Unauthorized access to GetFirst, role: "+userRole.String())
+	return nil, errors.ErrUnauthorized
 }
-func (u *LivestreamUsecase) GetOne(ctx context.Context, userRole role.Role) (*livestreamDTO.LivestreamGetOneResponseDTO, error) {
+
+func (u *LivestreamUsecase) GetOne(ctx context.Context, livestreamUUID, playbackToken string, userRole role.Role) (*livestreamDTO.LivestreamGetOneResponseDTO, error) {
 	// 先获取直播信息
-	livestream, err := u.LivestreamRepo.GetOne()
+	livestream, err := u.LivestreamRepo.GetByID(livestreamUUID)
 	if err != nil {
 		u.Log.Error(ctx, "Error getting livestream: "+err.Error())
 		return nil, err
 	}
 	// 根据Visibility检查访问权限
-	if err := u.checkViewAccess(userRole, livestream.Visibility); err != nil {
+	viaShareLink, err := u.checkPlaybackAccess(livestream, playbackToken, userRole)
+	if err != nil {
 		u.Log.Warn(ctx, "Unauthorized access to GetOne, role: "+userRole.String()+", visibility: "+string(livestream.Visibility))
 		return nil, err
 	}
+	response := u.toGetOneResponse(livestream)
+	if viaShareLink {
+		// 分享链接的观众请求播放文件时也要带上token
+		query := "?token=" + url.QueryEscape(playbackToken)
+		response.StreamURL += query
+		response.ThumbnailURL += query
+		if response.MasterURL != "" {
+			response.MasterURL += query
+		}
+		if response.DVRURL != "" {
+			response.DVRURL += query
+		}
+	}
+	return response, nil
+}
+
+// ListLivestreams 返回当前用户有权观看的所有直播
+func (u *LivestreamUsecase) ListLivestreams(ctx context.Context, userRole role.Role) ([]livestreamDTO.LivestreamGetOneResponseDTO, error) {
+	livestreams, err := u.LivestreamRepo.List()
+	if err != nil {
+		u.Log.Error(ctx, "Error listing livestreams: "+err.Error())
+		return nil, err
+	}
+	result := make([]livestreamDTO.LivestreamGetOneResponseDTO, 0, len(livestreams))
+	for _, ls := range livestreams {
+		if err := checkViewAccess(userRole, ls.Visibility); err != nil {
+			continue
+		}
+		result = append(result, *u.toGetOneResponse(ls))
+	}
+	return result, nil
+}
 
-	prefix := "http://"
-	port := ":" + strconv.Itoa(u.config.Server.Port)
-	if u.config.Server.HTTPS {
-		prefix = "https://"
-		port = ""
+// serverURL 返回本服务对外的地址，如 http://localhost:8080
+func serverURL(cfg config.Config) string {
+	if cfg.Server.HTTPS {
+		return "https://" + cfg.Server.Domain
 	}
+	return "http://" + cfg.Server.Domain + ":" + strconv.Itoa(cfg.Server.Port)
+}
 
-	livestreamResponse := livestreamDTO.LivestreamGetOneResponseDTO{
-		UUID:        livestream.UUID,
-		Name:        livestream.Name,
-		Title:       livestream.Title,
-		Information: livestream.Information,
-		StreamURL:   prefix + u.config.Server.Domain + port + "/livestream/" + livestream.UUID + "/playlist.m3u8",
-		Visibility:  livestream.Visibility, // 新增字段
+func (u *LivestreamUsecase) toGetByOwnerIDResponse(livestream *livestream.Livestream) *livestreamDTO.LivestreamGetByOwnerIDResponseDTO {
+	return &livestreamDTO.LivestreamGetByOwnerIDResponseDTO{
+		UUID:               livestream.UUID,
+		Name:               livestream.Name,
+		Visibility:         livestream.Visibility,
+		Title:              livestream.Title,
+		Information:        livestream.Information,
+		StreamPushURL:      u.streamPushURL(livestream.APIKey),
+		BanList:            livestream.BanList,
+		MuteList:           livestream.MuteList,
+		IsRecord:           livestream.IsRecord,
+		LowLatency:         livestream.LowLatency,
+		Renditions:         livestream.Renditions,
+		FragmentDurationMs: livestream.FragmentDurationMs,
+		FragmentNum:        livestream.FragmentNum,
+		CleanupMode:        livestream.CleanupMode,
+		DVRWindowSeconds:   livestream.DVRWindowSeconds,
+		Encrypted:          livestream.Encrypted,
 	}
-	return &livestreamResponse, nil
+}
+
+func (u *LivestreamUsecase) toGetOneResponse(livestream *livestream.Livestream) *livestreamDTO.LivestreamGetOneResponseDTO {
+	baseURL := serverURL(u.config) + "/livestream/" + livestream.UUID
+
+	response := &livestreamDTO.LivestreamGetOneResponseDTO{
+		UUID:         livestream.UUID,
+		Name:         livestream.Name,
+		Title:        livestream.Title,
+		Information:  livestream.Information,
+		StreamURL:    baseURL + "/playlist.m3u8",
+		ThumbnailURL: baseURL + "/thumbnail.jpg",
+		Visibility:   livestream.Visibility, // 新增字段
+	}
+	if len(livestream.Renditions) > 0 {
+		response.MasterURL = baseURL + "/master.m3u8"
+	}
+	if livestream.DVRWindowSeconds > 0 {
+		response.DVRURL = baseURL + "/dvr.m3u8"
+	}
+	// 只有推流端在线时才算直播中，前端据此显示离线状态
+	response.LiveSince = u.liveSince(livestream.UUID)
+	response.IsLive = response.LiveSince != nil
+	return response
+}
+
+// liveSince 返回当前推流开始的时间，未在直播时返回nil
+func (u *LivestreamUsecase) liveSince(livestreamUUID string) *time.Time {
+	status, ok := u.streamService.GetLiveStatus(livestreamUUID)
+	if !ok || status.State != livestream.LiveStatePublishing {
+		return nil
+	}
+	return &status.StartedAt
 }
 
 func (u *LivestreamUsecase) CreateLivestream(ctx context.Context, livestreamData *livestreamDTO.LivestreamCreateDTO, userID string, userRole role.Role) (*livestreamDTO.LivestreamCreateResponseDTO, error) {
@@ -198,13 +373,22 @@ func (u *LivestreamUsecase) CreateLivestream(ctx context.Context, livestreamData
 		u.Log.Error(ctx, "Unauthorized access to CreateLivestream")
 		return nil, err
 	}
-	existing, err := u.LivestreamRepo.GetOne()
+	if err := validateRenditions(livestreamData.Renditions); err != nil {
+		u.Log.Warn(ctx, "Invalid renditions in CreateLivestream")
+		return nil, err
+	}
+	// 每个Owner只能拥有一个直播，Admin可以代替其他Owner创建
+	ownerID := userID
+	if livestreamData.OwnerUserID != "" {
+		ownerID = livestreamData.OwnerUserID
+	}
+	existing, err := u.LivestreamRepo.GetByOwnerID(ownerID)
 	if err != nil && !goErrors.Is(err, errors.ErrNotFound) {
 		u.Log.Error(ctx, "Error checking existing livestream: "+err.Error())
 		return nil, err
 	}
 	if existing != nil {
-		u.Log.Error(ctx, "Livestream already exists")
+		u.Log.Error(ctx, "Livestream already exists for owner: "+ownerID)
 		return nil, errors.ErrExists
 	}
 	apiKey, err := util.GenerateRandomBase64String(16)
@@ -214,29 +398,41 @@ func (u *LivestreamUsecase) CreateLivestream(ctx context.Context, livestreamData
 	}
 	streamUUID := uuid.New().String()
 	livestreamEntity := livestream.Livestream{
-		UUID:        streamUUID,
-		APIKey:      apiKey,
-		OwnerUserId: userID,
-		Name:        livestreamData.Name,
-		Visibility:  livestreamData.Visibility,
-		Title:       livestreamData.Title,
-		Information: livestreamData.Information,
-		BanList:     []string{},
-		MuteList:    []string{},
-		IsRecord:    livestreamData.IsRecord,
+		UUID:               streamUUID,
+		APIKey:             apiKey,
+		OwnerUserId:        ownerID,
+		Name:               livestreamData.Name,
+		Visibility:         livestreamData.Visibility,
+		Title:              livestreamData.Title,
+		Information:        livestreamData.Information,
+		BanList:            []string{},
+		MuteList:           []string{},
+		IsRecord:           livestreamData.IsRecord,
+		LowLatency:         livestreamData.LowLatency,
+		Renditions:         livestreamData.Renditions,
+		FragmentDurationMs: livestreamData.FragmentDurationMs,
+		FragmentNum:        livestreamData.FragmentNum,
+		CleanupMode:        livestreamData.CleanupMode,
+		DVRWindowSeconds:   livestreamData.DVRWindowSeconds,
+		Encrypted:          livestreamData.Encrypted,
+	}
+	if err := applyMuxerSettings(&livestreamEntity); err != nil {
+		u.Log.Warn(ctx, "Invalid muxer settings in CreateLivestream")
+		return nil, err
 	}
 	err = u.LivestreamRepo.Create(&livestreamEntity)
 	if err != nil {
 		u.Log.Error(ctx, "Error creating livestream: "+err.Error())
 		return nil, err
 	}
-	err = u.streamService.OpenStream(livestreamData.Name, streamUUID, apiKey, livestreamData.IsRecord)
+	err = u.streamService.OpenStream(livestreamData.Name, streamUUID, apiKey, StreamOptions(&livestreamEntity))
 	if err != nil {
 		u.Log.Error(ctx, "Error opening stream Service: "+err.Error())
 		return nil, err
 	}
 	return &livestreamDTO.LivestreamCreateResponseDTO{
-		StreamPushURL: "rtmp://" + u.config.Server.RTMPHost + ":1935/" + apiKey,
+		UUID:          streamUUID,
+		StreamPushURL: u.streamPushURL(apiKey),
 	}, nil
 }
 
@@ -245,11 +441,106 @@ func (u *LivestreamUsecase) UpdateLivestream(ctx context.Context, livestream *li
 		u.Log.Error(ctx, "Unauthorized access to UpdateLivestream")
 		return err
 	}
+	if err := validateRenditions(livestream.Renditions); err != nil {
+		u.Log.Warn(ctx, "Invalid renditions in UpdateLivestream")
+		return err
+	}
+	if err := applyMuxerSettings(livestream); err != nil {
+		u.Log.Warn(ctx, "Invalid muxer settings in UpdateLivestream")
+		return err
+	}
 	err := u.LivestreamRepo.Update(livestream)
 	if err != nil {
 		u.Log.Error(ctx, "Error updating livestream: "+err.Error())
 		return err
 	}
+	// 新设置在下一次推流时生效
+	err = u.streamService.UpdateStreamOptions(livestream.UUID, StreamOptions(livestream))
+	if err != nil && !goErrors.Is(err, errors.ErrNotFound) {
+		u.Log.Error(ctx, "Error updating stream options: "+err.Error())
+		return err
+	}
+	return nil
+}
+
+// RotateStreamKey issues a new stream key, optionally dropping the publisher
+// that is connected with the old one
+func (u *LivestreamUsecase) RotateStreamKey(ctx context.Context, livestreamUUID string, dropPublisher bool, userRole role.Role) (*livestreamDTO.LivestreamRotateKeyResponseDTO, error) {
+	if err := u.checkAdminRole(userRole); err != nil {
+		u.Log.Error(ctx, "Unauthorized access to RotateStreamKey")
+		return nil, err
+	}
+	apiKey, err := util.GenerateRandomBase64String(16)
+	if err != nil {
+		u.Log.Error(ctx, "Error generating API key")
+		return nil, err
+	}
+	if err := u.setStreamKey(ctx, livestreamUUID, apiKey, dropPublisher); err != nil {
+		return nil, err
+	}
+	u.Log.Info(ctx, "Stream key rotated for livestream: "+livestreamUUID)
+	return &livestreamDTO.LivestreamRotateKeyResponseDTO{
+		StreamPushURL: u.streamPushURL(apiKey),
+	}, nil
+}
+
+// RevokeStreamKey removes the stream key so nobody can publish until a new
+// key is rotated in. The current publisher is always dropped.
+func (u *LivestreamUsecase) RevokeStreamKey(ctx context.Context, livestreamUUID string, userRole role.Role) error {
+	if err := u.checkAdminRole(userRole); err != nil {
+		u.Log.Error(ctx, "Unauthorized access to RevokeStreamKey")
+		return err
+	}
+	if err := u.setStreamKey(ctx, livestreamUUID, "", true); err != nil {
+		return err
+	}
+	u.Log.Info(ctx, "Stream key revoked for livestream: "+livestreamUUID)
+	return nil
+}
+
+// KickPublisher disconnects the current publisher of the livestream. When
+// blockSeconds is positive, new publishes are refused for that long even with
+// a valid stream key.
+func (u *LivestreamUsecase) KickPublisher(ctx context.Context, livestreamUUID string, blockSeconds int, userRole role.Role) error {
+	if err := u.checkAdminRole(userRole); err != nil {
+		u.Log.Error(ctx, "Unauthorized access to KickPublisher")
+		return err
+	}
+	if blockSeconds < 0 || blockSeconds > maxPublishBlockSeconds {
+		return errors.ErrInvalidInput
+	}
+	if err := u.streamService.KickPublisher(livestreamUUID, time.Duration(blockSeconds)*time.Second); err != nil {
+		u.Log.Error(ctx, "Error kicking publisher: "+err.Error())
+		return err
+	}
+	u.Log.Info(ctx, "Publisher kicked from livestream: "+livestreamUUID)
+	return nil
+}
+
+// setStreamKey writes the key to Postgres and then to the stream registry.
+// Key changes are serialized so both always end up with the same key.
+func (u *LivestreamUsecase) setStreamKey(ctx context.Context, livestreamUUID string, apiKey string, dropPublisher bool) error {
+	u.streamKeyLock.Lock()
+	defer u.streamKeyLock.Unlock()
+
+	livestream, err := u.LivestreamRepo.GetByID(livestreamUUID)
+	if err != nil {
+		u.Log.Error(ctx, "Error getting livestream by ID: "+err.Error())
+		return err
+	}
+	if err := u.LivestreamRepo.UpdateAPIKey(livestreamUUID, apiKey); err != nil {
+		u.Log.Error(ctx, "Error updating API key: "+err.Error())
+		return err
+	}
+	err = u.streamService.UpdateStreamKey(livestreamUUID, apiKey, dropPublisher)
+	if goErrors.Is(err, errors.ErrNotFound) {
+		// The stream was never opened in this process, open it with the new key
+		err = u.streamService.OpenStream(livestream.Name, livestreamUUID, apiKey, StreamOptions(livestream))
+	}
+	if err != nil {
+		u.Log.Error(ctx, "Error updating stream service key: "+err.Error())
+		return err
+	}
 	return nil
 }
 
@@ -268,9 +559,22 @@ func (u *LivestreamUsecase) DeleteLivestream(ctx context.Context, id string, use
 		u.Log.Error(ctx, "Error closing stream: "+err.Error())
 		return err
 	}
+	u.fileCache.StopStream(id)
 	return nil
 }
-func (u *LivestreamUsecase) PingViewerCount(ctx context.Context, userRole role.Role, livestreamUUID string, userID string, anonymousID string) (int, error) {
+
+// GetCacheStats reports the hit, miss and eviction counters of the HLS file
+// cache
+func (u *LivestreamUsecase) GetCacheStats(ctx context.Context, userRole role.Role) (*file_cache.CacheStats, error) {
+	if err := u.checkAdminRole(userRole); err != nil {
+		u.Log.Error(ctx, "Unauthorized access to GetCacheStats")
+		return nil, err
+	}
+	stats := u.fileCache.Stats()
+	return &stats, nil
+}
+
+func (u *LivestreamUsecase) PingViewerCount(ctx context.Context, userRole role.Role, livestreamUUID string, userID string, anonymousID string, playbackToken string) (int, error) {
 	// 获取直播信息以检查Visibility
 	livestream, err := u.LivestreamRepo.GetByID(livestreamUUID)
 	if err != nil {
@@ -279,7 +583,7 @@ func (u *LivestreamUsecase) PingViewerCount(ctx context.Context, userRole role.R
 	}
 
 	// 根据Visibility检查访问权限
-	if err := u.checkViewAccess(userRole, livestream.Visibility); err != nil {
+	if _, err := u.checkPlaybackAccess(livestream, playbackToken, userRole); err != nil {
 		u.Log.Warn(ctx, "Unauthorized access to PingViewerCount, role: "+userRole.String()+", visibility: "+string(livestream.Visibility))
 		return 0, err
 	}
@@ -313,7 +617,7 @@ func (u *LivestreamUsecase) RemoveViewerCount(ctx context.Context, livestreamUUI
 	}
 	return viewerCount, nil
 }
-func (u *LivestreamUsecase) GetChat(ctx context.Context, userRole role.Role, livestreamUUID string, index string) ([]chat.Chat, error) {
+func (u *LivestreamUsecase) GetChat(ctx context.Context, userRole role.Role, livestreamUUID string, index string, playbackToken string) ([]chat.Chat, error) {
 	// 获取直播信息以检查Visibility
 	livestream, err := u.LivestreamRepo.GetByID(livestreamUUID)
 	if err != nil {
@@ -322,7 +626,7 @@ func (u *LivestreamUsecase) GetChat(ctx context.Context, userRole role.Role, liv
 	}
 
 	// 根据Visibility检查访问权限（观看权限即可获取聊天）
-	if err := u.checkViewAccess(userRole, livestream.Visibility); err != nil {
+	if _, err := u.checkPlaybackAccess(livestream, playbackToken, userRole); err != nil {
 		u.Log.Warn(ctx, "Unauthorized access to GetChat, role: "+userRole.String()+", visibility: "+string(livestream.Visibility))
 		return nil, err
 	}
@@ -333,7 +637,7 @@ func (u *LivestreamUsecase) GetChat(ctx context.Context, userRole role.Role, liv
 	}
 	return chats, nil
 }
-func (u *LivestreamUsecase) AddChat(ctx context.Context, identityProvider string, userRole role.Role, livestreamUUID string, chat chat.Chat) error {
+func (u *LivestreamUsecase) AddChat(ctx context.Context, identityProvider string, userRole role.Role, livestreamUUID string, chat chat.Chat, playbackToken string) error {
 	if len(chat.Message) > 100 {
 		return errors.ErrInvalidInput
 	}
@@ -346,7 +650,7 @@ func (u *LivestreamUsecase) AddChat(ctx context.Context, identityProvider string
 	}
 
 	// 根据Visibility检查聊天权限
-	if err := u.checkChatAccess(userRole, livestream.Visibility); err != nil {
+	if err := u.checkChatAccess(livestream, playbackToken, userRole); err != nil {
 		u.Log.Warn(ctx, "Unauthorized access to AddChat, role: "+userRole.String()+", visibility: "+string(livestream.Visibility))
 		return err
 	}
@@ -360,7 +664,7 @@ func (u *LivestreamUsecase) AddChat(ctx context.Context, identityProvider string
 	}
 	return nil
 }
-func (u *LivestreamUsecase) DeleteChat(ctx context.Context, userRole role.Role, currentUserID string, livestreamUUID string, chatID string) error {
+func (u *LivestreamUsecase) DeleteChat(ctx context.Context, userRole role.Role, currentUserID string, livestreamUUID string, chatID string, playbackToken string) error {
 	// 获取直播信息以检查Visibility
 	livestream, err := u.LivestreamRepo.GetByID(livestreamUUID)
 	if err != nil {
@@ -369,7 +673,7 @@ func (u *LivestreamUsecase) DeleteChat(ctx context.Context, userRole role.Role,
 	}
 
 	// 根据Visibility检查访问权限（需要有观看权限才能删除聊天）
-	if err := u.checkViewAccess(userRole, livestream.Visibility); err != nil {
+	if _, err := u.checkPlaybackAccess(livestream, playbackToken, userRole); err != nil {
 		u.Log.Warn(ctx, "Unauthorized access to DeleteChat, role: "+userRole.String()+", visibility: "+string(livestream.Visibility))
 		return err
 	}
@@ -428,7 +732,7 @@ func (u *LivestreamUsecase) DeleteChat(ctx context.Context, userRole role.Role,
 	u.Log.Error(ctx, "Unauthorized access to DeleteChat")
 	return errors.ErrUnauthorized
 }
-func (u *LivestreamUsecase) GetDeleteChatIDs(ctx context.Context, userRole role.Role, livestreamUUID string) ([]string, error) {
+func (u *LivestreamUsecase) GetDeleteChatIDs(ctx context.Context, userRole role.Role, livestreamUUID string, playbackToken string) ([]string, error) {
 	// 获取直播信息
 	livestream, err := u.LivestreamRepo.GetByID(livestreamUUID)
 	if err != nil {
@@ -437,7 +741,7 @@ func (u *LivestreamUsecase) GetDeleteChatIDs(ctx context.Context, userRole role.
 	}
 
 	// 检查观看权限（与GetChat保持一致）
-	if err := u.checkViewAccess(userRole, livestream.Visibility); err != nil {
+	if _, err := u.checkPlaybackAccess(livestream, playbackToken, userRole); err != nil {
 		u.Log.Warn(ctx, "Unauthorized access to GetDeleteChatIDs, role: "+userRole.String()+", visibility: "+string(livestream.Visibility))
 		return nil, err
 	}
@@ -482,7 +786,10 @@ func (u *LivestreamUsecase) MuteUser(ctx context.Context, identityProvider strin
 	}
 	return nil
 }
-func (u *LivestreamUsecase) GetFile(ctx context.Context, rootPath, uuidStr, filename string, userRole role.Role) ([]byte, error) {
+
+// GetFile serves the live HLS files. For an LL-HLS playlist, reload carries
+// the _HLS_msn/_HLS_part directives of a blocking playlist reload.
+func (u *LivestreamUsecase) GetFile(ctx context.Context, rootPath, uuidStr, filename string, reload livestreamDTO.LivestreamPlaylistReloadDTO, playbackToken string, userRole role.Role) (*livestreamDTO.LivestreamFileDTO, error) {
 	// 1. Strictly validate UUID (external input)
 	if err := util.ValidateUUID(uuidStr); err != nil {
 		u.Log.Warn(ctx, "Invalid UUID in GetFile: "+uuidStr)
@@ -502,132 +809,350 @@ func (u *LivestreamUsecase) GetFile(ctx context.Context, rootPath, uuidStr, file
 		return nil, errors.ErrNotFound
 	}
 
+	// _HLS_part is only valid together with _HLS_msn
+	if (reload.Part != nil && reload.MSN == nil) || (reload.MSN != nil && *reload.MSN < 0) || (reload.Part != nil && *reload.Part < 0) {
+		u.Log.Warn(ctx, "Invalid playlist reload directives in GetFile")
+		return nil, errors.ErrInvalidInput
+	}
+
 	// 4. After validation, combine trusted rootPath with validated parameters
 	// No need to validate the combined path - it's guaranteed to be safe
 	filePath := filepath.Join(rootPath, "hls", uuidStr, filename)
 
 	// 5. Check livestream visibility
-	livestream, err := u.LivestreamRepo.GetOne()
+	livestream, err := u.LivestreamRepo.GetByID(uuidStr)
 	if err != nil {
 		u.Log.Error(ctx, "Error getting livestream: "+err.Error())
 		return nil, err
 	}
 
-	// 6. Check access permission based on visibility
-	if err := u.checkViewAccess(userRole, livestream.Visibility); err != nil {
+	// 6. Check access permission based on visibility, or the share link token
+	viaShareLink, err := u.checkPlaybackAccess(livestream, playbackToken, userRole)
+	if err != nil {
 		u.Log.Warn(ctx, "Unauthorized access to GetFile, role: "+userRole.String()+", visibility: "+string(livestream.Visibility))
 		return nil, err
 	}
-
-	// 7. Read file with caching
+	// Encrypted livestreams are encrypted as they are served, so the files on
+	// disk stay plain for recording. Share link viewers need the token on
+	// every URI of a playlist, key URIs included. The cache keeps playlists
+	// as written; segments never change, so those of encrypted livestreams
+	// are encrypted once per key and cached as served instead.
 	ext := filepath.Ext(filename)
+	encryptedCacheKey := ""
+	if livestream.Encrypted && ext == ".ts" {
+		keyID, err := u.hlsKeyStore.KeyID(uuidStr, filename)
+		if err != nil {
+			u.Log.Error(ctx, "Error getting key of "+filename+": "+err.Error())
+			return nil, err
+		}
+		encryptedCacheKey = filePath + "#" + keyID
+	}
+	serve := func(data []byte) (*livestreamDTO.LivestreamFileDTO, error) {
+		if livestream.Encrypted {
+			encrypted, err := u.encryptHLS(uuidStr, filename, data)
+			if err != nil {
+				u.Log.Error(ctx, "Error encrypting "+filename+": "+err.Error())
+				return nil, err
+			}
+			data = encrypted
+			if encryptedCacheKey != "" {
+				u.fileCache.StoreCache(encryptedCacheKey, data)
+			}
+		}
+		return u.fileDTO(livestream, filePath, filename, data, playbackToken, viaShareLink), nil
+	}
+
+	// 7. Blocking playlist reload: hold the request until the playlist has
+	// the requested part
+	if filename == "playlist.m3u8" && reload.MSN != nil {
+		part := -1
+		if reload.Part != nil {
+			part = *reload.Part
+		}
+		err := u.streamService.WaitLowLatencyPart(ctx, uuidStr, *reload.MSN, part)
+		if err != nil && !goErrors.Is(err, errors.ErrNotFound) {
+			u.Log.Warn(ctx, "Blocking playlist reload failed: "+err.Error())
+			return nil, err
+		}
+		// ErrNotFound: not publishing in low-latency mode, serve the playlist as is
+	}
+
+	// 8. Read file with caching. A playlist is locked on its own so an update
+	// event cannot be overtaken by a slower read of the old content.
 	if ext == ".m3u8" {
-		u.m3u8Lock.Lock()
-		defer u.m3u8Lock.Unlock()
+		unlock := u.fileCache.LockFile(filePath)
+		defer unlock()
 	}
 
-	if data, ok := u.fileCache.LoadCache(filePath); ok {
-		return data, nil
+	if encryptedCacheKey != "" {
+		if data, ok := u.fileCache.LoadCache(encryptedCacheKey); ok {
+			return u.fileDTO(livestream, filePath, filename, data, playbackToken, viaShareLink), nil
+		}
+	} else if data, ok := u.fileCache.LoadCache(filePath); ok {
+		return serve(data)
 	}
 
 	fileData, err := u.fileCache.ReadFile(filePath)
+	if err != nil && ext == ".ts" {
+		// The part named by EXT-X-PRELOAD-HINT is still being written
+		if waitErr := u.streamService.WaitPreloadHint(ctx, uuidStr, filename); waitErr == nil {
+			fileData, err = u.fileCache.ReadFile(filePath)
+		}
+	}
 	if err != nil {
 		u.Log.Error(ctx, "Error reading file: "+err.Error())
 		return nil, err
 	}
 
-	u.fileCache.StoreCache(filePath, fileData)
+	if encryptedCacheKey == "" {
+		u.fileCache.StoreCache(filePath, fileData)
+	}
+	// Playlists rewritten without update events, such as the ABR ones ffmpeg
+	// writes, are refreshed by the stream's watcher
+	u.fileCache.WatchStream(uuidStr, filepath.Join(rootPath, "hls", uuidStr))
 
-	if ext == ".m3u8" {
-		go u.updateCachePeriodically(filePath)
+	return serve(fileData)
+}
+
+// fileDTO returns a file as served, with the share link token on every URI
+// of a playlist
+func (u *LivestreamUsecase) fileDTO(ls *livestream.Livestream, filePath, filename string, data []byte, playbackToken string, viaShareLink bool) *livestreamDTO.LivestreamFileDTO {
+	if viaShareLink && filepath.Ext(filename) == ".m3u8" {
+		data = util.AppendPlaylistQuery(data, "token="+url.QueryEscape(playbackToken))
+	}
+	// Unknown when the file was deleted since it was cached
+	modTime, _ := u.fileCache.ModTime(filePath)
+	return &livestreamDTO.LivestreamFileDTO{
+		Data:    data,
+		ModTime: modTime,
+		Public:  isPublicPlayback(ls, viaShareLink),
 	}
+}
 
-	return fileData, nil
+// encryptHLS encrypts a segment, or points every segment of a playlist at
+// its key
+func (u *LivestreamUsecase) encryptHLS(uuidStr, filename string, data []byte) ([]byte, error) {
+	if filepath.Ext(filename) == ".ts" {
+		keyID, err := u.hlsKeyStore.KeyID(uuidStr, filename)
+		if err != nil {
+			return nil, err
+		}
+		key, err := u.hlsKeyStore.Key(uuidStr, keyID)
+		if err != nil {
+			return nil, err
+		}
+		return util.EncryptSegment(data, key, util.SegmentIV(filename))
+	}
+	keyURL := serverURL(u.config) + "/livestream/" + uuidStr + "/key/"
+	return util.InsertSegmentKeys(data, func(uri string) (string, []byte, error) {
+		// Segment URIs are relative to the playlist
+		segment := filepath.Join(filepath.Dir(filename), uri)
+		keyID, err := u.hlsKeyStore.KeyID(uuidStr, segment)
+		if err != nil {
+			return "", nil, err
+		}
+		return keyURL + keyID, util.SegmentIV(segment), nil
+	})
+}
+
+// GetKey hands out an HLS encryption key to the viewers allowed to watch
+// the livestream, the same ones GetFile serves
+func (u *LivestreamUsecase) GetKey(ctx context.Context, livestreamUUID, keyID, playbackToken, identityProvider, userID string, userRole role.Role) ([]byte, error) {
+	if err := util.ValidateUUID(livestreamUUID); err != nil {
+		u.Log.Warn(ctx, "Invalid UUID in GetKey: "+livestreamUUID)
+		return nil, errors.ErrInvalidInput
+	}
+	livestream, err := u.LivestreamRepo.GetByID(livestreamUUID)
+	if err != nil {
+		u.Log.Error(ctx, "Error getting livestream: "+err.Error())
+		return nil, err
+	}
+	if _, err := u.checkPlaybackAccess(livestream, playbackToken, userRole); err != nil {
+		u.Log.Warn(ctx, "Unauthorized access to GetKey, role: "+userRole.String()+", visibility: "+string(livestream.Visibility))
+		return nil, err
+	}
+	// 被封禁的用户拿不到密钥，也就无法解密片段
+	if userID != "" && slices.Contains(livestream.BanList, identityProvider+"-"+userID) {
+		u.Log.Warn(ctx, "Banned user requested a key of livestream "+livestreamUUID)
+		return nil, errors.ErrUnauthorized
+	}
+	if !livestream.Encrypted {
+		return nil, errors.ErrNotFound
+	}
+	key, err := u.hlsKeyStore.Key(livestreamUUID, keyID)
+	if err != nil {
+		if err != errors.ErrNotFound && err != errors.ErrInvalidInput {
+			u.Log.Error(ctx, "Error reading key: "+err.Error())
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// segmentLifetime is how long a segment of the livestream stays in its
+// playlists: the DVR window, or else the live playlist
+func segmentLifetime(ls *livestream.Livestream) time.Duration {
+	if ls.DVRWindowSeconds > 0 {
+		return time.Duration(ls.DVRWindowSeconds) * time.Second
+	}
+	fragmentDurationMs, fragmentNum := ls.FragmentDurationMs, ls.FragmentNum
+	if fragmentDurationMs == 0 {
+		fragmentDurationMs = livestream.DefaultFragmentDurationMs
+	}
+	if fragmentNum == 0 {
+		fragmentNum = livestream.DefaultFragmentNum
+	}
+	return time.Duration(fragmentDurationMs*fragmentNum) * time.Millisecond
+}
+
+// PruneHLSKeys deletes the encryption keys of every livestream that no
+// segment still in its playlists was encrypted with
+func (u *LivestreamUsecase) PruneHLSKeys(ctx context.Context) {
+	livestreams, err := u.LivestreamRepo.List()
+	if err != nil {
+		u.Log.Error(ctx, "Error listing livestreams: "+err.Error())
+		return
+	}
+	// Livestreams no longer encrypted may still hold keys
+	for _, ls := range livestreams {
+		if err := u.hlsKeyStore.Prune(ls.UUID, segmentLifetime(ls)); err != nil {
+			u.Log.Error(ctx, "Error pruning HLS keys of "+ls.UUID+": "+err.Error())
+		}
+	}
 }
-func (u *LivestreamUsecase) GetRecord(ctx context.Context, rootPath, livestreamUUID string, userRole role.Role) (string, error) {
+
+// SubscribeLiveFLV adds an HTTP-FLV viewer to a livestream that is being
+// published. The caller must Close the subscription when the viewer leaves.
+func (u *LivestreamUsecase) SubscribeLiveFLV(ctx context.Context, uuidStr, playbackToken string, userRole role.Role) (stream.FlvSubscription, error) {
+	if err := util.ValidateUUID(uuidStr); err != nil {
+		u.Log.Warn(ctx, "Invalid UUID in SubscribeLiveFLV: "+uuidStr)
+		return nil, errors.ErrInvalidInput
+	}
+
+	livestream, err := u.LivestreamRepo.GetByID(uuidStr)
+	if err != nil {
+		u.Log.Error(ctx, "Error getting livestream: "+err.Error())
+		return nil, err
+	}
+
+	// Same visibility rules as the HLS files
+	if _, err := u.checkPlaybackAccess(livestream, playbackToken, userRole); err != nil {
+		u.Log.Warn(ctx, "Unauthorized access to SubscribeLiveFLV, role: "+userRole.String()+", visibility: "+string(livestream.Visibility))
+		return nil, err
+	}
+
+	subscription, err := u.streamService.SubscribeFLV(uuidStr)
+	if err != nil {
+		// Not being published right now
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// GetRecord returns the MP4 of a recording, the latest one that has ended
+// unless recordingID names one. The conversion is queued when the publish
+// ends; should the MP4 be missing anyway it is queued again and ErrNotFound
+// returned. GetRecordStatus follows the conversion.
+func (u *LivestreamUsecase) GetRecord(ctx context.Context, rootPath, livestreamUUID, recordingID string, userRole role.Role) (string, error) {
 	// 1. Check admin role
 	if err := u.checkAdminRole(userRole); err != nil {
 		u.Log.Error(ctx, "Unauthorized access to GetRecord")
 		return "", err
 	}
 
-	// 2. Strictly validate UUID (external input)
-	if err := util.ValidateUUID(livestreamUUID); err != nil {
-		u.Log.Warn(ctx, "Invalid UUID in GetRecord: "+livestreamUUID)
-		return "", errors.ErrInvalidInput
+	// 2. Strictly validate UUIDs (external input)
+	if err := validateRecordIDs(livestreamUUID, recordingID); err != nil {
+		u.Log.Warn(ctx, "Invalid ID in GetRecord: "+livestreamUUID+" "+recordingID)
+		return "", err
 	}
 
-	// 3. After validation, combine trusted rootPath with validated UUID
-	// No need to validate the combined path - it's guaranteed to be safe
-	filePath := filepath.Join(rootPath, "hls", livestreamUUID, "*.mp4")
+	// 3. Every publish is recorded into a session directory of its own
+	recording, err := u.findRecording(livestreamUUID, recordingID)
+	if err != nil {
+		return "", err
+	}
+	recordingDir := util.RecordingDir(filepath.Join(rootPath, "hls", livestreamUUID), recording.ID)
+	filePath := filepath.Join(recordingDir, "*.mp4")
 
 	fullFilePath, err := u.fileCache.GetSingleFileName(filePath)
 	if err != nil {
-		u.Log.Error(ctx, "Error getting single file name: "+err.Error())
-
-		// Try to acquire the lock for conversion.
-		if !u.convertTaskLock.TryLock() {
-			return "", errors.ErrNotFound
+		// 4. 排队转换；已在排队或转换中的任务不会重复加入，失败的任务会重新开始
+		if err := u.ConversionJobRepo.Enqueue(newConversionJob(recording, time.Now())); err != nil {
+			u.Log.Error(ctx, "Error queueing conversion: "+err.Error())
+			return "", err
 		}
-
-		// Construct the source file as "record.m3u8" in the same directory
-		recordPath := filepath.Join(rootPath, "hls", livestreamUUID, "record.m3u8")
-		go func() {
-			defer u.convertTaskLock.Unlock()
-			u.Log.Info(ctx, "Converting stream to mp4: from "+recordPath+" to "+filePath)
-			livestream, err := u.LivestreamRepo.GetByID(livestreamUUID)
-			if err != nil {
-				u.Log.Error(ctx, "Error getting livestream by ID: "+err.Error())
-				return
-			}
-			err = u.ffmpegLibrary.ConvertStreamToMp4(recordPath, livestream.Title)
-			if err != nil {
-				u.Log.Error(ctx, "Error converting stream to mp4: "+err.Error())
-			}
-		}()
 		return "", errors.ErrNotFound
 	}
 	return fullFilePath, nil
 }
-func (u *LivestreamUsecase) updateCachePeriodically(filePath string) {
-	for {
-		time.Sleep(1 * time.Second)
-		u.m3u8Lock.Lock()
-		fileData, err := u.fileCache.ReadFile(filePath)
-		if err == nil {
-			u.fileCache.StoreCache(filePath, fileData)
+
+// GetRecordStatus reports the conversion of a recording to MP4, the latest
+// one that has ended unless recordingID names one. It is ErrNotFound until
+// GetRecord asked for the MP4.
+func (u *LivestreamUsecase) GetRecordStatus(ctx context.Context, livestreamUUID, recordingID string, userRole role.Role) (*livestream.ConversionJob, error) {
+	if err := u.checkAdminRole(userRole); err != nil {
+		u.Log.Error(ctx, "Unauthorized access to GetRecordStatus")
+		return nil, err
+	}
+	if err := validateRecordIDs(livestreamUUID, recordingID); err != nil {
+		u.Log.Warn(ctx, "Invalid ID in GetRecordStatus: "+livestreamUUID+" "+recordingID)
+		return nil, err
+	}
+	recording, err := u.findRecording(livestreamUUID, recordingID)
+	if err != nil {
+		return nil, err
+	}
+	job, err := u.ConversionJobRepo.GetByRecordingID(recording.ID)
+	if err != nil {
+		if err != errors.ErrNotFound {
+			u.Log.Error(ctx, "Error getting conversion job: "+err.Error())
 		}
-		u.m3u8Lock.Unlock()
+		return nil, err
 	}
+	return job, nil
 }
 
-func (u *LivestreamUsecase) startCacheCleanup() {
-	for {
-		time.Sleep(10 * time.Second) // Run cleanup every 10 seconds
-		now := time.Now().UnixMilli()
-
-		u.fileCache.Range(func(key, value any) bool {
-			filePath := key.(string)
-			filename := filepath.Base(filePath)
-
-			// Extract timestamp from filename
-			parts := strings.Split(filename, "-")
-			if len(parts) < 3 {
-				return true // Continue to next item
-			}
-
-			timestampStr := parts[1]
-			timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
-			if err != nil {
-				return true // Continue to next item
-			}
-
-			// Check if the file is older than 30 seconds
-			if now-timestamp > 30000 {
-				u.fileCache.DeleteFile(filePath)
-			}
+// validateRecordIDs checks the livestream UUID and the optional recording ID
+// of a record request
+func validateRecordIDs(livestreamUUID, recordingID string) error {
+	if err := util.ValidateUUID(livestreamUUID); err != nil {
+		return errors.ErrInvalidInput
+	}
+	if recordingID != "" {
+		if err := util.ValidateUUID(recordingID); err != nil {
+			return errors.ErrInvalidInput
+		}
+	}
+	return nil
+}
 
-			return true // Continue to next item
-		})
+// findRecording returns the named recording of a livestream, or the latest
+// one. Either must not be written anymore.
+func (u *LivestreamUsecase) findRecording(livestreamUUID, recordingID string) (*livestream.Recording, error) {
+	if recordingID != "" {
+		recording, err := u.RecordingRepo.GetByID(recordingID)
+		if err != nil {
+			return nil, err
+		}
+		if recording.LivestreamUUID != livestreamUUID || !recordingEnded(recording) {
+			return nil, errors.ErrNotFound
+		}
+		return recording, nil
+	}
+	recordings, err := u.RecordingRepo.ListByLivestream(livestreamUUID)
+	if err != nil {
+		return nil, err
 	}
+	for _, recording := range recordings {
+		if recordingEnded(recording) {
+			return recording, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+// recordingEnded tells whether a recording is complete and has something to
+// play
+func recordingEnded(recording *livestream.Recording) bool {
+	return recording.Status != livestream.RecordingStatusRecording && recording.Status != livestream.RecordingStatusFailed
 }
@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"Go-Service/src/main/application/interface/jwt"
+	"Go-Service/src/main/application/interface/repository"
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	goErrors "errors"
+	"sync"
+	"time"
+
+	"github.com/cool9850311/StreamPlatformLite-Core/pkg/role"
+)
+
+// shareLinkCacheTTL bounds how long a revoked share link keeps working,
+// sparing a database round trip on every segment request
+const shareLinkCacheTTL = 10 * time.Second
+
+// playbackAccess decides who may watch a livestream: checkViewAccess, plus
+// the holders of a valid share link token of a Link livestream
+type playbackAccess struct {
+	shareLinkRepo repository.ShareLinkRepository
+	jwtGenerator  jwt.JWTGenerator
+	secretKey     string
+
+	mu    sync.Mutex
+	links map[string]shareLinkLookup
+}
+
+// shareLinkLookup is a cached share link; a nil link means it was revoked
+type shareLinkLookup struct {
+	link    *livestream.ShareLink
+	expires time.Time
+}
+
+func newPlaybackAccess(shareLinkRepo repository.ShareLinkRepository, jwtGenerator jwt.JWTGenerator, secretKey string) *playbackAccess {
+	return &playbackAccess{
+		shareLinkRepo: shareLinkRepo,
+		jwtGenerator:  jwtGenerator,
+		secretKey:     secretKey,
+		links:         make(map[string]shareLinkLookup),
+	}
+}
+
+// check 在checkViewAccess之外，允许持有有效分享链接token的人观看Link直播。
+// 返回true表示是通过分享链接获得的权限
+func (a *playbackAccess) check(ls *livestream.Livestream, playbackToken string, userRole role.Role) (bool, error) {
+	if ls.Visibility != livestream.Link || playbackToken == "" || userRole == role.Admin {
+		return false, checkViewAccess(userRole, ls.Visibility)
+	}
+	claims, err := a.jwtGenerator.ParsePlaybackToken(playbackToken, a.secretKey)
+	if err != nil || claims.Subject != ls.UUID {
+		return false, errors.ErrUnauthorized
+	}
+	link, err := a.shareLink(claims.LinkID)
+	if err != nil {
+		return false, err
+	}
+	if link == nil || link.LivestreamUUID != ls.UUID || time.Now().After(link.ExpiresAt) {
+		return false, errors.ErrUnauthorized
+	}
+	return true, nil
+}
+
+// shareLink looks a share link up, remembering the answer for
+// shareLinkCacheTTL; revoked links are deleted, so they come back nil
+func (a *playbackAccess) shareLink(id string) (*livestream.ShareLink, error) {
+	now := time.Now()
+	a.mu.Lock()
+	cached, ok := a.links[id]
+	a.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.link, nil
+	}
+
+	link, err := a.shareLinkRepo.GetByID(id)
+	if goErrors.Is(err, errors.ErrNotFound) {
+		link, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// Drop the stale entries so tokens of old links don't pile up
+	for key, lookup := range a.links {
+		if !now.Before(lookup.expires) {
+			delete(a.links, key)
+		}
+	}
+	a.links[id] = shareLinkLookup{link: link, expires: now.Add(shareLinkCacheTTL)}
+	return link, nil
+}
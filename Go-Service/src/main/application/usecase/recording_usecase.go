@@ -0,0 +1,297 @@
+package usecase
+
+import (
+	livestreamDTO "Go-Service/src/main/application/dto/livestream"
+	"Go-Service/src/main/application/interface/repository"
+	"Go-Service/src/main/application/interface/stream"
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/domain/interface/logger"
+	"Go-Service/src/main/domain/interface/recording"
+	"Go-Service/src/main/infrastructure/util"
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/cool9850311/StreamPlatformLite-Core/pkg/role"
+)
+
+// RecordingUsecase keeps the recordings catalog in step with the recorded
+// publishes, one recording per publish, queues their conversion to MP4 once
+// they end and lets admins inspect and delete them. Ended recordings admins
+// have published are played back over HLS as VOD.
+type RecordingUsecase struct {
+	RecordingRepo     repository.RecordingRepository
+	LivestreamRepo    repository.LivestreamRepository
+	ConversionJobRepo repository.ConversionJobRepository
+	Log               logger.Logger
+	recordingStore    recording.RecordingStore
+}
+
+func NewRecordingUsecase(recordingRepo repository.RecordingRepository, livestreamRepo repository.LivestreamRepository, conversionJobRepo repository.ConversionJobRepository, log logger.Logger, recordingStore recording.RecordingStore) *RecordingUsecase {
+	return &RecordingUsecase{
+		RecordingRepo:     recordingRepo,
+		LivestreamRepo:    livestreamRepo,
+		ConversionJobRepo: conversionJobRepo,
+		Log:               log,
+		recordingStore:    recordingStore,
+	}
+}
+
+// CloseDanglingRecordings marks the recordings cut off by a previous process
+// as interrupted and ends their playlists. What they wrote until then stays
+// playable and is converted like any other recording. It must run before the
+// ingest service accepts publishers.
+func (u *RecordingUsecase) CloseDanglingRecordings(ctx context.Context) error {
+	open, err := u.RecordingRepo.ListByStatus(livestream.RecordingStatusRecording)
+	if err != nil {
+		u.Log.Error(ctx, "Error listing dangling recordings: "+err.Error())
+		return err
+	}
+	now := time.Now()
+	for _, rec := range open {
+		if err := u.finish(ctx, rec, now, livestream.RecordingStatusInterrupted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandlePublishEvent adds a recording when a recorded publish starts and
+// totals it up when the publish stops
+func (u *RecordingUsecase) HandlePublishEvent(event stream.PublishEvent) {
+	if !event.Recorded {
+		return
+	}
+	ctx := context.Background()
+	switch event.Type {
+	case stream.PublishStarted:
+		rec := &livestream.Recording{
+			ID:             event.SessionID,
+			LivestreamUUID: event.UUID,
+			StartedAt:      event.At,
+			Status:         livestream.RecordingStatusRecording,
+			Visibility:     livestream.Private,
+		}
+		if err := u.RecordingRepo.Create(rec); err != nil {
+			u.Log.Error(ctx, "Error creating recording: "+err.Error())
+		}
+	case stream.PublishStopped:
+		rec, err := u.RecordingRepo.GetByID(event.SessionID)
+		if err != nil {
+			u.Log.Error(ctx, "Error loading recording "+event.SessionID+": "+err.Error())
+			return
+		}
+		u.finish(ctx, rec, event.At, livestream.RecordingStatusFinished)
+	}
+}
+
+// finish ends a recording with the totals of what it wrote and queues its
+// conversion. A recording without a single segment failed.
+func (u *RecordingUsecase) finish(ctx context.Context, rec *livestream.Recording, endedAt time.Time, status livestream.RecordingStatus) error {
+	// 正常结束时推流端已写入 EXT-X-ENDLIST，被中断的录制由这里补上
+	if status == livestream.RecordingStatusInterrupted {
+		if err := u.recordingStore.Finalize(rec.LivestreamUUID, rec.ID); err != nil && err != errors.ErrNotFound {
+			u.Log.Error(ctx, "Error ending playlist of recording "+rec.ID+": "+err.Error())
+		}
+	}
+	summary, err := u.recordingStore.Summarize(rec.LivestreamUUID, rec.ID)
+	if err != nil {
+		u.Log.Warn(ctx, "Recording "+rec.ID+" has nothing to play: "+err.Error())
+		status = livestream.RecordingStatusFailed
+	}
+	rec.EndedAt = &endedAt
+	rec.DurationSeconds = summary.DurationSeconds
+	rec.SizeBytes = summary.SizeBytes
+	rec.Status = status
+	if err := u.RecordingRepo.Update(rec); err != nil {
+		u.Log.Error(ctx, "Error ending recording "+rec.ID+": "+err.Error())
+		return err
+	}
+	if status == livestream.RecordingStatusFailed {
+		return nil
+	}
+	// The MP4 is ready by the time anyone asks for it. Should this fail,
+	// GetRecord queues it on demand.
+	if err := u.ConversionJobRepo.Enqueue(newConversionJob(rec, time.Now())); err != nil {
+		u.Log.Error(ctx, "Error queueing conversion of recording "+rec.ID+": "+err.Error())
+	}
+	return nil
+}
+
+// withLiveTotals fills in the totals of a recording still being written
+func (u *RecordingUsecase) withLiveTotals(rec *livestream.Recording) *livestream.Recording {
+	if rec.Status != livestream.RecordingStatusRecording {
+		return rec
+	}
+	if summary, err := u.recordingStore.Summarize(rec.LivestreamUUID, rec.ID); err == nil {
+		rec.DurationSeconds = summary.DurationSeconds
+		rec.SizeBytes = summary.SizeBytes
+	}
+	return rec
+}
+
+// ListRecordings returns the recordings of a livestream, newest first
+func (u *RecordingUsecase) ListRecordings(ctx context.Context, livestreamUUID string, userRole role.Role) ([]*livestream.Recording, error) {
+	if userRole != role.Admin {
+		u.Log.Error(ctx, "Unauthorized access to ListRecordings")
+		return nil, errors.ErrUnauthorized
+	}
+	if _, err := u.LivestreamRepo.GetByID(livestreamUUID); err != nil {
+		return nil, err
+	}
+	recordings, err := u.RecordingRepo.ListByLivestream(livestreamUUID)
+	if err != nil {
+		u.Log.Error(ctx, "Error listing recordings: "+err.Error())
+		return nil, err
+	}
+	for _, rec := range recordings {
+		u.withLiveTotals(rec)
+	}
+	return recordings, nil
+}
+
+func (u *RecordingUsecase) GetRecording(ctx context.Context, livestreamUUID, recordingID string, userRole role.Role) (*livestream.Recording, error) {
+	if userRole != role.Admin {
+		u.Log.Error(ctx, "Unauthorized access to GetRecording")
+		return nil, errors.ErrUnauthorized
+	}
+	rec, err := u.RecordingRepo.GetByID(recordingID)
+	if err != nil {
+		return nil, err
+	}
+	if rec.LivestreamUUID != livestreamUUID {
+		return nil, errors.ErrNotFound
+	}
+	return u.withLiveTotals(rec), nil
+}
+
+// DeleteRecording removes a recording with its files. A recording still
+// being written cannot be deleted.
+func (u *RecordingUsecase) DeleteRecording(ctx context.Context, livestreamUUID, recordingID string, userRole role.Role) error {
+	if userRole != role.Admin {
+		u.Log.Error(ctx, "Unauthorized access to DeleteRecording")
+		return errors.ErrUnauthorized
+	}
+	rec, err := u.RecordingRepo.GetByID(recordingID)
+	if err != nil {
+		return err
+	}
+	if rec.LivestreamUUID != livestreamUUID {
+		return errors.ErrNotFound
+	}
+	if rec.Status == livestream.RecordingStatusRecording {
+		u.Log.Warn(ctx, "Refused to delete recording "+recordingID+" while it is being written")
+		return errors.ErrInvalidInput
+	}
+	// The row goes last, so a failed delete can be retried
+	if err := u.recordingStore.Delete(rec.LivestreamUUID, rec.ID); err != nil {
+		u.Log.Error(ctx, "Error deleting recording files: "+err.Error())
+		return err
+	}
+	if err := u.RecordingRepo.Delete(rec.ID); err != nil {
+		u.Log.Error(ctx, "Error deleting recording: "+err.Error())
+		return err
+	}
+	return nil
+}
+
+// UpdateRecordingVisibility sets who may play a recording back. Share links
+// belong to livestreams, so a recording cannot be link only.
+func (u *RecordingUsecase) UpdateRecordingVisibility(ctx context.Context, livestreamUUID, recordingID string, visibility livestream.Visibility, userRole role.Role) (*livestream.Recording, error) {
+	if userRole != role.Admin {
+		u.Log.Error(ctx, "Unauthorized access to UpdateRecordingVisibility")
+		return nil, errors.ErrUnauthorized
+	}
+	switch visibility {
+	case livestream.Public, livestream.MemberOnly, livestream.Private:
+	default:
+		return nil, errors.ErrInvalidInput
+	}
+	rec, err := u.RecordingRepo.GetByID(recordingID)
+	if err != nil {
+		return nil, err
+	}
+	if rec.LivestreamUUID != livestreamUUID {
+		return nil, errors.ErrNotFound
+	}
+	if err := u.RecordingRepo.UpdateVisibility(rec.ID, visibility); err != nil {
+		u.Log.Error(ctx, "Error updating recording visibility: "+err.Error())
+		return nil, err
+	}
+	rec.Visibility = visibility
+	return u.withLiveTotals(rec), nil
+}
+
+// ListVODs returns the ended recordings of a livestream the user may play
+// back, newest first. Encrypted livestreams have none: their recordings
+// are written in plaintext.
+func (u *RecordingUsecase) ListVODs(ctx context.Context, livestreamUUID string, userRole role.Role) ([]*livestream.Recording, error) {
+	ls, err := u.LivestreamRepo.GetByID(livestreamUUID)
+	if err != nil {
+		return nil, err
+	}
+	if ls.Encrypted {
+		return []*livestream.Recording{}, nil
+	}
+	recordings, err := u.RecordingRepo.ListByLivestream(livestreamUUID)
+	if err != nil {
+		u.Log.Error(ctx, "Error listing recordings: "+err.Error())
+		return nil, err
+	}
+	vods := make([]*livestream.Recording, 0, len(recordings))
+	for _, rec := range recordings {
+		if !recordingEnded(rec) || checkViewAccess(userRole, rec.Visibility) != nil {
+			continue
+		}
+		vods = append(vods, rec)
+	}
+	return vods, nil
+}
+
+// GetVODFile returns the playlist or a segment of an ended recording.
+// Recordings still being written are not VOD yet and, like recordings the
+// user may not watch, are not found.
+func (u *RecordingUsecase) GetVODFile(ctx context.Context, recordingID, filename string, userRole role.Role) (*livestreamDTO.VODFileDTO, error) {
+	// 1. Validate the inputs before they reach the file system
+	if err := util.ValidateUUID(recordingID); err != nil {
+		return nil, errors.ErrInvalidInput
+	}
+	if err := util.ValidateHLSFilename(filename, []string{".m3u8", ".ts"}); err != nil {
+		return nil, errors.ErrInvalidInput
+	}
+	if filepath.Ext(filename) == ".m3u8" && filename != util.RecordPlaylistName {
+		return nil, errors.ErrInvalidInput
+	}
+
+	// 2. 录制结束且有权限观看
+	rec, err := u.RecordingRepo.GetByID(recordingID)
+	if err != nil {
+		return nil, err
+	}
+	if !recordingEnded(rec) {
+		return nil, errors.ErrNotFound
+	}
+	if err := checkViewAccess(userRole, rec.Visibility); err != nil {
+		u.Log.Warn(ctx, "Unauthorized access to GetVODFile, role: "+userRole.String()+", visibility: "+string(rec.Visibility))
+		return nil, err
+	}
+	// Recordings keep the plaintext segments, which must not leak out of an
+	// encrypted livestream
+	ls, err := u.LivestreamRepo.GetByID(rec.LivestreamUUID)
+	if err != nil {
+		return nil, err
+	}
+	if ls.Encrypted {
+		return nil, errors.ErrNotFound
+	}
+
+	path, err := u.recordingStore.Path(rec.LivestreamUUID, rec.ID, filename)
+	if err != nil {
+		if err != errors.ErrNotFound {
+			u.Log.Error(ctx, "Error reading recording "+rec.ID+": "+err.Error())
+		}
+		return nil, err
+	}
+	return &livestreamDTO.VODFileDTO{Path: path, Public: rec.Visibility == livestream.Public}, nil
+}
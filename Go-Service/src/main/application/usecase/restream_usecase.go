@@ -0,0 +1,211 @@
+package usecase
+
+import (
+	livestreamDTO "Go-Service/src/main/application/dto/livestream"
+	"Go-Service/src/main/application/interface/repository"
+	"Go-Service/src/main/application/interface/stream"
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/domain/interface/logger"
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cool9850311/StreamPlatformLite-Core/pkg/role"
+	"github.com/google/uuid"
+)
+
+const (
+	maxRestreamTargetsPerLivestream = 10
+	maxRestreamTargetNameLength     = 100
+	maxRestreamTargetURLLength      = 2048
+)
+
+// RestreamUsecase manages the external RTMP destinations a livestream is
+// relayed to
+type RestreamUsecase struct {
+	RestreamTargetRepo repository.RestreamTargetRepository
+	LivestreamRepo     repository.LivestreamRepository
+	Log                logger.Logger
+	streamService      stream.ILivestreamService
+}
+
+func NewRestreamUsecase(restreamTargetRepo repository.RestreamTargetRepository, livestreamRepo repository.LivestreamRepository, log logger.Logger, streamService stream.ILivestreamService) *RestreamUsecase {
+	return &RestreamUsecase{
+		RestreamTargetRepo: restreamTargetRepo,
+		LivestreamRepo:     livestreamRepo,
+		Log:                log,
+		streamService:      streamService,
+	}
+}
+
+// EnabledTargets is the restream target source of the ingest service
+func (u *RestreamUsecase) EnabledTargets(livestreamUUID string) ([]livestream.RestreamTarget, error) {
+	targets, err := u.RestreamTargetRepo.ListByLivestream(livestreamUUID)
+	if err != nil {
+		return nil, err
+	}
+	enabled := make([]livestream.RestreamTarget, 0, len(targets))
+	for _, target := range targets {
+		if target.Enabled {
+			enabled = append(enabled, *target)
+		}
+	}
+	return enabled, nil
+}
+
+func (u *RestreamUsecase) ListTargets(ctx context.Context, livestreamUUID string, userRole role.Role) ([]livestreamDTO.RestreamTargetResponseDTO, error) {
+	if userRole != role.Admin {
+		u.Log.Error(ctx, "Unauthorized access to ListRestreamTargets")
+		return nil, errors.ErrUnauthorized
+	}
+	if _, err := u.LivestreamRepo.GetByID(livestreamUUID); err != nil {
+		return nil, err
+	}
+	targets, err := u.RestreamTargetRepo.ListByLivestream(livestreamUUID)
+	if err != nil {
+		u.Log.Error(ctx, "Error listing restream targets: "+err.Error())
+		return nil, err
+	}
+	statuses := u.streamService.GetRestreamStatus(livestreamUUID)
+	result := make([]livestreamDTO.RestreamTargetResponseDTO, 0, len(targets))
+	for _, target := range targets {
+		status, ok := statuses[target.ID]
+		if !ok {
+			status = livestream.RestreamStatus{State: livestream.RestreamStateIdle}
+		}
+		result = append(result, toRestreamTargetResponse(target, status))
+	}
+	return result, nil
+}
+
+func (u *RestreamUsecase) CreateTarget(ctx context.Context, livestreamUUID string, request *livestreamDTO.RestreamTargetRequestDTO, userRole role.Role) (*livestreamDTO.RestreamTargetResponseDTO, error) {
+	if userRole != role.Admin {
+		u.Log.Error(ctx, "Unauthorized access to CreateRestreamTarget")
+		return nil, errors.ErrUnauthorized
+	}
+	if err := validateRestreamTarget(request.Name, request.URL); err != nil {
+		return nil, err
+	}
+	if _, err := u.LivestreamRepo.GetByID(livestreamUUID); err != nil {
+		return nil, err
+	}
+	existing, err := u.RestreamTargetRepo.ListByLivestream(livestreamUUID)
+	if err != nil {
+		u.Log.Error(ctx, "Error listing restream targets: "+err.Error())
+		return nil, err
+	}
+	if len(existing) >= maxRestreamTargetsPerLivestream {
+		return nil, errors.ErrInvalidInput
+	}
+
+	target := &livestream.RestreamTarget{
+		ID:             uuid.New().String(),
+		LivestreamUUID: livestreamUUID,
+		Name:           strings.TrimSpace(request.Name),
+		URL:            request.URL,
+		Enabled:        request.Enabled == nil || *request.Enabled,
+		CreatedAt:      time.Now(),
+	}
+	if err := u.RestreamTargetRepo.Create(target); err != nil {
+		u.Log.Error(ctx, "Error creating restream target: "+err.Error())
+		return nil, err
+	}
+	u.refresh(ctx, livestreamUUID)
+	u.Log.Info(ctx, "Restream target "+target.ID+" created for livestream: "+livestreamUUID)
+	response := toRestreamTargetResponse(target, livestream.RestreamStatus{State: livestream.RestreamStateIdle})
+	return &response, nil
+}
+
+func (u *RestreamUsecase) UpdateTarget(ctx context.Context, livestreamUUID string, targetID string, request *livestreamDTO.RestreamTargetRequestDTO, userRole role.Role) error {
+	if userRole != role.Admin {
+		u.Log.Error(ctx, "Unauthorized access to UpdateRestreamTarget")
+		return errors.ErrUnauthorized
+	}
+	if err := validateRestreamTarget(request.Name, request.URL); err != nil {
+		return err
+	}
+	target, err := u.getTarget(livestreamUUID, targetID)
+	if err != nil {
+		return err
+	}
+	target.Name = strings.TrimSpace(request.Name)
+	target.URL = request.URL
+	if request.Enabled != nil {
+		target.Enabled = *request.Enabled
+	}
+	if err := u.RestreamTargetRepo.Update(target); err != nil {
+		u.Log.Error(ctx, "Error updating restream target: "+err.Error())
+		return err
+	}
+	u.refresh(ctx, livestreamUUID)
+	return nil
+}
+
+func (u *RestreamUsecase) DeleteTarget(ctx context.Context, livestreamUUID string, targetID string, userRole role.Role) error {
+	if userRole != role.Admin {
+		u.Log.Error(ctx, "Unauthorized access to DeleteRestreamTarget")
+		return errors.ErrUnauthorized
+	}
+	if _, err := u.getTarget(livestreamUUID, targetID); err != nil {
+		return err
+	}
+	if err := u.RestreamTargetRepo.Delete(targetID); err != nil {
+		u.Log.Error(ctx, "Error deleting restream target: "+err.Error())
+		return err
+	}
+	u.refresh(ctx, livestreamUUID)
+	return nil
+}
+
+// getTarget loads a target and makes sure it belongs to the livestream
+func (u *RestreamUsecase) getTarget(livestreamUUID string, targetID string) (*livestream.RestreamTarget, error) {
+	target, err := u.RestreamTargetRepo.GetByID(targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target.LivestreamUUID != livestreamUUID {
+		return nil, errors.ErrNotFound
+	}
+	return target, nil
+}
+
+// refresh applies target changes to a publish that is already running. A
+// failure only delays the change until the next publish, so it is not
+// returned to the caller.
+func (u *RestreamUsecase) refresh(ctx context.Context, livestreamUUID string) {
+	if err := u.streamService.RefreshRestreamTargets(livestreamUUID); err != nil {
+		u.Log.Error(ctx, "Error refreshing restream targets: "+err.Error())
+	}
+}
+
+func validateRestreamTarget(name string, rawURL string) error {
+	if len(name) > maxRestreamTargetNameLength || len(rawURL) > maxRestreamTargetURLLength {
+		return errors.ErrInvalidInput
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.ErrInvalidInput
+	}
+	if u.Scheme != "rtmp" && u.Scheme != "rtmps" {
+		return errors.ErrInvalidInput
+	}
+	// Pushing needs both an app and a stream name: rtmp://host/app/stream
+	appAndStream := strings.Trim(u.Path, "/")
+	if u.Hostname() == "" || !strings.Contains(appAndStream, "/") {
+		return errors.ErrInvalidInput
+	}
+	return nil
+}
+
+func toRestreamTargetResponse(target *livestream.RestreamTarget, status livestream.RestreamStatus) livestreamDTO.RestreamTargetResponseDTO {
+	return livestreamDTO.RestreamTargetResponseDTO{
+		ID:        target.ID,
+		Name:      target.Name,
+		URL:       target.URL,
+		Enabled:   target.Enabled,
+		CreatedAt: target.CreatedAt,
+		Status:    status,
+	}
+}
@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"Go-Service/src/main/application/dto/config"
+	livestreamDTO "Go-Service/src/main/application/dto/livestream"
+	"Go-Service/src/main/application/interface/jwt"
+	"Go-Service/src/main/application/interface/repository"
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/domain/interface/logger"
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/cool9850311/StreamPlatformLite-Core/pkg/role"
+	"github.com/google/uuid"
+)
+
+const maxShareLinkNameLength = 100
+
+// ShareLinkUsecase mints and revokes the signed playback links of Link
+// livestreams. The links are checked by LivestreamUsecase when served.
+type ShareLinkUsecase struct {
+	ShareLinkRepo  repository.ShareLinkRepository
+	LivestreamRepo repository.LivestreamRepository
+	Log            logger.Logger
+	config         config.Config
+	jwtGenerator   jwt.JWTGenerator
+}
+
+func NewShareLinkUsecase(shareLinkRepo repository.ShareLinkRepository, livestreamRepo repository.LivestreamRepository, log logger.Logger, config config.Config, jwtGenerator jwt.JWTGenerator) *ShareLinkUsecase {
+	return &ShareLinkUsecase{
+		ShareLinkRepo:  shareLinkRepo,
+		LivestreamRepo: livestreamRepo,
+		Log:            log,
+		config:         config,
+		jwtGenerator:   jwtGenerator,
+	}
+}
+
+func (u *ShareLinkUsecase) CreateShareLink(ctx context.Context, livestreamUUID string, request *livestreamDTO.ShareLinkCreateDTO, userRole role.Role) (*livestreamDTO.ShareLinkResponseDTO, error) {
+	if userRole != role.Admin {
+		u.Log.Error(ctx, "Unauthorized access to CreateShareLink")
+		return nil, errors.ErrUnauthorized
+	}
+	ls, err := u.LivestreamRepo.GetByID(livestreamUUID)
+	if err != nil {
+		return nil, err
+	}
+	// Other visibilities ignore the token, a link would not grant anything
+	if ls.Visibility != livestream.Link {
+		u.Log.Warn(ctx, "Share link requested for a livestream that is not Link visibility: "+livestreamUUID)
+		return nil, errors.ErrInvalidInput
+	}
+	ttl := request.ExpiresInSeconds
+	if ttl == 0 {
+		ttl = livestream.DefaultShareLinkTTLSeconds
+	}
+	if ttl < livestream.MinShareLinkTTLSeconds || ttl > livestream.MaxShareLinkTTLSeconds || len(request.Name) > maxShareLinkNameLength {
+		return nil, errors.ErrInvalidInput
+	}
+
+	now := time.Now()
+	link := &livestream.ShareLink{
+		ID:             uuid.NewString(),
+		LivestreamUUID: livestreamUUID,
+		Name:           request.Name,
+		ExpiresAt:      now.Add(time.Duration(ttl) * time.Second),
+		CreatedAt:      now,
+	}
+	if err := u.ShareLinkRepo.Create(link); err != nil {
+		u.Log.Error(ctx, "Error creating share link: "+err.Error())
+		return nil, err
+	}
+	return u.toShareLinkResponse(ctx, link)
+}
+
+// ListShareLinks returns the links that have not expired yet
+func (u *ShareLinkUsecase) ListShareLinks(ctx context.Context, livestreamUUID string, userRole role.Role) ([]livestreamDTO.ShareLinkResponseDTO, error) {
+	if userRole != role.Admin {
+		u.Log.Error(ctx, "Unauthorized access to ListShareLinks")
+		return nil, errors.ErrUnauthorized
+	}
+	if _, err := u.LivestreamRepo.GetByID(livestreamUUID); err != nil {
+		return nil, err
+	}
+	links, err := u.ShareLinkRepo.ListByLivestream(livestreamUUID)
+	if err != nil {
+		u.Log.Error(ctx, "Error listing share links: "+err.Error())
+		return nil, err
+	}
+	now := time.Now()
+	result := make([]livestreamDTO.ShareLinkResponseDTO, 0, len(links))
+	for _, link := range links {
+		if now.After(link.ExpiresAt) {
+			continue
+		}
+		response, err := u.toShareLinkResponse(ctx, link)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *response)
+	}
+	return result, nil
+}
+
+// RevokeShareLink deletes a link; its token stops working once the cached
+// lookups of it expire, within shareLinkCacheTTL
+func (u *ShareLinkUsecase) RevokeShareLink(ctx context.Context, livestreamUUID, linkID string, userRole role.Role) error {
+	if userRole != role.Admin {
+		u.Log.Error(ctx, "Unauthorized access to RevokeShareLink")
+		return errors.ErrUnauthorized
+	}
+	link, err := u.ShareLinkRepo.GetByID(linkID)
+	if err != nil {
+		return err
+	}
+	if link.LivestreamUUID != livestreamUUID {
+		return errors.ErrNotFound
+	}
+	if err := u.ShareLinkRepo.Delete(linkID); err != nil {
+		u.Log.Error(ctx, "Error revoking share link: "+err.Error())
+		return err
+	}
+	return nil
+}
+
+func (u *ShareLinkUsecase) toShareLinkResponse(ctx context.Context, link *livestream.ShareLink) (*livestreamDTO.ShareLinkResponseDTO, error) {
+	token, err := u.jwtGenerator.GeneratePlaybackToken(link.ID, link.LivestreamUUID, link.ExpiresAt, u.config.JWT.SecretKey)
+	if err != nil {
+		u.Log.Error(ctx, "Error signing share link: "+err.Error())
+		return nil, errors.ErrInternal
+	}
+	return &livestreamDTO.ShareLinkResponseDTO{
+		ID:          link.ID,
+		Name:        link.Name,
+		ExpiresAt:   link.ExpiresAt,
+		CreatedAt:   link.CreatedAt,
+		Token:       token,
+		PlaybackURL: serverURL(u.config) + "/livestream/" + link.LivestreamUUID + "/playlist.m3u8?token=" + url.QueryEscape(token),
+	}, nil
+}
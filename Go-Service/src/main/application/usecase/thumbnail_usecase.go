@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"Go-Service/src/main/application/dto/config"
+	livestreamDTO "Go-Service/src/main/application/dto/livestream"
+	"Go-Service/src/main/application/interface/jwt"
+	"Go-Service/src/main/application/interface/repository"
+	"Go-Service/src/main/application/interface/stream"
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/domain/interface/libarary/ffmpeg"
+	"Go-Service/src/main/domain/interface/logger"
+	"Go-Service/src/main/domain/interface/thumbnail"
+	"Go-Service/src/main/infrastructure/util"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cool9850311/StreamPlatformLite-Core/pkg/role"
+)
+
+// ThumbnailUsecase grabs preview snapshots of live streams and serves them,
+// together with the poster the admin picked for when the stream is offline
+type ThumbnailUsecase struct {
+	LivestreamRepo repository.LivestreamRepository
+	Log            logger.Logger
+	streamService  stream.ILivestreamService
+	ffmpegLibrary  ffmpeg.FfmpegLibrary
+	store          thumbnail.ThumbnailStore
+	playback       *playbackAccess
+
+	// capturing skips a run while the previous one is still going
+	capturing sync.Mutex
+}
+
+func NewThumbnailUsecase(livestreamRepo repository.LivestreamRepository, log logger.Logger, config config.Config, streamService stream.ILivestreamService, ffmpegLibrary ffmpeg.FfmpegLibrary, store thumbnail.ThumbnailStore, shareLinkRepo repository.ShareLinkRepository, jwtGenerator jwt.JWTGenerator) *ThumbnailUsecase {
+	return &ThumbnailUsecase{
+		LivestreamRepo: livestreamRepo,
+		Log:            log,
+		streamService:  streamService,
+		ffmpegLibrary:  ffmpegLibrary,
+		store:          store,
+		playback:       newPlaybackAccess(shareLinkRepo, jwtGenerator, config.JWT.SecretKey),
+	}
+}
+
+// CaptureAll takes one snapshot of every livestream that is publishing
+func (u *ThumbnailUsecase) CaptureAll(ctx context.Context) {
+	if !u.capturing.TryLock() {
+		u.Log.Warn(ctx, "Previous thumbnail capture still running, skipped")
+		return
+	}
+	defer u.capturing.Unlock()
+
+	livestreams, err := u.LivestreamRepo.List()
+	if err != nil {
+		u.Log.Error(ctx, "Error listing livestreams: "+err.Error())
+		return
+	}
+	for _, ls := range livestreams {
+		if !u.isLive(ls.UUID) {
+			continue
+		}
+		segment, err := u.store.LatestSegment(ls.UUID)
+		if err != nil {
+			// Nothing muxed yet right after the publish started
+			continue
+		}
+		_, err = u.store.Add(ls.UUID, time.Now(), func(outputPath string) error {
+			return u.ffmpegLibrary.CaptureThumbnail(segment, outputPath)
+		})
+		if err != nil {
+			u.Log.Error(ctx, "Error capturing thumbnail of "+ls.UUID+": "+err.Error())
+		}
+	}
+}
+
+func (u *ThumbnailUsecase) isLive(livestreamUUID string) bool {
+	status, ok := u.streamService.GetLiveStatus(livestreamUUID)
+	return ok && status.State == livestream.LiveStatePublishing
+}
+
+// GetThumbnail returns the latest snapshot while the stream is live and the
+// poster while it is offline, each falling back to the other
+func (u *ThumbnailUsecase) GetThumbnail(ctx context.Context, livestreamUUID, playbackToken string, userRole role.Role) ([]byte, error) {
+	if err := util.ValidateUUID(livestreamUUID); err != nil {
+		u.Log.Warn(ctx, "Invalid UUID format: "+livestreamUUID)
+		return nil, errors.ErrInvalidInput
+	}
+	ls, err := u.LivestreamRepo.GetByID(livestreamUUID)
+	if err != nil {
+		u.Log.Error(ctx, "Error getting livestream: "+err.Error())
+		return nil, err
+	}
+	if _, err := u.playback.check(ls, playbackToken, userRole); err != nil {
+		u.Log.Warn(ctx, "Unauthorized access to GetThumbnail, role: "+userRole.String()+", visibility: "+string(ls.Visibility))
+		return nil, err
+	}
+
+	if u.isLive(livestreamUUID) {
+		data, err := u.latest(livestreamUUID)
+		if err != errors.ErrNotFound {
+			return data, err
+		}
+		return u.store.ReadPoster(livestreamUUID)
+	}
+	data, err := u.store.ReadPoster(livestreamUUID)
+	if err != errors.ErrNotFound {
+		return data, err
+	}
+	return u.latest(livestreamUUID)
+}
+
+func (u *ThumbnailUsecase) latest(livestreamUUID string) ([]byte, error) {
+	thumbnails, err := u.store.List(livestreamUUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(thumbnails) == 0 {
+		return nil, errors.ErrNotFound
+	}
+	return u.store.Read(livestreamUUID, thumbnails[0].ID)
+}
+
+// ListThumbnails returns the snapshot history the poster can be picked from
+func (u *ThumbnailUsecase) ListThumbnails(ctx context.Context, livestreamUUID string, userRole role.Role) ([]livestream.Thumbnail, error) {
+	if err := u.checkAdmin(ctx, livestreamUUID, userRole, "ListThumbnails"); err != nil {
+		return nil, err
+	}
+	thumbnails, err := u.store.List(livestreamUUID)
+	if err != nil {
+		u.Log.Error(ctx, "Error listing thumbnails: "+err.Error())
+		return nil, err
+	}
+	return thumbnails, nil
+}
+
+func (u *ThumbnailUsecase) GetThumbnailByID(ctx context.Context, livestreamUUID, thumbnailID string, userRole role.Role) ([]byte, error) {
+	if err := u.checkAdmin(ctx, livestreamUUID, userRole, "GetThumbnailByID"); err != nil {
+		return nil, err
+	}
+	return u.store.Read(livestreamUUID, thumbnailID)
+}
+
+// SetPoster makes a snapshot of the history the offline poster
+func (u *ThumbnailUsecase) SetPoster(ctx context.Context, livestreamUUID string, request *livestreamDTO.ThumbnailPosterDTO, userRole role.Role) error {
+	if err := u.checkAdmin(ctx, livestreamUUID, userRole, "SetPoster"); err != nil {
+		return err
+	}
+	if request.ThumbnailID == "" {
+		return errors.ErrInvalidInput
+	}
+	if err := u.store.SetPoster(livestreamUUID, request.ThumbnailID); err != nil {
+		if err != errors.ErrNotFound && err != errors.ErrInvalidInput {
+			u.Log.Error(ctx, "Error setting poster: "+err.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+func (u *ThumbnailUsecase) checkAdmin(ctx context.Context, livestreamUUID string, userRole role.Role, action string) error {
+	if userRole != role.Admin {
+		u.Log.Error(ctx, "Unauthorized access to "+action)
+		return errors.ErrUnauthorized
+	}
+	if err := util.ValidateUUID(livestreamUUID); err != nil {
+		return errors.ErrInvalidInput
+	}
+	if _, err := u.LivestreamRepo.GetByID(livestreamUUID); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"Go-Service/src/main/application/interface/repository"
+	"Go-Service/src/main/application/interface/stream"
+	"Go-Service/src/main/domain/interface/libarary/ffmpeg"
+	"Go-Service/src/main/domain/interface/logger"
+	"context"
+	"io"
+	"path/filepath"
+	"sync"
+)
+
+// TranscodeUsecase runs the ABR ladder of every livestream that has one
+// configured. ffmpeg reads the publish over the stream's FLV relay and writes
+// the renditions next to the source playlist.
+type TranscodeUsecase struct {
+	LivestreamRepo repository.LivestreamRepository
+	Log            logger.Logger
+	streamService  stream.ILivestreamService
+	ffmpegLibrary  ffmpeg.FfmpegLibrary
+	hlsRoot        string
+
+	mu          sync.Mutex
+	transcoders map[string]activeTranscode
+}
+
+type activeTranscode struct {
+	sessionID  string
+	transcoder ffmpeg.Transcoder
+}
+
+func NewTranscodeUsecase(livestreamRepo repository.LivestreamRepository, log logger.Logger, streamService stream.ILivestreamService, ffmpegLibrary ffmpeg.FfmpegLibrary, hlsRoot string) *TranscodeUsecase {
+	return &TranscodeUsecase{
+		LivestreamRepo: livestreamRepo,
+		Log:            log,
+		streamService:  streamService,
+		ffmpegLibrary:  ffmpegLibrary,
+		hlsRoot:        hlsRoot,
+		transcoders:    make(map[string]activeTranscode),
+	}
+}
+
+// HandlePublishEvent starts the ladder when a publisher starts and stops it
+// when that publisher stops
+func (u *TranscodeUsecase) HandlePublishEvent(event stream.PublishEvent) {
+	switch event.Type {
+	case stream.PublishStarted:
+		u.start(event)
+	case stream.PublishStopped:
+		u.mu.Lock()
+		active, exists := u.transcoders[event.UUID]
+		if exists && active.sessionID == event.SessionID {
+			delete(u.transcoders, event.UUID)
+		} else {
+			exists = false
+		}
+		u.mu.Unlock()
+		if exists {
+			active.transcoder.Stop()
+		}
+	}
+}
+
+func (u *TranscodeUsecase) start(event stream.PublishEvent) {
+	ctx := context.Background()
+	livestream, err := u.LivestreamRepo.GetByID(event.UUID)
+	if err != nil {
+		u.Log.Error(ctx, "Error loading livestream "+event.UUID+" for transcoding: "+err.Error())
+		return
+	}
+	if len(livestream.Renditions) == 0 {
+		return
+	}
+	uuid := event.UUID
+	transcoder, err := u.ffmpegLibrary.StartTranscode(ffmpeg.TranscodeJob{
+		Source: func() (io.ReadCloser, error) {
+			subscription, err := u.streamService.SubscribeFLV(uuid)
+			if err != nil {
+				return nil, err
+			}
+			return &flvReader{subscription: subscription}, nil
+		},
+		OutputDir:  filepath.Join(u.hlsRoot, uuid),
+		Renditions: livestream.Renditions,
+		OnFailure: func(err error) {
+			u.Log.Warn(ctx, "Transcoder of livestream "+uuid+" failed: "+err.Error())
+		},
+	})
+	if err != nil {
+		u.Log.Error(ctx, "Error starting transcoder of livestream "+uuid+": "+err.Error())
+		return
+	}
+
+	u.mu.Lock()
+	previous, exists := u.transcoders[uuid]
+	u.transcoders[uuid] = activeTranscode{sessionID: event.SessionID, transcoder: transcoder}
+	u.mu.Unlock()
+	if exists {
+		previous.transcoder.Stop()
+	}
+	u.Log.Info(ctx, "Started transcoder of livestream "+uuid)
+}
+
+// flvReader turns an FLV subscription into the byte stream ffmpeg reads
+type flvReader struct {
+	subscription stream.FlvSubscription
+	pending      []byte
+}
+
+func (r *flvReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		data, ok := <-r.subscription.Data()
+		if !ok {
+			return 0, io.EOF
+		}
+		r.pending = data
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+func (r *flvReader) Close() error {
+	r.subscription.Close()
+	return nil
+}
@@ -12,5 +12,6 @@ var (
 	ErrMuteUser         = errors.New("user already muted")
 	ErrDuplicate        = errors.New("duplicate")
 	ErrPassword         = errors.New("incorrect password")
+	ErrTimeout          = errors.New("timed out")
 	// Add other error types as needed
 )
@@ -0,0 +1,14 @@
+package livestream
+
+import "time"
+
+// BroadcastSession is one publish of a livestream, from the RTMP publisher
+// connecting until it disconnects. EndedAt is nil while the session is live.
+type BroadcastSession struct {
+	ID             string     `json:"id"`
+	LivestreamUUID string     `json:"livestream_uuid"`
+	StartedAt      time.Time  `json:"started_at"`
+	EndedAt        *time.Time `json:"ended_at"`
+	PeakViewers    int        `json:"peak_viewers"`
+	PublisherIP    string     `json:"publisher_ip"`
+}
@@ -0,0 +1,30 @@
+package livestream
+
+import "time"
+
+type ConversionStatus string
+
+const (
+	ConversionStatusQueued  ConversionStatus = "queued"
+	ConversionStatusRunning ConversionStatus = "running"
+	ConversionStatusDone    ConversionStatus = "done"
+	// ConversionStatusFailed jobs ran out of attempts
+	ConversionStatusFailed ConversionStatus = "failed"
+)
+
+// ConversionJob converts one recording into an MP4. A failed run is queued
+// again until the attempts run out.
+type ConversionJob struct {
+	ID             string           `json:"id"`
+	RecordingID    string           `json:"recording_id"`
+	LivestreamUUID string           `json:"livestream_uuid"`
+	Status         ConversionStatus `json:"status"`
+	// Progress is the part converted so far, between 0 and 1
+	Progress float64 `json:"progress"`
+	Attempts int     `json:"attempts"`
+	// NextAttemptAt is when a queued job may run
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	LastError     string    `json:"last_error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
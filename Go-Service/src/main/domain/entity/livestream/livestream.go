@@ -1,5 +1,7 @@
 package livestream
 
+import "time"
+
 // Define the Livestream struct
 type Livestream struct {
 	UUID        string     `json:"uuid"`
@@ -12,6 +14,21 @@ type Livestream struct {
 	BanList     []string   `json:"ban_list"`
 	MuteList    []string   `json:"mute_list"`
 	IsRecord    bool       `json:"is_record"`
+	LowLatency  bool       `json:"low_latency"`
+	// Renditions is the ABR transcoding ladder; empty means the source
+	// rendition only
+	Renditions []Rendition `json:"renditions"`
+	// HLS muxer settings, applied when the next publish starts. LL-HLS keeps
+	// its own part and segment targets.
+	FragmentDurationMs int         `json:"fragment_duration_ms"`
+	FragmentNum        int         `json:"fragment_num"`
+	CleanupMode        CleanupMode `json:"cleanup_mode"`
+	// DVRWindowSeconds is how far viewers can rewind through dvr.m3u8; zero
+	// disables DVR
+	DVRWindowSeconds int `json:"dvr_window_seconds"`
+	// Encrypted serves the HLS segments AES-128 encrypted, with keys only
+	// handed out to viewers allowed to watch
+	Encrypted bool `json:"encrypted"`
 }
 
 type Visibility string
@@ -22,3 +39,86 @@ const (
 	Private    Visibility = "private"
 	Link       Visibility = "link"
 )
+
+// CleanupMode is when the HLS muxer deletes fragments that left the live
+// playlist. DVR streams keep them, their DVR playlist deleting them once
+// they leave its window; recordings keep hard-linked copies of their own.
+type CleanupMode string
+
+const (
+	CleanupModeNever    CleanupMode = "never"
+	CleanupModeInTheEnd CleanupMode = "in_the_end"
+	CleanupModeAsap     CleanupMode = "asap"
+)
+
+func (m CleanupMode) IsValid() bool {
+	switch m {
+	case CleanupModeNever, CleanupModeInTheEnd, CleanupModeAsap:
+		return true
+	}
+	return false
+}
+
+const (
+	DefaultFragmentDurationMs = 500
+	DefaultFragmentNum        = 5
+	DefaultCleanupMode        = CleanupModeAsap
+
+	MinFragmentDurationMs = 200
+	MaxFragmentDurationMs = 10000
+	MinFragmentNum        = 2
+	MaxFragmentNum        = 30
+
+	MinDVRWindowSeconds = 60
+	MaxDVRWindowSeconds = 6 * 60 * 60
+
+	// HLSKeyRotationSeconds is how long segments share an encryption key
+	HLSKeyRotationSeconds = 10 * 60
+)
+
+// Rendition names one rung of the transcoding ladder. It is also the name of
+// the subdirectory its HLS output is written to.
+type Rendition string
+
+const (
+	Rendition1080p     Rendition = "1080p"
+	Rendition720p      Rendition = "720p"
+	Rendition480p      Rendition = "480p"
+	RenditionAudioOnly Rendition = "audio"
+)
+
+// RenditionPreset is the encoder output of a rendition. Height is zero for
+// audio-only renditions.
+type RenditionPreset struct {
+	Width        int
+	Height       int
+	VideoBitrate int // kbps
+	AudioBitrate int // kbps
+}
+
+var RenditionPresets = map[Rendition]RenditionPreset{
+	Rendition1080p:     {Width: 1920, Height: 1080, VideoBitrate: 5000, AudioBitrate: 128},
+	Rendition720p:      {Width: 1280, Height: 720, VideoBitrate: 2800, AudioBitrate: 128},
+	Rendition480p:      {Width: 854, Height: 480, VideoBitrate: 1400, AudioBitrate: 96},
+	RenditionAudioOnly: {AudioBitrate: 64},
+}
+
+func (r Rendition) IsValid() bool {
+	_, ok := RenditionPresets[r]
+	return ok
+}
+
+type LiveState string
+
+const (
+	LiveStateIdle         LiveState = "idle"
+	LiveStatePublishing   LiveState = "publishing"
+	LiveStateDisconnected LiveState = "disconnected"
+)
+
+// LiveStatus is a snapshot of the RTMP ingest state of a livestream
+type LiveStatus struct {
+	State      LiveState `json:"state"`
+	RemoteAddr string    `json:"remote_addr"`
+	StartedAt  time.Time `json:"started_at"`
+}
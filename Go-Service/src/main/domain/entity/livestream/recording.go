@@ -0,0 +1,29 @@
+package livestream
+
+import "time"
+
+type RecordingStatus string
+
+const (
+	RecordingStatusRecording RecordingStatus = "recording"
+	RecordingStatusFinished  RecordingStatus = "finished"
+	// RecordingStatusInterrupted recordings were cut off by a shutdown
+	RecordingStatusInterrupted RecordingStatus = "interrupted"
+	// RecordingStatusFailed recordings have no segment to play
+	RecordingStatusFailed RecordingStatus = "failed"
+)
+
+// Recording is one publish of a recorded livestream, written to a session
+// directory of its own. Its ID is the broadcast session ID. EndedAt is nil
+// while the publish is live. Visibility decides who may play it back, apart
+// from the livestream it was recorded from.
+type Recording struct {
+	ID              string          `json:"id"`
+	LivestreamUUID  string          `json:"livestream_uuid"`
+	StartedAt       time.Time       `json:"started_at"`
+	EndedAt         *time.Time      `json:"ended_at"`
+	DurationSeconds float64         `json:"duration_seconds"`
+	SizeBytes       int64           `json:"size_bytes"`
+	Status          RecordingStatus `json:"status"`
+	Visibility      Visibility      `json:"visibility"`
+}
@@ -0,0 +1,31 @@
+package livestream
+
+import "time"
+
+// RestreamTarget is an external RTMP server a livestream is relayed to while
+// it is being published
+type RestreamTarget struct {
+	ID             string    `json:"id"`
+	LivestreamUUID string    `json:"livestream_uuid"`
+	Name           string    `json:"name"`
+	URL            string    `json:"url"`
+	Enabled        bool      `json:"enabled"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type RestreamState string
+
+const (
+	RestreamStateIdle       RestreamState = "idle"
+	RestreamStateConnecting RestreamState = "connecting"
+	RestreamStateLive       RestreamState = "live"
+	RestreamStateRetrying   RestreamState = "retrying"
+)
+
+// RestreamStatus is the relay state of one restream target
+type RestreamStatus struct {
+	State     RestreamState `json:"state"`
+	LastError string        `json:"last_error"`
+	Attempts  int           `json:"attempts"`
+	Since     time.Time     `json:"since"`
+}
@@ -0,0 +1,19 @@
+package livestream
+
+import "time"
+
+// ShareLink lets anyone holding its signed playback URL watch a Link
+// livestream until the link expires or is revoked
+type ShareLink struct {
+	ID             string    `json:"id"`
+	LivestreamUUID string    `json:"livestream_uuid"`
+	Name           string    `json:"name"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+const (
+	DefaultShareLinkTTLSeconds = 24 * 60 * 60
+	MinShareLinkTTLSeconds     = 60
+	MaxShareLinkTTLSeconds     = 30 * 24 * 60 * 60
+)
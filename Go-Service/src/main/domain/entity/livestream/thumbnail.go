@@ -0,0 +1,10 @@
+package livestream
+
+import "time"
+
+// Thumbnail is one preview snapshot grabbed from a live stream. The ID is the
+// capture time in Unix milliseconds.
+type Thumbnail struct {
+	ID         string    `json:"id"`
+	CapturedAt time.Time `json:"captured_at"`
+}
@@ -1,10 +1,30 @@
 package file_cache
 
+import "time"
+
 type IFileCache interface {
 	GetSingleFileName(filePath string) (string, error)
 	ReadFile(filePath string) ([]byte, error)
+	// ModTime returns when a file was last written on disk
+	ModTime(filePath string) (time.Time, error)
 	StoreCache(filePath string, data []byte)
 	LoadCache(filePath string) ([]byte, bool)
 	DeleteFile(filePath string)
-	Range(f func(key, value interface{}) bool)
+	// LockFile locks one file and returns its unlock function
+	LockFile(filePath string) func()
+	// WatchStream keeps the cached playlists under dir in step with the disk
+	// until StopStream or until the directory is removed
+	WatchStream(uuid, dir string)
+	StopStream(uuid string)
+	Stats() CacheStats
+}
+
+// CacheStats reports how well the cache is doing and how full it is
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+	Bytes     int64  `json:"bytes"`
+	MaxBytes  int64  `json:"max_bytes"`
 }
@@ -0,0 +1,15 @@
+package hls_key
+
+import "time"
+
+// KeyStore holds the AES-128 keys the HLS segments of a livestream are
+// encrypted with. Keys rotate, so each segment maps to one key id.
+type KeyStore interface {
+	// KeyID returns the key of a segment, given by its path relative to the
+	// livestream's HLS directory, creating the key if it is new
+	KeyID(uuid, segment string) (string, error)
+	// Key returns an existing key
+	Key(uuid, id string) ([]byte, error)
+	// Prune deletes the keys no segment younger than maxAge was encrypted with
+	Prune(uuid string, maxAge time.Duration) error
+}
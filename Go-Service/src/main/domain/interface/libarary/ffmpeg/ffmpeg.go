@@ -1,5 +1,46 @@
 package ffmpeg
 
+import (
+	"Go-Service/src/main/domain/entity/livestream"
+	"context"
+	"io"
+)
+
+// ConvertJob remuxes a recorded HLS playlist into one MP4
+type ConvertJob struct {
+	PlaylistPath string
+	// OutputPath only appears once the MP4 is complete
+	OutputPath string
+	// DurationSeconds is the length of the recording, progress is measured
+	// against it
+	DurationSeconds float64
+	// OnProgress, when set, gets the part converted so far, between 0 and 1
+	OnProgress func(progress float64)
+}
+
+// TranscodeJob describes an ABR ladder for one publish
+type TranscodeJob struct {
+	// Source opens a new FLV stream of the live input. It is called again
+	// every time the transcoder is restarted.
+	Source func() (io.ReadCloser, error)
+	// OutputDir gets master.m3u8 and one subdirectory per rendition
+	OutputDir  string
+	Renditions []livestream.Rendition
+	// OnFailure, when set, is called each time the transcoder stops
+	// unexpectedly, before it is restarted
+	OnFailure func(err error)
+}
+
+// Transcoder is a running, supervised transcoding ladder
+type Transcoder interface {
+	// Stop ends the transcoder without restarting it and waits for it to exit
+	Stop()
+}
+
 type FfmpegLibrary interface {
-	ConvertStreamToMp4(filePath string, fileName string) error
+	// ConvertToMp4 runs until the MP4 is written or ctx is cancelled
+	ConvertToMp4(ctx context.Context, job ConvertJob) error
+	StartTranscode(job TranscodeJob) (Transcoder, error)
+	// CaptureThumbnail writes the first video frame of a segment as a JPEG
+	CaptureThumbnail(segmentPath string, outputPath string) error
 }
@@ -0,0 +1,24 @@
+package recording
+
+// Summary totals what a recording has on disk
+type Summary struct {
+	DurationSeconds float64
+	SizeBytes       int64
+	Segments        int
+}
+
+// RecordingStore reads, serves, finalizes and deletes the session directories
+// publishes are recorded into
+type RecordingStore interface {
+	// Summarize totals the segments listed in a recording's playlist. It
+	// returns ErrNotFound when nothing was recorded.
+	Summarize(uuid, id string) (Summary, error)
+	// Finalize ends the playlist of a recording that was cut off before its
+	// publish ended. It returns ErrNotFound when nothing was recorded.
+	Finalize(uuid, id string) error
+	// Path returns the path of a file in a recording's session directory.
+	// It returns ErrNotFound when there is no such file.
+	Path(uuid, id, name string) (string, error)
+	// Delete removes the session directory with everything in it
+	Delete(uuid, id string) error
+}
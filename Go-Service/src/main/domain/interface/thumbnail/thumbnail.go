@@ -0,0 +1,22 @@
+package thumbnail
+
+import (
+	"Go-Service/src/main/domain/entity/livestream"
+	"time"
+)
+
+// ThumbnailStore keeps a short history of preview snapshots per livestream,
+// plus the poster shown while the stream is offline
+type ThumbnailStore interface {
+	// LatestSegment returns the path of the newest complete HLS segment
+	LatestSegment(uuid string) (string, error)
+	// Add stores the snapshot written by capture and drops the oldest ones
+	// beyond the history size
+	Add(uuid string, capturedAt time.Time, capture func(outputPath string) error) (*livestream.Thumbnail, error)
+	// List returns the history, newest first
+	List(uuid string) ([]livestream.Thumbnail, error)
+	Read(uuid, id string) ([]byte, error)
+	// SetPoster copies a snapshot of the history to the poster
+	SetPoster(uuid, id string) error
+	ReadPoster(uuid string) ([]byte, error)
+}
@@ -1,19 +1,78 @@
 package cache
 
 import (
+	"Go-Service/src/main/domain/interface/file_cache"
+	"container/list"
 	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 )
 
+const (
+	defaultRefreshInterval = 500 * time.Millisecond
+	// defaultRefreshIdleTicks stops the refresher of a stream after a minute
+	// without cached files
+	defaultRefreshIdleTicks = 120
+	// modTimeSlack covers coarse filesystem timestamps and a write racing the
+	// read that filled the cache
+	modTimeSlack = time.Second
+)
+
+type cacheEntry struct {
+	filePath string
+	data     []byte
+	storedAt time.Time
+}
+
+type fileLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// streamRefresher drops the cached playlists of one stream that changed on
+// disk. It covers the files written without update events, such as the ABR
+// playlists ffmpeg writes.
+type streamRefresher struct {
+	dir  string
+	stop chan struct{}
+	done chan struct{}
+}
+
+// FileCache keeps HLS files in memory up to a byte budget, evicting the least
+// recently used ones first
 type FileCache struct {
-	cache sync.Map
+	cacheLock sync.Mutex
+	// lru holds *cacheEntry, most recently used first
+	lru       *list.List
+	entries   map[string]*list.Element
+	bytes     int64
+	maxBytes  int64
+	hits      uint64
+	misses    uint64
+	evictions uint64
+
+	locksLock sync.Mutex
+	locks     map[string]*fileLock
+
+	refreshersLock   sync.Mutex
+	refreshers       map[string]*streamRefresher
+	refreshInterval  time.Duration
+	refreshIdleTicks int
 }
 
-func NewFileCache() *FileCache {
+// NewFileCache creates a cache holding at most maxBytes of file data
+func NewFileCache(maxBytes int64) *FileCache {
 	return &FileCache{
-		cache: sync.Map{},
+		lru:              list.New(),
+		entries:          make(map[string]*list.Element),
+		maxBytes:         maxBytes,
+		locks:            make(map[string]*fileLock),
+		refreshers:       make(map[string]*streamRefresher),
+		refreshInterval:  defaultRefreshInterval,
+		refreshIdleTicks: defaultRefreshIdleTicks,
 	}
 }
 
@@ -38,22 +97,215 @@ func (fc *FileCache) ReadFile(filePath string) ([]byte, error) {
 	return os.ReadFile(filePath)
 }
 
+func (fc *FileCache) ModTime(filePath string) (time.Time, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// StoreCache caches a file and evicts the least recently used ones beyond the
+// budget. A file larger than the whole budget is not cached.
 func (fc *FileCache) StoreCache(filePath string, data []byte) {
-	fc.cache.Store(filePath, data)
+	fc.cacheLock.Lock()
+	defer fc.cacheLock.Unlock()
+	if element, ok := fc.entries[filePath]; ok {
+		fc.removeElement(element)
+	}
+	if int64(len(data)) > fc.maxBytes {
+		return
+	}
+	entry := &cacheEntry{filePath: filePath, data: data, storedAt: time.Now()}
+	fc.entries[filePath] = fc.lru.PushFront(entry)
+	fc.bytes += int64(len(data))
+	for fc.bytes > fc.maxBytes {
+		fc.removeElement(fc.lru.Back())
+		fc.evictions++
+	}
 }
 
 func (fc *FileCache) LoadCache(filePath string) ([]byte, bool) {
-	data, ok := fc.cache.Load(filePath)
+	fc.cacheLock.Lock()
+	defer fc.cacheLock.Unlock()
+	element, ok := fc.entries[filePath]
 	if !ok {
+		fc.misses++
 		return nil, false
 	}
-	return data.([]byte), true
+	fc.hits++
+	fc.lru.MoveToFront(element)
+	return element.Value.(*cacheEntry).data, true
 }
 
 func (fc *FileCache) DeleteFile(filePath string) {
-	fc.cache.Delete(filePath)
+	fc.cacheLock.Lock()
+	defer fc.cacheLock.Unlock()
+	if element, ok := fc.entries[filePath]; ok {
+		fc.removeElement(element)
+	}
+}
+
+// Stats returns the counters since the cache was created
+func (fc *FileCache) Stats() file_cache.CacheStats {
+	fc.cacheLock.Lock()
+	defer fc.cacheLock.Unlock()
+	return file_cache.CacheStats{
+		Hits:      fc.hits,
+		Misses:    fc.misses,
+		Evictions: fc.evictions,
+		Entries:   len(fc.entries),
+		Bytes:     fc.bytes,
+		MaxBytes:  fc.maxBytes,
+	}
+}
+
+// removeElement must be called with cacheLock held
+func (fc *FileCache) removeElement(element *list.Element) {
+	entry := fc.lru.Remove(element).(*cacheEntry)
+	delete(fc.entries, entry.filePath)
+	fc.bytes -= int64(len(entry.data))
+}
+
+// LockFile locks one cached file and returns its unlock function. Readers of
+// different files do not wait for each other.
+func (fc *FileCache) LockFile(filePath string) func() {
+	fc.locksLock.Lock()
+	lock, ok := fc.locks[filePath]
+	if !ok {
+		lock = &fileLock{}
+		fc.locks[filePath] = lock
+	}
+	lock.refs++
+	fc.locksLock.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+		fc.locksLock.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(fc.locks, filePath)
+		}
+		fc.locksLock.Unlock()
+	}
+}
+
+// WatchStream starts the refresher of a stream unless it is running. Call it
+// after StoreCache so an idle refresher cannot miss the new entry.
+func (fc *FileCache) WatchStream(uuid, dir string) {
+	fc.refreshersLock.Lock()
+	defer fc.refreshersLock.Unlock()
+	if _, ok := fc.refreshers[uuid]; ok {
+		return
+	}
+	refresher := &streamRefresher{dir: dir, stop: make(chan struct{}), done: make(chan struct{})}
+	fc.refreshers[uuid] = refresher
+	go fc.refresh(uuid, refresher)
+}
+
+// StopStream stops the refresher of a closed stream, waits for it to exit and
+// drops the files it cached
+func (fc *FileCache) StopStream(uuid string) {
+	fc.refreshersLock.Lock()
+	refresher, ok := fc.refreshers[uuid]
+	delete(fc.refreshers, uuid)
+	fc.refreshersLock.Unlock()
+	if !ok {
+		return
+	}
+	close(refresher.stop)
+	<-refresher.done
+	fc.purge(refresher.dir)
+}
+
+func (fc *FileCache) refresh(uuid string, refresher *streamRefresher) {
+	defer close(refresher.done)
+	ticker := time.NewTicker(fc.refreshInterval)
+	defer ticker.Stop()
+	idle := 0
+	for {
+		select {
+		case <-refresher.stop:
+			return
+		case <-ticker.C:
+		}
+
+		// The stream directory is removed when the stream is closed
+		if _, err := os.Stat(refresher.dir); os.IsNotExist(err) {
+			fc.purge(refresher.dir)
+			fc.removeRefresher(uuid, refresher)
+			return
+		}
+
+		// Counted under the lock, see WatchStream
+		fc.refreshersLock.Lock()
+		if fc.invalidateChanged(refresher.dir) > 0 {
+			idle = 0
+		} else if idle++; idle >= fc.refreshIdleTicks {
+			if fc.refreshers[uuid] == refresher {
+				delete(fc.refreshers, uuid)
+			}
+			fc.refreshersLock.Unlock()
+			return
+		}
+		fc.refreshersLock.Unlock()
+	}
+}
+
+func (fc *FileCache) removeRefresher(uuid string, refresher *streamRefresher) {
+	fc.refreshersLock.Lock()
+	defer fc.refreshersLock.Unlock()
+	if fc.refreshers[uuid] == refresher {
+		delete(fc.refreshers, uuid)
+	}
+}
+
+// invalidateChanged drops the cached playlists under dir that were deleted
+// or modified since they were cached. It returns how many cached files are
+// left.
+func (fc *FileCache) invalidateChanged(dir string) int {
+	fc.cacheLock.Lock()
+	left := 0
+	var playlists []*cacheEntry
+	for filePath, element := range fc.entries {
+		if !isInDir(dir, filePath) {
+			continue
+		}
+		left++
+		// Segments never change
+		if filepath.Ext(filePath) == ".m3u8" {
+			playlists = append(playlists, element.Value.(*cacheEntry))
+		}
+	}
+	fc.cacheLock.Unlock()
+
+	for _, entry := range playlists {
+		info, err := os.Stat(entry.filePath)
+		if err == nil && info.ModTime().Before(entry.storedAt.Add(-modTimeSlack)) {
+			continue
+		}
+		fc.cacheLock.Lock()
+		// Unless it was stored again meanwhile
+		if element, ok := fc.entries[entry.filePath]; ok && element.Value == entry {
+			fc.removeElement(element)
+			left--
+		}
+		fc.cacheLock.Unlock()
+	}
+	return left
+}
+
+func (fc *FileCache) purge(dir string) {
+	fc.cacheLock.Lock()
+	defer fc.cacheLock.Unlock()
+	for filePath, element := range fc.entries {
+		if isInDir(dir, filePath) {
+			fc.removeElement(element)
+		}
+	}
 }
 
-func (fc *FileCache) Range(f func(key, value interface{}) bool) {
-	fc.cache.Range(f)
+func isInDir(dir, filePath string) bool {
+	return strings.HasPrefix(filePath, dir+string(filepath.Separator))
 }
@@ -0,0 +1,235 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestFileCache() *FileCache {
+	fc := NewFileCache(1 << 20)
+	fc.refreshInterval = 10 * time.Millisecond
+	return fc
+}
+
+// streamDir creates <root>/<uuid> with an old playlist in it
+func streamDir(t *testing.T, uuid string) (string, string) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), uuid)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	playlist := filepath.Join(dir, "playlist.m3u8")
+	os.WriteFile(playlist, []byte("#EXTM3U"), 0666)
+	old := time.Now().Add(-time.Minute)
+	os.Chtimes(playlist, old, old)
+	return dir, playlist
+}
+
+func (fc *FileCache) refresher(uuid string) *streamRefresher {
+	fc.refreshersLock.Lock()
+	defer fc.refreshersLock.Unlock()
+	return fc.refreshers[uuid]
+}
+
+func waitExited(t *testing.T, refresher *streamRefresher) {
+	t.Helper()
+	select {
+	case <-refresher.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("refresher goroutine did not exit")
+	}
+}
+
+func TestWatchStream_ExitsWhenStreamClosed(t *testing.T) {
+	fc := newTestFileCache()
+	dir, playlist := streamDir(t, "uuid-1")
+	fc.StoreCache(playlist, []byte("#EXTM3U"))
+	fc.WatchStream("uuid-1", dir)
+	fc.WatchStream("uuid-1", dir)
+	refresher := fc.refresher("uuid-1")
+
+	// CloseStream removes the HLS directory of the stream
+	os.RemoveAll(dir)
+	waitExited(t, refresher)
+
+	if _, ok := fc.LoadCache(playlist); ok {
+		t.Fatal("cached playlist of a closed stream was kept")
+	}
+	if fc.refresher("uuid-1") != nil {
+		t.Fatal("exited refresher is still registered")
+	}
+}
+
+func TestStopStream_WaitsForRefresher(t *testing.T) {
+	fc := newTestFileCache()
+	dir, playlist := streamDir(t, "uuid-1")
+	other, otherPlaylist := streamDir(t, "uuid-2")
+	fc.StoreCache(playlist, []byte("#EXTM3U"))
+	fc.StoreCache(otherPlaylist, []byte("#EXTM3U"))
+	fc.WatchStream("uuid-1", dir)
+	fc.WatchStream("uuid-2", other)
+	refresher := fc.refresher("uuid-1")
+
+	fc.StopStream("uuid-1")
+
+	select {
+	case <-refresher.done:
+	default:
+		t.Fatal("StopStream returned before the refresher exited")
+	}
+	if _, ok := fc.LoadCache(playlist); ok {
+		t.Fatal("cached playlist of a stopped stream was kept")
+	}
+	if _, ok := fc.LoadCache(otherPlaylist); !ok {
+		t.Fatal("another stream lost its cache")
+	}
+	fc.StopStream("uuid-2")
+	// Stopping twice is a no-op
+	fc.StopStream("uuid-2")
+}
+
+func TestWatchStream_IdleRefresherExits(t *testing.T) {
+	fc := newTestFileCache()
+	fc.refreshIdleTicks = 3
+	dir, _ := streamDir(t, "uuid-1")
+	fc.WatchStream("uuid-1", dir)
+	refresher := fc.refresher("uuid-1")
+
+	waitExited(t, refresher)
+
+	if fc.refresher("uuid-1") != nil {
+		t.Fatal("idle refresher is still registered")
+	}
+	// The next cached read starts a new one
+	fc.WatchStream("uuid-1", dir)
+	if next := fc.refresher("uuid-1"); next == nil || next == refresher {
+		t.Fatal("refresher was not restarted")
+	}
+	fc.StopStream("uuid-1")
+}
+
+func TestWatchStream_DropsChangedPlaylists(t *testing.T) {
+	fc := newTestFileCache()
+	dir, playlist := streamDir(t, "uuid-1")
+	os.MkdirAll(filepath.Join(dir, "720p"), 0777)
+	rendition := filepath.Join(dir, "720p", "playlist.m3u8")
+	os.WriteFile(rendition, []byte("#EXTM3U"), 0666)
+	old := time.Now().Add(-time.Minute)
+	os.Chtimes(rendition, old, old)
+	segment := filepath.Join(dir, "720p", "segment-1.ts")
+	fc.StoreCache(playlist, []byte("old"))
+	fc.StoreCache(rendition, []byte("old"))
+	fc.StoreCache(segment, []byte("ts"))
+	fc.WatchStream("uuid-1", dir)
+	defer fc.StopStream("uuid-1")
+
+	// ffmpeg rewrites the rendition playlist without an update event
+	os.WriteFile(rendition, []byte("#EXTM3U\nnew"), 0666)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := fc.LoadCache(rendition); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("changed rendition playlist was not dropped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, ok := fc.LoadCache(playlist); !ok {
+		t.Fatal("unchanged playlist was dropped")
+	}
+	if _, ok := fc.LoadCache(segment); !ok {
+		t.Fatal("segments are not refreshed")
+	}
+}
+
+func TestLockFile_LocksPerFile(t *testing.T) {
+	fc := NewFileCache(1 << 20)
+	unlockA := fc.LockFile("/hls/a/playlist.m3u8")
+
+	// Another file is not blocked
+	locked := make(chan struct{})
+	go func() {
+		unlock := fc.LockFile("/hls/b/playlist.m3u8")
+		unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("lock on one file blocked another file")
+	}
+
+	// The same file waits
+	locked = make(chan struct{})
+	go func() {
+		unlock := fc.LockFile("/hls/a/playlist.m3u8")
+		unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		t.Fatal("same file was locked twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlockA()
+	<-locked
+
+	fc.locksLock.Lock()
+	defer fc.locksLock.Unlock()
+	if len(fc.locks) != 0 {
+		t.Fatalf("unused locks were kept: %d", len(fc.locks))
+	}
+}
+
+func TestFileCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	fc := NewFileCache(10)
+	fc.StoreCache("/hls/uuid-1/a.ts", []byte("aaaa"))
+	fc.StoreCache("/hls/uuid-1/b.ts", []byte("bbbb"))
+	// a is used again, so b is the oldest
+	fc.LoadCache("/hls/uuid-1/a.ts")
+	fc.StoreCache("/hls/uuid-1/c.ts", []byte("cccc"))
+
+	if _, ok := fc.LoadCache("/hls/uuid-1/b.ts"); ok {
+		t.Fatal("least recently used file was kept")
+	}
+	for _, filePath := range []string{"/hls/uuid-1/a.ts", "/hls/uuid-1/c.ts"} {
+		if _, ok := fc.LoadCache(filePath); !ok {
+			t.Fatalf("%s was evicted", filePath)
+		}
+	}
+	stats := fc.Stats()
+	if stats.Hits != 3 || stats.Misses != 1 || stats.Evictions != 1 || stats.Entries != 2 || stats.Bytes != 8 || stats.MaxBytes != 10 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestFileCache_ByteBudget(t *testing.T) {
+	fc := NewFileCache(10)
+	// Larger than the whole budget: not cached, nothing evicted for it
+	fc.StoreCache("/hls/uuid-1/small.ts", []byte("1234"))
+	fc.StoreCache("/hls/uuid-1/huge.ts", make([]byte, 11))
+	if _, ok := fc.LoadCache("/hls/uuid-1/huge.ts"); ok {
+		t.Fatal("file over the budget was cached")
+	}
+
+	// Replacing a file counts its new size only
+	fc.StoreCache("/hls/uuid-1/small.ts", []byte("123456"))
+	fc.StoreCache("/hls/uuid-1/other.ts", []byte("1234"))
+	if stats := fc.Stats(); stats.Bytes != 10 || stats.Entries != 2 || stats.Evictions != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	// Any name is evicted, not only timestamped segments
+	fc.StoreCache("/hls/uuid-1/record.m3u8", []byte("#EXTM3U"))
+	if stats := fc.Stats(); stats.Bytes > 10 || stats.Evictions != 2 {
+		t.Fatalf("budget exceeded %+v", stats)
+	}
+	fc.DeleteFile("/hls/uuid-1/record.m3u8")
+	if stats := fc.Stats(); stats.Bytes != 0 || stats.Entries != 0 {
+		t.Fatalf("deleted file still counted %+v", stats)
+	}
+}
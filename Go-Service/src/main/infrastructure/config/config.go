@@ -74,10 +74,25 @@ func LoadConfig() {
 	AppConfig.PostgreSQL.AutoMigrateSchema = getEnvAsBool("SCHEMA_AUTO_MIGRATE", true)
 	AppConfig.JWT.SecretKey = os.Getenv("APP_SECRET_KEY")
 	AppConfig.Server.Domain = os.Getenv("DOMAIN")
-	AppConfig.Server.RTMPHost = getEnvOrDefault("RTMP_HOST", AppConfig.Server.Domain)
+	AppConfig.RTMP.ListenAddr = getEnvOrDefault("RTMP_LISTEN_ADDR", ":1935")
+	// RTMP_HOST is the older name of RTMP_ADVERTISED_HOST
+	AppConfig.RTMP.AdvertisedHost = getEnvOrDefault("RTMP_ADVERTISED_HOST", getEnvOrDefault("RTMP_HOST", AppConfig.Server.Domain))
+	AppConfig.RTMP.AdvertisedPort = int(getEnvAsInt64("RTMP_ADVERTISED_PORT", 1935))
+	AppConfig.RTMP.TLSEnabled = getEnvAsBool("RTMPS_ENABLED", false)
+	AppConfig.RTMP.TLSListenAddr = getEnvOrDefault("RTMPS_LISTEN_ADDR", ":1936")
+	AppConfig.RTMP.TLSAdvertisedPort = int(getEnvAsInt64("RTMPS_ADVERTISED_PORT", 1936))
+	AppConfig.RTMP.TLSCertFile = os.Getenv("RTMPS_CERT_FILE")
+	AppConfig.RTMP.TLSKeyFile = os.Getenv("RTMPS_KEY_FILE")
+	AppConfig.RTMP.OnPublishURL = os.Getenv("RTMP_ON_PUBLISH_URL")
+	AppConfig.RTMP.OnPublishTimeoutMs = getEnvAsInt64("RTMP_ON_PUBLISH_TIMEOUT_MS", 3000)
 	AppConfig.Frontend.Domain = os.Getenv("FRONTEND_DOMAIN")
 	AppConfig.Frontend.Port = int(getEnvAsInt64("FRONTEND_PORT", 3000))
 	AppConfig.Redis.URI = os.Getenv("REDIS_URI")
+	AppConfig.Cache.HLSMaxBytes = getEnvAsInt64("HLS_CACHE_MAX_BYTES", 256<<20)
+	AppConfig.Cache.HLSPlaylistMaxAge = int(getEnvAsInt64("HLS_PLAYLIST_MAX_AGE", 1))
+	AppConfig.Cache.HLSVaryOnAuth = getEnvAsBool("HLS_VARY_ON_AUTH", false)
+	AppConfig.Recording.ConversionWorkers = int(getEnvAsInt64("RECORDING_CONVERSION_WORKERS", 2))
+	AppConfig.Recording.ConversionMaxAttempts = int(getEnvAsInt64("RECORDING_CONVERSION_MAX_ATTEMPTS", 3))
 	AppConfig.Server.EnableGinLog, err = strconv.ParseBool(os.Getenv("ENABLE_GIN_LOG"))
 	if err != nil {
 		log.Printf("Invalid ENABLE_GIN_LOG: %s", err)
@@ -11,11 +11,10 @@ import (
 	"Go-Service/src/main/infrastructure/config"
 	"Go-Service/src/main/infrastructure/message"
 	"Go-Service/src/main/infrastructure/util"
-	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"path/filepath"
+	"strconv"
 
 	claims "github.com/cool9850311/StreamPlatformLite-Core/pkg/claims"
 	"github.com/gin-gonic/gin"
@@ -39,6 +38,11 @@ func NewLivestreamController(log logger.Logger, livestreamUseCase *usecase.Lives
 
 // getClaims safely extracts claims from context
 func (c *LivestreamController) getClaims(ctx *gin.Context) (*claims.Claims, error) {
+	return claimsFromContext(ctx, c.Log)
+}
+
+// claimsFromContext extracts the claims set by the JWT middlewares
+func claimsFromContext(ctx *gin.Context, log logger.Logger) (*claims.Claims, error) {
 	claimsValue := ctx.Request.Context().Value("claims")
 	if claimsValue == nil {
 		return nil, errors.ErrUnauthorized
@@ -46,12 +50,27 @@ func (c *LivestreamController) getClaims(ctx *gin.Context) (*claims.Claims, erro
 
 	cl, ok := claimsValue.(*claims.Claims)
 	if !ok {
-		c.Log.Error(ctx, "Failed to assert claims type")
+		log.Error(ctx, "Failed to assert claims type")
 		return nil, errors.ErrInternal
 	}
 
 	return cl, nil
 }
+
+// respondError maps usecase errors to HTTP responses
+func respondError(ctx *gin.Context, err error) {
+	switch err {
+	case errors.ErrUnauthorized:
+		ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
+	case errors.ErrNotFound:
+		ctx.JSON(http.StatusNotFound, gin.H{"message": message.MsgNotFound})
+	case errors.ErrInvalidInput:
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": message.MsgInvalidInput})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
+	}
+}
+
 func (c *LivestreamController) GetLivestreamByID(ctx *gin.Context) {
 	id := ctx.Param("uuid")
 	claims, err := c.getClaims(ctx)
@@ -95,6 +114,7 @@ func (c *LivestreamController) GetLivestreamByOwnerId(ctx *gin.Context) {
 }
 
 func (c *LivestreamController) GetLivestreamOne(ctx *gin.Context) {
+	id := ctx.Param("uuid")
 	claims, err := c.getClaims(ctx)
 	if err != nil {
 		if err == errors.ErrUnauthorized {
@@ -104,7 +124,14 @@ func (c *LivestreamController) GetLivestreamOne(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
 		return
 	}
-	livestream, err := c.livestreamUseCase.GetOne(ctx, claims.Role)
+	var livestream *livestreamDTO.LivestreamGetOneResponseDTO
+	if id == "" {
+		// Deprecated GET /livestream/one, from before several livestreams
+		livestream, err = c.livestreamUseCase.GetFirst(ctx, claims.Role)
+	} else {
+		// token is the signed share link of a Link livestream
+		livestream, err = c.livestreamUseCase.GetOne(ctx, id, ctx.Query("token"), claims.Role)
+	}
 	if err != nil {
 		if err == errors.ErrUnauthorized {
 			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
@@ -120,6 +147,24 @@ func (c *LivestreamController) GetLivestreamOne(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, livestream)
 }
 
+func (c *LivestreamController) ListLivestreams(ctx *gin.Context) {
+	claims, err := c.getClaims(ctx)
+	if err != nil {
+		if err == errors.ErrUnauthorized {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
+		return
+	}
+	livestreams, err := c.livestreamUseCase.ListLivestreams(ctx, claims.Role)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
+		return
+	}
+	ctx.JSON(http.StatusOK, livestreams)
+}
+
 func (c *LivestreamController) CreateLivestream(ctx *gin.Context) {
 	var livestreamCreateDTO livestreamDTO.LivestreamCreateDTO
 	if err := ctx.ShouldBindJSON(&livestreamCreateDTO); err != nil {
@@ -153,6 +198,8 @@ func (c *LivestreamController) UpdateLivestream(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
+	// The path decides which livestream is updated, not the request body
+	livestream.UUID = ctx.Param("uuid")
 	claims, err := c.getClaims(ctx)
 	if err != nil {
 		if err == errors.ErrUnauthorized {
@@ -189,6 +236,127 @@ func (c *LivestreamController) DeleteLivestream(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"message": "Livestream deleted"})
 }
 
+func (c *LivestreamController) RotateStreamKey(ctx *gin.Context) {
+	id := ctx.Param("uuid")
+	var rotateRequest livestreamDTO.LivestreamRotateKeyRequestDTO
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&rotateRequest); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+	}
+	claims, err := c.getClaims(ctx)
+	if err != nil {
+		if err == errors.ErrUnauthorized {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
+		return
+	}
+	response, err := c.livestreamUseCase.RotateStreamKey(ctx, id, rotateRequest.DropPublisher, claims.Role)
+	if err != nil {
+		if err == errors.ErrUnauthorized {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
+			return
+		}
+		if err == errors.ErrNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": message.MsgNotFound})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (c *LivestreamController) RevokeStreamKey(ctx *gin.Context) {
+	id := ctx.Param("uuid")
+	claims, err := c.getClaims(ctx)
+	if err != nil {
+		if err == errors.ErrUnauthorized {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
+		return
+	}
+	err = c.livestreamUseCase.RevokeStreamKey(ctx, id, claims.Role)
+	if err != nil {
+		if err == errors.ErrUnauthorized {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
+			return
+		}
+		if err == errors.ErrNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": message.MsgNotFound})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Stream key revoked"})
+}
+
+func (c *LivestreamController) KickPublisher(ctx *gin.Context) {
+	id := ctx.Param("uuid")
+	var kickRequest livestreamDTO.LivestreamKickPublisherRequestDTO
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&kickRequest); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+	}
+	claims, err := c.getClaims(ctx)
+	if err != nil {
+		if err == errors.ErrUnauthorized {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
+		return
+	}
+	err = c.livestreamUseCase.KickPublisher(ctx, id, kickRequest.BlockSeconds, claims.Role)
+	if err != nil {
+		if err == errors.ErrUnauthorized {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
+			return
+		}
+		if err == errors.ErrNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": message.MsgNotFound})
+			return
+		}
+		if err == errors.ErrInvalidInput {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": message.MsgInvalidInput})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Publisher kicked"})
+}
+
+func (c *LivestreamController) GetCacheStats(ctx *gin.Context) {
+	claims, err := c.getClaims(ctx)
+	if err != nil {
+		if err == errors.ErrUnauthorized {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
+		return
+	}
+	stats, err := c.livestreamUseCase.GetCacheStats(ctx, claims.Role)
+	if err != nil {
+		if err == errors.ErrUnauthorized {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
+		return
+	}
+	ctx.JSON(http.StatusOK, stats)
+}
+
 func (c *LivestreamController) PingViewerCount(ctx *gin.Context) {
 	id := ctx.Param("uuid")
 	claims, err := c.getClaims(ctx)
@@ -230,7 +398,7 @@ func (c *LivestreamController) PingViewerCount(ctx *gin.Context) {
 		})
 	}
 
-	viewerCount, err := c.livestreamUseCase.PingViewerCount(ctx, claims.Role, id, claims.UserID, anonymousID)
+	viewerCount, err := c.livestreamUseCase.PingViewerCount(ctx, claims.Role, id, claims.UserID, anonymousID, ctx.Query("token"))
 	if err != nil {
 		if err == errors.ErrUnauthorized {
 			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
@@ -262,7 +430,7 @@ func (c *LivestreamController) GetChat(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
 		return
 	}
-	chats, err := c.livestreamUseCase.GetChat(ctx, claims.Role, id, indexStr)
+	chats, err := c.livestreamUseCase.GetChat(ctx, claims.Role, id, indexStr, ctx.Query("token"))
 	if err != nil {
 		if err == errors.ErrUnauthorized {
 			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
@@ -301,7 +469,7 @@ func (c *LivestreamController) AddChat(ctx *gin.Context) {
 		Message:  chatRequest.Message,
 		Role:     claims.Role,
 	}
-	err = c.livestreamUseCase.AddChat(ctx, claims.IdentityProvider, claims.Role, chatRequest.StreamUUID, chat)
+	err = c.livestreamUseCase.AddChat(ctx, claims.IdentityProvider, claims.Role, chatRequest.StreamUUID, chat, ctx.Query("token"))
 	if err != nil {
 		if err == errors.ErrMuteUser {
 			ctx.JSON(http.StatusForbidden, gin.H{"message": message.MsgForbidden})
@@ -329,7 +497,7 @@ func (c *LivestreamController) RemoveViewerCount(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
 		return
 	}
-	err = c.livestreamUseCase.DeleteChat(ctx, claims.Role, claims.UserID, id, chatID)
+	err = c.livestreamUseCase.DeleteChat(ctx, claims.Role, claims.UserID, id, chatID, ctx.Query("token"))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
 		return
@@ -347,7 +515,7 @@ func (c *LivestreamController) GetDeleteChatIDs(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
 		return
 	}
-	ids, err := c.livestreamUseCase.GetDeleteChatIDs(ctx, claims.Role, id)
+	ids, err := c.livestreamUseCase.GetDeleteChatIDs(ctx, claims.Role, id, ctx.Query("token"))
 	if err != nil {
 		if err == errors.ErrUnauthorized {
 			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
@@ -393,6 +561,10 @@ func (c *LivestreamController) MuteUser(ctx *gin.Context) {
 func (c *LivestreamController) GetFile(ctx *gin.Context) {
 	uuidStr := ctx.Param("uuid")
 	filename := ctx.Param("filename")
+	// ABR rendition files live one directory down, e.g. 720p/playlist.m3u8
+	if segment := ctx.Param("segment"); segment != "" {
+		filename = filename + "/" + segment
+	}
 
 	rootPath, err := util.GetProjectRootPath()
 	if err != nil {
@@ -410,8 +582,19 @@ func (c *LivestreamController) GetFile(ctx *gin.Context) {
 		return
 	}
 
+	// LL-HLS blocking playlist reload directives
+	var reload livestreamDTO.LivestreamPlaylistReloadDTO
+	if reload.MSN, err = getOptionalIntQuery(ctx, "_HLS_msn"); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+	if reload.Part, err = getOptionalIntQuery(ctx, "_HLS_part"); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
 	// Pass rootPath (trusted), uuid and filename (external inputs) to usecase
-	fileData, err := c.livestreamUseCase.GetFile(ctx, rootPath, uuidStr, filename, claims.Role)
+	file, err := c.livestreamUseCase.GetFile(ctx, rootPath, uuidStr, filename, reload, ctx.Query("token"), claims.Role)
 	if err != nil {
 		if err == errors.ErrUnauthorized {
 			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
@@ -421,12 +604,126 @@ func (c *LivestreamController) GetFile(ctx *gin.Context) {
 			ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
 			return
 		}
+		if err == errors.ErrTimeout {
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"message": "Playlist not ready"})
+			return
+		}
 		ctx.JSON(http.StatusNotFound, gin.H{"message": "File not found"})
 		return
 	}
+	// Segments are immutable and playlists short lived; only files of public
+	// livestreams may be kept by a CDN
+	etag := util.ETag(file.Data)
+	ctx.Header("Cache-Control", util.HLSCacheControl(filename, file.Public, config.AppConfig.Cache.HLSPlaylistMaxAge))
+	ctx.Header("ETag", etag)
+	if !file.ModTime.IsZero() {
+		ctx.Header("Last-Modified", file.ModTime.UTC().Format(http.TimeFormat))
+	}
+	if config.AppConfig.Cache.HLSVaryOnAuth {
+		ctx.Header("Vary", "Authorization, Cookie")
+	}
+	if util.NotModified(ctx.Request.Header, etag, file.ModTime) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	ctx.Data(http.StatusOK, getContentType(filename), file.Data)
+}
+
+// GetKey serves the AES-128 key an encrypted livestream's playlists point at
+func (c *LivestreamController) GetKey(ctx *gin.Context) {
+	claims, err := c.getClaims(ctx)
+	if err != nil {
+		if err == errors.ErrUnauthorized {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
+		return
+	}
+
+	key, err := c.livestreamUseCase.GetKey(ctx, ctx.Param("uuid"), ctx.Param("key_id"), ctx.Query("token"), claims.IdentityProvider, claims.UserID, claims.Role)
+	if err != nil {
+		if err == errors.ErrUnauthorized {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
+			return
+		}
+		if err == errors.ErrInvalidInput {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+			return
+		}
+		if err == errors.ErrNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": message.MsgNotFound})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
+		return
+	}
+	// Keys are per viewer authorization, shared caches must not keep them
+	ctx.Header("Cache-Control", "private, no-store")
+	ctx.Data(http.StatusOK, "application/octet-stream", key)
+}
+
+// getOptionalIntQuery returns nil when the query parameter is absent
+func getOptionalIntQuery(ctx *gin.Context, name string) (*int, error) {
+	value, ok := ctx.GetQuery(name)
+	if !ok {
+		return nil, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	return &number, nil
+}
+
+// GetLiveFLV streams a live broadcast as HTTP-FLV until the publish ends, the
+// viewer disconnects or the viewer falls too far behind
+func (c *LivestreamController) GetLiveFLV(ctx *gin.Context) {
+	uuidStr := ctx.Param("uuid")
+
+	claims, err := c.getClaims(ctx)
+	if err != nil {
+		if err == errors.ErrUnauthorized {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
+		return
+	}
+
+	subscription, err := c.livestreamUseCase.SubscribeLiveFLV(ctx, uuidStr, ctx.Query("token"), claims.Role)
+	if err != nil {
+		if err == errors.ErrUnauthorized {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
+			return
+		}
+		if err == errors.ErrInvalidInput {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+			return
+		}
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "Livestream is not live"})
+		return
+	}
+	defer subscription.Close()
+
 	ctx.Header("Cache-Control", "no-cache, no-store, must-revalidate")
-	ctx.Data(http.StatusOK, getContentType(filename), fileData)
+	ctx.Header("Content-Type", "video/x-flv")
+	ctx.Status(http.StatusOK)
+	// Stream flushes after every tag so players get them without delay
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case data, ok := <-subscription.Data():
+			if !ok {
+				return false
+			}
+			_, err := w.Write(data)
+			return err == nil
+		case <-ctx.Request.Context().Done():
+			return false
+		}
+	})
 }
+
 func (c *LivestreamController) GetRecord(ctx *gin.Context) {
 	uuidStr := ctx.Param("uuid")
 
@@ -446,8 +743,8 @@ func (c *LivestreamController) GetRecord(ctx *gin.Context) {
 		return
 	}
 
-	// Pass rootPath (trusted) and uuid (external input) to usecase
-	fullFilePath, err := c.livestreamUseCase.GetRecord(ctx, rootPath, uuidStr, claims.Role)
+	// Pass rootPath (trusted), uuid and recording_id (external inputs) to usecase
+	fullFilePath, err := c.livestreamUseCase.GetRecord(ctx, rootPath, uuidStr, ctx.Query("recording_id"), claims.Role)
 	if err != nil {
 		if err == errors.ErrInvalidInput {
 			ctx.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
@@ -456,34 +753,40 @@ func (c *LivestreamController) GetRecord(ctx *gin.Context) {
 		ctx.JSON(http.StatusNotFound, gin.H{"message": "File not found"})
 		return
 	}
-	file, err := os.Open(fullFilePath)
-	if err != nil {
+	// The MP4 is revalidated by its ETag; Range requests resume downloads
+	ctx.Header("Cache-Control", "private, no-cache")
+	if err := util.ServeDownload(ctx.Writer, ctx.Request, fullFilePath, "video/mp4"); err != nil {
+		c.Log.Error(ctx, "Error opening record: "+err.Error())
 		ctx.JSON(http.StatusNotFound, gin.H{"message": "File not found"})
-		return
 	}
-	defer file.Close()
+}
 
-	// Get file info to determine size
-	fileInfo, err := file.Stat()
+// GetRecordStatus reports the MP4 conversion GetRecord queued
+func (c *LivestreamController) GetRecordStatus(ctx *gin.Context) {
+	claims, err := c.getClaims(ctx)
 	if err != nil {
+		if err == errors.ErrUnauthorized {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
 		return
 	}
-
-	ctx.Header("Cache-Control", "no-cache, no-store, must-revalidate")
-	ctx.Header("Content-Type", "video/mp4")
-	ctx.Header("Content-Length", fmt.Sprintf("%d", fileInfo.Size()))
-	filename := filepath.Base(file.Name())
-	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", util.EncodeRFC5987(filename)))
-
-	_, err = io.Copy(ctx.Writer, file)
+	job, err := c.livestreamUseCase.GetRecordStatus(ctx, ctx.Param("uuid"), ctx.Query("recording_id"), claims.Role)
 	if err != nil {
-		// Cannot send JSON response after headers are sent and streaming has started
-		// Just log the error and abort the connection
-		c.Log.Error(ctx, "Error streaming file: "+err.Error())
-		ctx.Abort()
+		switch err {
+		case errors.ErrUnauthorized:
+			ctx.JSON(http.StatusUnauthorized, gin.H{"message": message.MsgUnauthorized})
+		case errors.ErrInvalidInput:
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": message.MsgInvalidInput})
+		case errors.ErrNotFound:
+			ctx.JSON(http.StatusNotFound, gin.H{"message": message.MsgNotFound})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": message.MsgInternalServerError})
+		}
 		return
 	}
+	ctx.JSON(http.StatusOK, job)
 }
 
 func getContentType(filename string) string {
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	livestreamDTO "Go-Service/src/main/application/dto/livestream"
+	"Go-Service/src/main/application/usecase"
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/interface/logger"
+	"Go-Service/src/main/infrastructure/config"
+	"Go-Service/src/main/infrastructure/util"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RecordingController struct {
+	Log              logger.Logger
+	recordingUseCase *usecase.RecordingUsecase
+}
+
+func NewRecordingController(log logger.Logger, recordingUseCase *usecase.RecordingUsecase) *RecordingController {
+	return &RecordingController{
+		Log:              log,
+		recordingUseCase: recordingUseCase,
+	}
+}
+
+func (c *RecordingController) ListRecordings(ctx *gin.Context) {
+	claims, err := claimsFromContext(ctx, c.Log)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	recordings, err := c.recordingUseCase.ListRecordings(ctx, ctx.Param("uuid"), claims.Role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, recordings)
+}
+
+func (c *RecordingController) GetRecording(ctx *gin.Context) {
+	claims, err := claimsFromContext(ctx, c.Log)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	recording, err := c.recordingUseCase.GetRecording(ctx, ctx.Param("uuid"), ctx.Param("recording_id"), claims.Role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, recording)
+}
+
+func (c *RecordingController) DeleteRecording(ctx *gin.Context) {
+	claims, err := claimsFromContext(ctx, c.Log)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	err = c.recordingUseCase.DeleteRecording(ctx, ctx.Param("uuid"), ctx.Param("recording_id"), claims.Role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Recording deleted"})
+}
+
+func (c *RecordingController) UpdateVisibility(ctx *gin.Context) {
+	claims, err := claimsFromContext(ctx, c.Log)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	var body livestreamDTO.RecordingVisibilityDTO
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		respondError(ctx, errors.ErrInvalidInput)
+		return
+	}
+	recording, err := c.recordingUseCase.UpdateRecordingVisibility(ctx, ctx.Param("uuid"), ctx.Param("recording_id"), body.Visibility, claims.Role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, recording)
+}
+
+func (c *RecordingController) ListVODs(ctx *gin.Context) {
+	claims, err := claimsFromContext(ctx, c.Log)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	recordings, err := c.recordingUseCase.ListVODs(ctx, ctx.Param("uuid"), claims.Role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, recordings)
+}
+
+// GetVODFile serves the playlist and segments of a recording played back
+// over HLS
+func (c *RecordingController) GetVODFile(ctx *gin.Context) {
+	claims, err := claimsFromContext(ctx, c.Log)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	filename := ctx.Param("filename")
+	file, err := c.recordingUseCase.GetVODFile(ctx, ctx.Param("recording_id"), filename, claims.Role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	// The files of an ended recording no longer change, but its visibility
+	// can, so neither the playlist nor the segments are cached for long
+	ctx.Header("Cache-Control", util.VODCacheControl(file.Public, config.AppConfig.Cache.HLSPlaylistMaxAge))
+	if config.AppConfig.Cache.HLSVaryOnAuth {
+		ctx.Header("Vary", "Authorization, Cookie")
+	}
+	if err := util.ServeFile(ctx.Writer, ctx.Request, file.Path, getContentType(filename)); err != nil {
+		respondError(ctx, errors.ErrNotFound)
+	}
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	livestreamDTO "Go-Service/src/main/application/dto/livestream"
+	"Go-Service/src/main/application/usecase"
+	"Go-Service/src/main/domain/interface/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RestreamController struct {
+	Log             logger.Logger
+	restreamUseCase *usecase.RestreamUsecase
+}
+
+func NewRestreamController(log logger.Logger, restreamUseCase *usecase.RestreamUsecase) *RestreamController {
+	return &RestreamController{
+		Log:             log,
+		restreamUseCase: restreamUseCase,
+	}
+}
+
+func (c *RestreamController) ListTargets(ctx *gin.Context) {
+	claims, err := claimsFromContext(ctx, c.Log)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	targets, err := c.restreamUseCase.ListTargets(ctx, ctx.Param("uuid"), claims.Role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, targets)
+}
+
+func (c *RestreamController) CreateTarget(ctx *gin.Context) {
+	var request livestreamDTO.RestreamTargetRequestDTO
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	claims, err := claimsFromContext(ctx, c.Log)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	target, err := c.restreamUseCase.CreateTarget(ctx, ctx.Param("uuid"), &request, claims.Role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, target)
+}
+
+func (c *RestreamController) UpdateTarget(ctx *gin.Context) {
+	var request livestreamDTO.RestreamTargetRequestDTO
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	claims, err := claimsFromContext(ctx, c.Log)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	err = c.restreamUseCase.UpdateTarget(ctx, ctx.Param("uuid"), ctx.Param("target_id"), &request, claims.Role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Restream target updated"})
+}
+
+func (c *RestreamController) DeleteTarget(ctx *gin.Context) {
+	claims, err := claimsFromContext(ctx, c.Log)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	err = c.restreamUseCase.DeleteTarget(ctx, ctx.Param("uuid"), ctx.Param("target_id"), claims.Role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Restream target deleted"})
+}
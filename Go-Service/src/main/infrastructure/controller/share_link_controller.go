@@ -0,0 +1,69 @@
+package controller
+
+import (
+	livestreamDTO "Go-Service/src/main/application/dto/livestream"
+	"Go-Service/src/main/application/usecase"
+	"Go-Service/src/main/domain/interface/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShareLinkController struct {
+	Log              logger.Logger
+	shareLinkUseCase *usecase.ShareLinkUsecase
+}
+
+func NewShareLinkController(log logger.Logger, shareLinkUseCase *usecase.ShareLinkUsecase) *ShareLinkController {
+	return &ShareLinkController{
+		Log:              log,
+		shareLinkUseCase: shareLinkUseCase,
+	}
+}
+
+func (c *ShareLinkController) CreateShareLink(ctx *gin.Context) {
+	var request livestreamDTO.ShareLinkCreateDTO
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	claims, err := claimsFromContext(ctx, c.Log)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	link, err := c.shareLinkUseCase.CreateShareLink(ctx, ctx.Param("uuid"), &request, claims.Role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, link)
+}
+
+func (c *ShareLinkController) ListShareLinks(ctx *gin.Context) {
+	claims, err := claimsFromContext(ctx, c.Log)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	links, err := c.shareLinkUseCase.ListShareLinks(ctx, ctx.Param("uuid"), claims.Role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, links)
+}
+
+func (c *ShareLinkController) RevokeShareLink(ctx *gin.Context) {
+	claims, err := claimsFromContext(ctx, c.Log)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	err = c.shareLinkUseCase.RevokeShareLink(ctx, ctx.Param("uuid"), ctx.Param("link_id"), claims.Role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Share link revoked"})
+}
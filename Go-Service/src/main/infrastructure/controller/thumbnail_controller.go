@@ -0,0 +1,84 @@
+package controller
+
+import (
+	livestreamDTO "Go-Service/src/main/application/dto/livestream"
+	"Go-Service/src/main/application/usecase"
+	"Go-Service/src/main/domain/interface/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ThumbnailController struct {
+	Log              logger.Logger
+	thumbnailUseCase *usecase.ThumbnailUsecase
+}
+
+func NewThumbnailController(log logger.Logger, thumbnailUseCase *usecase.ThumbnailUsecase) *ThumbnailController {
+	return &ThumbnailController{
+		Log:              log,
+		thumbnailUseCase: thumbnailUseCase,
+	}
+}
+
+func (c *ThumbnailController) GetThumbnail(ctx *gin.Context) {
+	claims, err := claimsFromContext(ctx, c.Log)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	data, err := c.thumbnailUseCase.GetThumbnail(ctx, ctx.Param("uuid"), ctx.Query("token"), claims.Role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	// A new snapshot replaces it every capture interval
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Data(http.StatusOK, "image/jpeg", data)
+}
+
+func (c *ThumbnailController) ListThumbnails(ctx *gin.Context) {
+	claims, err := claimsFromContext(ctx, c.Log)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	thumbnails, err := c.thumbnailUseCase.ListThumbnails(ctx, ctx.Param("uuid"), claims.Role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, thumbnails)
+}
+
+func (c *ThumbnailController) GetThumbnailByID(ctx *gin.Context) {
+	claims, err := claimsFromContext(ctx, c.Log)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	data, err := c.thumbnailUseCase.GetThumbnailByID(ctx, ctx.Param("uuid"), ctx.Param("thumbnail_id"), claims.Role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.Data(http.StatusOK, "image/jpeg", data)
+}
+
+func (c *ThumbnailController) SetPoster(ctx *gin.Context) {
+	var request livestreamDTO.ThumbnailPosterDTO
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	claims, err := claimsFromContext(ctx, c.Log)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	if err := c.thumbnailUseCase.SetPoster(ctx, ctx.Param("uuid"), &request, claims.Role); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Poster updated"})
+}
@@ -12,7 +12,10 @@ import (
 	"Go-Service/src/main/infrastructure/repository"
 	"Go-Service/src/main/infrastructure/util"
 	"context"
+	"crypto/tls"
 	"log"
+	"path/filepath"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -28,8 +31,10 @@ import (
 var GormDB *gorm.DB
 var Log domainLogger.Logger
 var LiveStreamService stream.ILivestreamService
+var BroadcastSessionUseCase *usecase.BroadcastSessionUsecase
 var RedisClient *redis.Client
 var cronJob *cron.Cron
+var conversionUseCase *usecase.ConversionUsecase
 
 func InitLog() {
 	var err error
@@ -92,10 +97,47 @@ func InitRedisClient() {
 }
 
 func InitLiveStreamService(log domainLogger.Logger, db *gorm.DB) {
-	LiveStreamService = livestream.NewLivestreamService(log)
+	var publishHook livestream.PublishHook
+	if config.AppConfig.RTMP.OnPublishURL != "" {
+		timeout := time.Duration(config.AppConfig.RTMP.OnPublishTimeoutMs) * time.Millisecond
+		publishHook = livestream.NewHTTPPublishHook(config.AppConfig.RTMP.OnPublishURL, timeout)
+		log.Info(context.TODO(), "RTMP on_publish hook enabled: "+config.AppConfig.RTMP.OnPublishURL)
+	}
+	livestreamService := livestream.NewLivestreamService(log, config.AppConfig.RTMP.ListenAddr, publishHook)
+	if config.AppConfig.RTMP.TLSEnabled {
+		cert, err := tls.LoadX509KeyPair(config.AppConfig.RTMP.TLSCertFile, config.AppConfig.RTMP.TLSKeyFile)
+		if err != nil {
+			log.Fatal(context.TODO(), "Failed to load RTMPS certificate: "+err.Error())
+		}
+		livestreamService.EnableTLS(config.AppConfig.RTMP.TLSListenAddr, &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		})
+	}
+	LiveStreamService = livestreamService
+
+	// Sessions still open were cut off by the last shutdown
+	BroadcastSessionUseCase = usecase.NewBroadcastSessionUsecase(repository.NewPostgresBroadcastSessionRepository(db), log)
+	BroadcastSessionUseCase.CloseDanglingSessions(context.TODO())
+	LiveStreamService.OnPublishEvent(BroadcastSessionUseCase.HandlePublishEvent)
+
+	rootPath, err := util.GetProjectRootPath()
+	if err != nil {
+		log.Fatal(context.TODO(), "Failed to get project root path: "+err.Error())
+	}
+	livestreamRepo := repository.NewPostgresLivestreamRepository(db)
+	recordingUseCase := usecase.NewRecordingUsecase(repository.NewPostgresRecordingRepository(db), livestreamRepo, repository.NewPostgresConversionJobRepository(db), log, util.NewFileRecordingStore(filepath.Join(rootPath, "hls")))
+	recordingUseCase.CloseDanglingRecordings(context.TODO())
+	LiveStreamService.OnPublishEvent(recordingUseCase.HandlePublishEvent)
+
+	restreamUseCase := usecase.NewRestreamUsecase(repository.NewPostgresRestreamTargetRepository(db), livestreamRepo, log, LiveStreamService)
+	LiveStreamService.SetRestreamTargetSource(restreamUseCase.EnabledTargets)
+
+	transcodeUseCase := usecase.NewTranscodeUsecase(livestreamRepo, log, LiveStreamService, util.NewFfmpegLibrary(), filepath.Join(rootPath, "hls"))
+	LiveStreamService.OnPublishEvent(transcodeUseCase.HandlePublishEvent)
 
 	// Start the service
-	err := LiveStreamService.StartService()
+	err = LiveStreamService.StartService()
 	if err != nil {
 		log.Fatal(context.TODO(), "Failed to start LiveStreamService: "+err.Error())
 	}
@@ -107,37 +149,73 @@ func InitLiveStreamService(log domainLogger.Logger, db *gorm.DB) {
 			log.Fatal(context.TODO(), "RunLoop error: "+err.Error())
 		}
 	}()
-	livestreamRepo := repository.NewPostgresLivestreamRepository(db)
-	result, err := livestreamRepo.GetOne()
+	livestreams, err := livestreamRepo.List()
 	if err != nil {
-		log.Info(context.TODO(), "No Stream Found: "+err.Error())
+		log.Error(context.TODO(), "Error listing livestreams: "+err.Error())
 		return
 	}
-	LiveStreamService.OpenStream(result.Name, result.UUID, result.APIKey, result.IsRecord)
-	log.Info(context.TODO(), "Livestream Started: "+result.UUID)
-	// time.Sleep(time.Second)
-
-	// // Open a stream
-	// LiveStreamService.OpenStream("test1", "test2", "test")
-	// LiveStreamService.CloseStream("test2")
+	if len(livestreams) == 0 {
+		log.Info(context.TODO(), "No Stream Found")
+		return
+	}
+	for _, ls := range livestreams {
+		LiveStreamService.OpenStream(ls.Name, ls.UUID, ls.APIKey, usecase.StreamOptions(ls))
+		log.Info(context.TODO(), "Livestream Started: "+ls.UUID)
+	}
 }
-func InitCronJob(log domainLogger.Logger, db *gorm.DB) {
+
+// InitCronJob starts the background jobs. The recording conversions run
+// until ctx is done.
+func InitCronJob(ctx context.Context, log domainLogger.Logger, db *gorm.DB) {
 	cronJob = cron.New()
 	viewerCountCache := cache.NewRedisViewerCount(RedisClient)
 	chatCache := cache.NewRedisChat(RedisClient)
-	fileCache := cache.NewFileCache()
+	fileCache := cache.NewFileCache(config.AppConfig.Cache.HLSMaxBytes)
 	ffmpegLibrary := util.NewFfmpegLibrary()
 	livestreamRepo := repository.NewPostgresLivestreamRepository(db)
-	livestreamUseCase := usecase.NewLivestreamUsecase(livestreamRepo, log, config.AppConfig, LiveStreamService, viewerCountCache, chatCache, fileCache, ffmpegLibrary)
+	rootPath, err := util.GetProjectRootPath()
+	if err != nil {
+		log.Fatal(context.Background(), "Failed to get project root path: "+err.Error())
+	}
+	recordingRepo := repository.NewPostgresRecordingRepository(db)
+	conversionJobRepo := repository.NewPostgresConversionJobRepository(db)
+	livestreamUseCase := usecase.NewLivestreamUsecase(livestreamRepo, log, config.AppConfig, LiveStreamService, viewerCountCache, chatCache, fileCache, repository.NewPostgresShareLinkRepository(db), util.NewJWTLibrary(), util.NewFileHLSKeyStore(filepath.Join(rootPath, "hls")), recordingRepo, conversionJobRepo)
 	cronJob.AddFunc("@every 10s", func() {
 		log.Info(context.Background(), "Running viewer count cleanup")
-		ls, err := livestreamRepo.GetOne()
+		livestreams, err := livestreamRepo.List()
 		if err != nil {
-			log.Error(context.Background(), "Error fetching livestream: "+err.Error())
+			log.Error(context.Background(), "Error fetching livestreams: "+err.Error())
 			return
 		}
-		livestreamUseCase.RemoveViewerCount(context.Background(), ls.UUID, 10)
+		for _, ls := range livestreams {
+			viewerCount, err := livestreamUseCase.RemoveViewerCount(context.Background(), ls.UUID, 10)
+			if err != nil {
+				continue
+			}
+			BroadcastSessionUseCase.RecordViewerCount(context.Background(), ls.UUID, viewerCount)
+		}
+	})
+
+	// Keys rotate every livestream.HLSKeyRotationSeconds
+	cronJob.AddFunc("@every 10m", func() {
+		livestreamUseCase.PruneHLSKeys(context.Background())
+	})
+
+	thumbnailUseCase := usecase.NewThumbnailUsecase(livestreamRepo, log, config.AppConfig, LiveStreamService, ffmpegLibrary, util.NewFileThumbnailStore(filepath.Join(rootPath, "hls")), repository.NewPostgresShareLinkRepository(db), util.NewJWTLibrary())
+	cronJob.AddFunc("@every 30s", func() {
+		thumbnailUseCase.CaptureAll(context.Background())
 	})
 
 	cronJob.Start()
+
+	// Recording conversions, queued when a recorded publish ends
+	conversionUseCase = usecase.NewConversionUsecase(conversionJobRepo, recordingRepo, livestreamRepo, log, ffmpegLibrary, filepath.Join(rootPath, "hls"), config.AppConfig.Recording.ConversionWorkers, config.AppConfig.Recording.ConversionMaxAttempts)
+	conversionUseCase.Start(ctx)
+}
+
+// StopCronJob waits for the running cron jobs and, once the context passed to
+// InitCronJob is done, for the conversion workers to hand back their jobs
+func StopCronJob() {
+	<-cronJob.Stop().Done()
+	conversionUseCase.Wait()
 }
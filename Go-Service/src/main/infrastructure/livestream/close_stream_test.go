@@ -0,0 +1,43 @@
+package livestream
+
+import (
+	streamInterface "Go-Service/src/main/application/interface/stream"
+	"Go-Service/src/main/infrastructure/cache"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestCloseStream_StopsPlaylistCacheRefresher(t *testing.T) {
+	service := NewLivestreamService(nopLogger{}, "127.0.0.1:0", nil)
+	service.hlsRoot = t.TempDir()
+	service.OpenStream("stream", "uuid-1", "key-1", streamInterface.StreamOptions{})
+	dir := filepath.Join(service.hlsRoot, "uuid-1")
+	os.MkdirAll(filepath.Join(dir, "720p"), 0777)
+	playlist := filepath.Join(dir, "720p", "playlist.m3u8")
+	os.WriteFile(playlist, []byte("#EXTM3U"), 0666)
+
+	baseline := runtime.NumGoroutine()
+	fileCache := cache.NewFileCache(1 << 20)
+	fileCache.StoreCache(playlist, []byte("#EXTM3U"))
+	fileCache.WatchStream("uuid-1", dir)
+	if runtime.NumGoroutine() <= baseline {
+		t.Fatal("refresher goroutine was not started")
+	}
+
+	// No StopStream: removing the stream directory is enough
+	service.CloseStream("uuid-1")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			t.Fatalf("refresher goroutine leaked: %d goroutines, expected %d", runtime.NumGoroutine(), baseline)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, ok := fileCache.LoadCache(playlist); ok {
+		t.Fatal("cached playlist of a closed stream was kept")
+	}
+}
@@ -0,0 +1,105 @@
+package livestream
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const dvrPlaylistName = "dvr.m3u8"
+
+type dvrSegment struct {
+	uri      string
+	duration float64
+	discont  bool
+}
+
+// dvrPlaylist writes dvr.m3u8, a sliding playlist of the segments closed
+// within the DVR window of a publish, next to the live playlist. It is only
+// used by the RTMP connection goroutine of the publish.
+type dvrPlaylist struct {
+	outPath string
+	window  time.Duration
+	// deleteExpired removes segments once they slide out of the window
+	deleteExpired bool
+	onUpdate      func(playlistPath string)
+
+	segments    []dvrSegment
+	total       float64
+	mediaSeq    int
+	discontSeq  int
+	maxDuration int
+}
+
+func newDVRPlaylist(outPath string, window time.Duration, deleteExpired bool, onUpdate func(playlistPath string)) *dvrPlaylist {
+	return &dvrPlaylist{
+		outPath:       outPath,
+		window:        window,
+		deleteExpired: deleteExpired,
+		onUpdate:      onUpdate,
+	}
+}
+
+// add appends a closed segment and drops the ones that are no longer needed
+// to cover the window
+func (d *dvrPlaylist) add(uri string, duration float64, discont bool) error {
+	d.segments = append(d.segments, dvrSegment{uri: uri, duration: duration, discont: discont})
+	d.total += duration
+	d.maxDuration = max(d.maxDuration, int(math.Ceil(duration)))
+	for len(d.segments) > 1 && d.total-d.segments[0].duration >= d.window.Seconds() {
+		expired := d.segments[0]
+		d.segments = d.segments[1:]
+		d.total -= expired.duration
+		d.mediaSeq++
+		if expired.discont {
+			d.discontSeq++
+		}
+		if d.deleteExpired {
+			_ = os.Remove(filepath.Join(d.outPath, expired.uri))
+		}
+	}
+	return d.write(false)
+}
+
+// close finishes the playlist with EXT-X-ENDLIST
+func (d *dvrPlaylist) close() error {
+	if len(d.segments) == 0 {
+		return nil
+	}
+	return d.write(true)
+}
+
+func (d *dvrPlaylist) write(ended bool) error {
+	var buf bytes.Buffer
+	buf.WriteString("#EXTM3U\n")
+	buf.WriteString("#EXT-X-VERSION:3\n")
+	buf.WriteString(fmt.Sprintf("#EXT-X-TARGETDURATION:%d\n", d.maxDuration))
+	buf.WriteString(fmt.Sprintf("#EXT-X-MEDIA-SEQUENCE:%d\n", d.mediaSeq))
+	if d.discontSeq > 0 {
+		buf.WriteString(fmt.Sprintf("#EXT-X-DISCONTINUITY-SEQUENCE:%d\n", d.discontSeq))
+	}
+	for _, segment := range d.segments {
+		if segment.discont {
+			buf.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		buf.WriteString(fmt.Sprintf("#EXTINF:%.3f,\n%s\n", segment.duration, segment.uri))
+	}
+	if ended {
+		buf.WriteString("#EXT-X-ENDLIST\n")
+	}
+
+	path := filepath.Join(d.outPath, dvrPlaylistName)
+	if err := os.WriteFile(path+".tmp", buf.Bytes(), 0666); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	if d.onUpdate != nil {
+		d.onUpdate(path)
+	}
+	return nil
+}
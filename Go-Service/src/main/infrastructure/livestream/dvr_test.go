@@ -0,0 +1,85 @@
+package livestream
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeSegments(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("ts"), 0666); err != nil {
+			t.Fatalf("write segment failed: %v", err)
+		}
+	}
+}
+
+func TestDVRPlaylist_SlidesWindow(t *testing.T) {
+	dir := t.TempDir()
+	var updates []string
+	dvr := newDVRPlaylist(dir, 10*time.Second, true, func(playlistPath string) {
+		updates = append(updates, playlistPath)
+	})
+
+	for i := 0; i < 8; i++ {
+		name := fmt.Sprintf("stream-%d.ts", i)
+		writeSegments(t, dir, name)
+		if err := dvr.add(name, 4, i == 5); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, dvrPlaylistName))
+	if err != nil {
+		t.Fatalf("dvr.m3u8 not written: %v", err)
+	}
+	// 12s of segments cover the 10s window
+	expected := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:4\n#EXT-X-MEDIA-SEQUENCE:5\n" +
+		"#EXT-X-DISCONTINUITY\n#EXTINF:4.000,\nstream-5.ts\n" +
+		"#EXTINF:4.000,\nstream-6.ts\n" +
+		"#EXTINF:4.000,\nstream-7.ts\n"
+	if string(content) != expected {
+		t.Fatalf("unexpected dvr playlist:\n%s", content)
+	}
+	for i := 0; i < 8; i++ {
+		_, err := os.Stat(filepath.Join(dir, fmt.Sprintf("stream-%d.ts", i)))
+		if kept := err == nil; kept != (i >= 5) {
+			t.Fatalf("segment %d kept=%v", i, kept)
+		}
+	}
+	if len(updates) != 8 || updates[0] != filepath.Join(dir, dvrPlaylistName) {
+		t.Fatalf("expected an update per segment, got %v", updates)
+	}
+
+	// The discontinuity leaves with its segment
+	writeSegments(t, dir, "stream-8.ts")
+	dvr.add("stream-8.ts", 4, false)
+	if err := dvr.close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	content, _ = os.ReadFile(filepath.Join(dir, dvrPlaylistName))
+	if !strings.Contains(string(content), "#EXT-X-MEDIA-SEQUENCE:6\n#EXT-X-DISCONTINUITY-SEQUENCE:1\n") ||
+		!strings.HasSuffix(string(content), "stream-8.ts\n#EXT-X-ENDLIST\n") {
+		t.Fatalf("unexpected closed dvr playlist:\n%s", content)
+	}
+}
+
+func TestDVRPlaylist_KeepsExpiredWhenRecording(t *testing.T) {
+	dir := t.TempDir()
+	dvr := newDVRPlaylist(dir, time.Second, false, nil)
+	writeSegments(t, dir, "a.ts", "b.ts")
+	dvr.add("a.ts", 2, false)
+	dvr.add("b.ts", 2, false)
+
+	content, _ := os.ReadFile(filepath.Join(dir, dvrPlaylistName))
+	if strings.Contains(string(content), "a.ts") {
+		t.Fatalf("segment outside the window is still listed:\n%s", content)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.ts")); err != nil {
+		t.Fatal("recorded segment was deleted")
+	}
+}
@@ -0,0 +1,144 @@
+package livestream
+
+import (
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/interface/logger"
+	"context"
+	"sync"
+
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/base"
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/httpflv"
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/rtmp"
+)
+
+// flvViewerQueueSize is how many tags a viewer may fall behind before it is
+// dropped. The HTTP handler writes at the pace of the viewer's connection, so
+// a viewer that cannot keep up fills its queue instead of stalling the
+// publisher.
+const flvViewerQueueSize = 512
+
+// flvHub fans the AV messages of one publish out to HTTP-FLV viewers
+type flvHub struct {
+	uuid   string
+	logger logger.Logger
+
+	mu             sync.Mutex
+	viewers        map[*flvViewer]struct{}
+	closed         bool
+	metadata       []byte
+	videoSeqHeader []byte
+	audioSeqHeader []byte
+}
+
+func newFlvHub(uuid string, logger logger.Logger) *flvHub {
+	return &flvHub{uuid: uuid, logger: logger, viewers: make(map[*flvViewer]struct{})}
+}
+
+// flvViewer implements stream.FlvSubscription. Its fields are guarded by the
+// hub's lock, and data is only closed by the hub while removing the viewer.
+type flvViewer struct {
+	hub          *flvHub
+	data         chan []byte
+	waitKeyframe bool
+}
+
+func (v *flvViewer) Data() <-chan []byte {
+	return v.data
+}
+
+// Close unsubscribes the viewer. It is safe to call more than once.
+func (v *flvViewer) Close() {
+	v.hub.mu.Lock()
+	defer v.hub.mu.Unlock()
+	v.hub.removeLocked(v)
+}
+
+// subscribe adds a viewer that starts with the FLV header and the cached
+// headers, then receives media from the next keyframe on
+func (h *flvHub) subscribe() (*flvViewer, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, errors.ErrNotFound
+	}
+	viewer := &flvViewer{hub: h, data: make(chan []byte, flvViewerQueueSize), waitKeyframe: true}
+	viewer.data <- httpflv.FlvHeader
+	for _, tag := range [][]byte{h.metadata, h.videoSeqHeader, h.audioSeqHeader} {
+		if tag != nil {
+			viewer.data <- tag
+		}
+	}
+	h.viewers[viewer] = struct{}{}
+	return viewer, nil
+}
+
+func (h *flvHub) feed(msg base.RtmpMsg) {
+	var tag []byte
+	switch msg.Header.MsgTypeId {
+	case base.RtmpTypeIdAudio, base.RtmpTypeIdVideo:
+		if len(msg.Payload) < 2 {
+			return
+		}
+		tag = httpflv.PackHttpflvTag(msg.Header.MsgTypeId, msg.Header.TimestampAbs, msg.Payload)
+	case base.RtmpTypeIdMetadata:
+		// FLV metadata is the onMetaData object without the @setDataFrame
+		// wrapper RTMP sends it in
+		payload, err := rtmp.MetadataEnsureWithoutSdf(msg.Payload)
+		if err != nil {
+			return
+		}
+		tag = httpflv.PackHttpflvTag(msg.Header.MsgTypeId, msg.Header.TimestampAbs, payload)
+	default:
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	isVideoHeader := isVideoSeqHeader(msg)
+	switch {
+	case msg.Header.MsgTypeId == base.RtmpTypeIdMetadata:
+		h.metadata = tag
+	case isVideoHeader:
+		h.videoSeqHeader = tag
+	case msg.IsAacSeqHeader():
+		h.audioSeqHeader = tag
+	}
+	for viewer := range h.viewers {
+		if viewer.waitKeyframe && msg.Header.MsgTypeId == base.RtmpTypeIdVideo && !isVideoHeader {
+			if !isVideoKeyframe(msg) {
+				continue
+			}
+			viewer.waitKeyframe = false
+		}
+		select {
+		case viewer.data <- tag:
+		default:
+			h.logger.Warn(context.TODO(), "Dropping slow HTTP-FLV viewer of livestream "+h.uuid)
+			h.removeLocked(viewer)
+		}
+	}
+}
+
+// close ends the stream for every viewer
+func (h *flvHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for viewer := range h.viewers {
+		h.removeLocked(viewer)
+	}
+}
+
+func (h *flvHub) viewerCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.viewers)
+}
+
+func (h *flvHub) removeLocked(viewer *flvViewer) {
+	if _, ok := h.viewers[viewer]; !ok {
+		return
+	}
+	delete(h.viewers, viewer)
+	close(viewer.data)
+}
@@ -0,0 +1,144 @@
+package livestream
+
+import (
+	streamInterface "Go-Service/src/main/application/interface/stream"
+	"Go-Service/src/main/domain/entity/errors"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/base"
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/httpflv"
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/rtmp"
+)
+
+func waitFlvData(t *testing.T, subscription streamInterface.FlvSubscription) ([]byte, bool) {
+	t.Helper()
+	select {
+	case data, ok := <-subscription.Data():
+		return data, ok
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for FLV data")
+		return nil, false
+	}
+}
+
+// flvTagInfo returns the tag type, timestamp and first two payload bytes
+func flvTagInfo(t *testing.T, tag []byte) (tagType uint8, timestamp uint32, head [2]byte) {
+	t.Helper()
+	if len(tag) < httpflv.TagHeaderSize+2 {
+		t.Fatalf("FLV tag too short: %x", tag)
+	}
+	timestamp = uint32(tag[4])<<16 | uint32(tag[5])<<8 | uint32(tag[6]) | uint32(tag[7])<<24
+	return tag[0], timestamp, [2]byte{tag[httpflv.TagHeaderSize], tag[httpflv.TagHeaderSize+1]}
+}
+
+func TestHttpFlv_ViewerStartsOnKeyframe(t *testing.T) {
+	service := NewLivestreamService(nopLogger{}, "127.0.0.1:0", nil)
+	addr := startTestServer(t, service)
+	service.OpenStream("stream", "uuid-1", "key-1", streamInterface.StreamOptions{})
+
+	if _, err := service.SubscribeFLV("uuid-1"); err != errors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound before publishing, got %v", err)
+	}
+
+	push := rtmp.NewPushSession()
+	if err := push.Start("rtmp://" + addr + "/live/key-1"); err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+	defer push.Dispose()
+
+	var subscription streamInterface.FlvSubscription
+	deadline := time.Now().Add(5 * time.Second)
+	for subscription == nil {
+		var err error
+		if subscription, err = service.SubscribeFLV("uuid-1"); err != nil {
+			if time.Now().After(deadline) {
+				t.Fatalf("SubscribeFLV failed: %v", err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	defer subscription.Close()
+
+	if data, _ := waitFlvData(t, subscription); !bytes.Equal(data, httpflv.FlvHeader) {
+		t.Fatalf("expected the FLV header first, got %x", data)
+	}
+	for _, msg := range []base.RtmpMsg{testSeqHeader(), testFrame(false, 10), testFrame(true, 40)} {
+		if err := push.WriteMsg(msg); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	push.Flush()
+
+	data, _ := waitFlvData(t, subscription)
+	if tagType, _, head := flvTagInfo(t, data); tagType != httpflv.TagTypeVideo || head != [2]byte{0x17, 0x00} {
+		t.Fatalf("expected the sequence header, got %x", data)
+	}
+	// The inter frame before the first keyframe is skipped
+	data, _ = waitFlvData(t, subscription)
+	if _, timestamp, head := flvTagInfo(t, data); head != [2]byte{0x17, 0x01} || timestamp != 40 {
+		t.Fatalf("expected the keyframe at 40ms, got %x", data)
+	}
+
+	// A late joiner gets the cached sequence header right after the FLV header
+	late, err := service.SubscribeFLV("uuid-1")
+	if err != nil {
+		t.Fatalf("SubscribeFLV failed: %v", err)
+	}
+	defer late.Close()
+	waitFlvData(t, late)
+	if data, _ := waitFlvData(t, late); !bytes.Equal(data[httpflv.TagHeaderSize:httpflv.TagHeaderSize+2], []byte{0x17, 0x00}) {
+		t.Fatalf("expected the cached sequence header, got %x", data)
+	}
+
+	// Ending the publish ends the stream for every viewer
+	push.Dispose()
+	for {
+		if _, ok := waitFlvData(t, subscription); !ok {
+			break
+		}
+	}
+}
+
+func TestFlvHub_DropsSlowViewer(t *testing.T) {
+	hub := newFlvHub("uuid-1", nopLogger{})
+	slow, err := hub.subscribe()
+	if err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	fast, err := hub.subscribe()
+	if err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+
+	for i := 0; i < flvViewerQueueSize+1; i++ {
+		hub.feed(testFrame(true, uint32(i)))
+		for len(fast.Data()) > 0 {
+			<-fast.Data()
+		}
+	}
+
+	// The slow viewer keeps what was queued, then its stream ends
+	received := 0
+	for range slow.Data() {
+		received++
+	}
+	if received != flvViewerQueueSize {
+		t.Fatalf("expected %d queued tags for the slow viewer, got %d", flvViewerQueueSize, received)
+	}
+	if count := hub.viewerCount(); count != 1 {
+		t.Fatalf("only the slow viewer should be dropped, %d viewers left", count)
+	}
+	slow.Close()
+
+	fast.Close()
+	fast.Close()
+	if count := hub.viewerCount(); count != 0 {
+		t.Fatalf("expected no viewers after closing, got %d", count)
+	}
+	hub.close()
+	if _, err := hub.subscribe(); err != errors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound after the publish ended, got %v", err)
+	}
+}
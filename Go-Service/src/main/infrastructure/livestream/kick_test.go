@@ -0,0 +1,132 @@
+package livestream
+
+import (
+	streamInterface "Go-Service/src/main/application/interface/stream"
+	"Go-Service/src/main/domain/entity/errors"
+	livestreamEntity "Go-Service/src/main/domain/entity/livestream"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/rtmp"
+)
+
+func TestStreamRegistry_KickBlocksPublish(t *testing.T) {
+	registry := newStreamRegistry()
+	registry.add(livestream{name: "stream", uuid: "uuid-1", apiKey: "key-1"})
+
+	conn, peer := net.Pipe()
+	defer peer.Close()
+	if err := registry.beginPublish("uuid-1", conn); err != nil {
+		t.Fatalf("beginPublish failed: %v", err)
+	}
+	kicked, err := registry.kick("uuid-1", time.Now().Add(time.Hour))
+	if err != nil || kicked != conn {
+		t.Fatalf("kick should return the publisher connection, got %v, %v", kicked, err)
+	}
+	registry.endPublish("uuid-1", conn)
+
+	// Re-opening the stream must not lift the block
+	registry.add(livestream{name: "stream", uuid: "uuid-1", apiKey: "key-1"})
+	next, nextPeer := net.Pipe()
+	defer nextPeer.Close()
+	if err := registry.beginPublish("uuid-1", next); err != errors.ErrUnauthorized {
+		t.Fatalf("expected ErrUnauthorized while blocked, got %v", err)
+	}
+
+	// An expired block lets the publisher back in
+	if _, err := registry.kick("uuid-1", time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("kick failed: %v", err)
+	}
+	if err := registry.beginPublish("uuid-1", next); err != nil {
+		t.Fatalf("expected publish after block expired, got %v", err)
+	}
+	if _, err := registry.kick("missing", time.Time{}); err != errors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// startTestServer runs the RTMP ingest, and RTMPS when enabled, on the
+// listeners configured for the service
+func startTestServer(t *testing.T, service *LivestreamService) string {
+	t.Helper()
+	if err := service.StartService(); err != nil {
+		t.Fatalf("StartService failed: %v", err)
+	}
+	service.hlsRoot = t.TempDir()
+	go service.RunLoop()
+	t.Cleanup(func() {
+		service.listener.Close()
+		if service.tlsListener != nil {
+			service.tlsListener.Close()
+		}
+	})
+	return service.listener.Addr().String()
+}
+
+func waitEvent(t *testing.T, events <-chan streamInterface.PublishEvent) streamInterface.PublishEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for publish event")
+		return streamInterface.PublishEvent{}
+	}
+}
+
+func TestKickPublisher_EmitsEventsAndBlocks(t *testing.T) {
+	service := NewLivestreamService(nopLogger{}, "127.0.0.1:0", nil)
+	events := make(chan streamInterface.PublishEvent, 8)
+	service.OnPublishEvent(func(event streamInterface.PublishEvent) {
+		events <- event
+	})
+	addr := startTestServer(t, service)
+	service.OpenStream("stream", "uuid-1", "key-1", streamInterface.StreamOptions{})
+
+	push := rtmp.NewPushSession()
+	if err := push.Start("rtmp://" + addr + "/live/key-1"); err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+	defer push.Dispose()
+
+	started := waitEvent(t, events)
+	if started.Type != streamInterface.PublishStarted || started.UUID != "uuid-1" || started.RemoteAddr == "" {
+		t.Fatalf("unexpected start event: %+v", started)
+	}
+	if status, _ := service.GetLiveStatus("uuid-1"); status.State != livestreamEntity.LiveStatePublishing {
+		t.Fatalf("expected publishing state, got %s", status.State)
+	}
+
+	if err := service.KickPublisher("uuid-1", time.Minute); err != nil {
+		t.Fatalf("KickPublisher failed: %v", err)
+	}
+	stopped := waitEvent(t, events)
+	if stopped.Type != streamInterface.PublishStopped || stopped.UUID != "uuid-1" || stopped.SessionID != started.SessionID {
+		t.Fatalf("unexpected stop event: %+v", stopped)
+	}
+	select {
+	case <-push.WaitChan():
+	case <-time.After(5 * time.Second):
+		t.Fatal("kicked publisher connection was not closed")
+	}
+
+	// While blocked the same key is turned away without a start event
+	retry := rtmp.NewPushSession()
+	_ = retry.Start("rtmp://" + addr + "/live/key-1")
+	defer retry.Dispose()
+	select {
+	case <-retry.WaitChan():
+	case <-time.After(5 * time.Second):
+		t.Fatal("blocked publisher connection was not closed")
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("blocked publish must not emit events, got %+v", event)
+	default:
+	}
+
+	if err := service.KickPublisher("missing", 0); err != errors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
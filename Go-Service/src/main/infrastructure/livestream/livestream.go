@@ -1,51 +1,124 @@
 package livestream
 
 import (
+	streamInterface "Go-Service/src/main/application/interface/stream"
 	"Go-Service/src/main/domain/entity/errors"
+	livestreamEntity "Go-Service/src/main/domain/entity/livestream"
 	"Go-Service/src/main/domain/interface/logger"
 	"Go-Service/src/main/infrastructure/util"
 	"context"
+	"crypto/tls"
 	"net"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
+	"time"
 
 	"github.com/cool9850311/lal-StreamPlatformLite/pkg/base"
 	"github.com/cool9850311/lal-StreamPlatformLite/pkg/hls"
 	"github.com/cool9850311/lal-StreamPlatformLite/pkg/remux"
 	"github.com/cool9850311/lal-StreamPlatformLite/pkg/rtmp"
+	"github.com/google/uuid"
 )
 
 type LivestreamService struct {
-	listener net.Listener
-	logger   logger.Logger
-	streams  map[string]*livestream
+	listener      net.Listener
+	listenAddr    string
+	tlsListener   net.Listener
+	tlsListenAddr string
+	tlsConfig     *tls.Config
+	logger        logger.Logger
+	streams       *streamRegistry
+	publishHook   PublishHook
+	eventLock     sync.RWMutex
+	eventHandlers []streamInterface.PublishEventHandler
+	// restreamers holds the relays of every stream that is being published
+	restreamLock       sync.Mutex
+	restreamers        map[string]*restreamer
+	restreamSource     streamInterface.RestreamTargetSource
+	restreamMinBackoff time.Duration
+	restreamMaxBackoff time.Duration
+	// flvHubs holds the HTTP-FLV viewers of every stream that is being published
+	flvLock sync.Mutex
+	flvHubs map[string]*flvHub
+	// llhlsMuxers holds the LL-HLS muxer of every stream that is being
+	// published in low-latency mode
+	llhlsLock        sync.Mutex
+	llhlsMuxers      map[string]*llhlsMuxer
+	playlistLock     sync.RWMutex
+	playlistHandlers []streamInterface.PlaylistUpdateHandler
+	// hlsRoot overrides the directory HLS output is written to, for tests
+	hlsRoot string
 }
 type livestream struct {
-	name     string
-	uuid     string
-	conn     net.Conn
-	apiKey   string
-	isRecord bool
+	name         string
+	uuid         string
+	conn         net.Conn
+	apiKey       string
+	options      streamInterface.StreamOptions
+	status       livestreamEntity.LiveStatus
+	blockedUntil time.Time
+	// publishes counts the publishes begun, telling them apart once ended
+	publishes uint64
 }
 
-func NewLivestreamService(logger logger.Logger) *LivestreamService {
-	return &LivestreamService{logger: logger, streams: make(map[string]*livestream)}
+// NewLivestreamService creates the RTMP ingest service listening on
+// listenAddr. publishHook is optional; when set it must approve every publish.
+func NewLivestreamService(logger logger.Logger, listenAddr string, publishHook PublishHook) *LivestreamService {
+	return &LivestreamService{
+		logger:             logger,
+		listenAddr:         listenAddr,
+		streams:            newStreamRegistry(),
+		publishHook:        publishHook,
+		restreamers:        make(map[string]*restreamer),
+		flvHubs:            make(map[string]*flvHub),
+		llhlsMuxers:        make(map[string]*llhlsMuxer),
+		restreamMinBackoff: defaultRestreamMinDelay,
+		restreamMaxBackoff: defaultRestreamMaxDelay,
+	}
+}
+
+// EnableTLS adds an RTMPS listener on listenAddr next to the plain RTMP one.
+// It must be called before StartService.
+func (l *LivestreamService) EnableTLS(listenAddr string, tlsConfig *tls.Config) {
+	l.tlsListenAddr = listenAddr
+	l.tlsConfig = tlsConfig
 }
 
 func (l *LivestreamService) StartService() error {
 	var err error
-	l.listener, err = net.Listen("tcp", ":1935")
+	l.listener, err = net.Listen("tcp", l.listenAddr)
 	if err != nil {
 		return errors.ErrConnectionClosed
 	}
-	l.logger.Info(context.TODO(), "start rtmp server listen. addr= "+"1935")
+	l.logger.Info(context.TODO(), "start rtmp server listen. addr= "+l.listener.Addr().String())
+	if l.tlsConfig == nil {
+		return nil
+	}
+	l.tlsListener, err = tls.Listen("tcp", l.tlsListenAddr, l.tlsConfig)
+	if err != nil {
+		l.listener.Close()
+		return errors.ErrConnectionClosed
+	}
+	l.logger.Info(context.TODO(), "start rtmps server listen. addr= "+l.tlsListener.Addr().String())
 	return nil
 }
+
+// RunLoop accepts publishers on every listener and returns when one of them
+// fails
 func (l *LivestreamService) RunLoop() error {
+	if l.tlsListener == nil {
+		return l.acceptLoop(l.listener)
+	}
+	errCh := make(chan error, 2)
+	go func() { errCh <- l.acceptLoop(l.listener) }()
+	go func() { errCh <- l.acceptLoop(l.tlsListener) }()
+	return <-errCh
+}
+
+func (l *LivestreamService) acceptLoop(listener net.Listener) error {
 	for {
-		conn, err := l.listener.Accept()
+		conn, err := listener.Accept()
 		if err != nil {
 			return err
 		}
@@ -58,46 +131,75 @@ func (l *LivestreamService) handleTcpConnect(conn net.Conn) error {
 
 	session := rtmp.NewServerSession(conn)
 	var rtmp2Mpegts *remux.Rtmp2MpegtsRemuxer
-	var once sync.Once
+	var hlsMuxer *hls.Muxer
+	var hlsOutPath string
+	var dvr *dvrPlaylist
+	var recording *recordingSession
+	var llhls *llhlsMuxer
+	var relay *restreamer
+	var flv *flvHub
+	// publishing is the stream this connection has been authorized to publish to
+	var publishing *livestream
+	sessionID := uuid.New().String()
 
 	task := func(stream *rtmp.Stream) error {
 		switch stream.Header.MsgTypeId {
 		case base.RtmpTypeIdCommandMessageAmf0:
 			_ = session.DoCommandMessage(stream)
-			if session.Url() == "" {
+			if session.Url() == "" || publishing != nil {
 				break
 			}
 
-			stream, found := l.getLivestreamByUrl(session.Url())
+			ls, found := l.getLivestreamByUrl(session.Url())
 			if !found {
 				session.Dispose()
-				l.logger.Warn(context.TODO(), "Unauthorized livestream attempt: "+session.Url())
+				l.logger.Warn(context.TODO(), "Unauthorized livestream attempt from "+remoteAddr)
+				return nil
+			}
+			if err := l.authorizePublish(session, ls, remoteAddr); err != nil {
+				session.Dispose()
+				l.logger.Warn(context.TODO(), "Publish to livestream "+ls.uuid+" denied by on_publish hook: "+err.Error())
+				return nil
+			}
+			if err := l.streams.beginPublish(ls.uuid, conn); err != nil {
+				session.Dispose()
+				l.logger.Warn(context.TODO(), "Rejected publish to livestream "+ls.uuid+": "+err.Error())
 				return nil
 			}
+			publishing = &ls
+			// Relays are up before the start event so handlers can subscribe
+			relay = l.startRestream(ls.uuid)
+			flv = l.startFlv(ls.uuid)
+			l.emitPublishEvent(streamInterface.PublishEvent{Type: streamInterface.PublishStarted, SessionID: sessionID, UUID: ls.uuid, RemoteAddr: remoteAddr, At: time.Now(), Recorded: ls.options.IsRecord})
 
-			once.Do(func() {
-				rootPath, err := util.GetProjectRootPath()
-				if err != nil {
-					l.logger.Error(context.TODO(), "Failed to get project root path: "+err.Error())
-					return
-				}
-				outputPath := rootPath + "/hls/" + stream.uuid
-				cleanupMode := 2
-				if stream.isRecord {
-					cleanupMode = 0
+			outputPath, err := l.hlsDir(ls.uuid)
+			if err != nil {
+				l.logger.Error(context.TODO(), "Failed to get project root path: "+err.Error())
+				break
+			}
+			hlsOutPath = outputPath
+			if ls.options.DVRWindowSeconds > 0 {
+				dvr = l.newDVR(ls, outputPath)
+			}
+			if ls.options.IsRecord {
+				if recording, err = newRecordingSession(outputPath, sessionID); err != nil {
+					l.logger.Error(context.TODO(), "Failed to start recording of livestream "+ls.uuid+": "+err.Error())
 				}
-				hlsMuxerConfig := hls.MuxerConfig{
-					OutPath:            outputPath,
-					FragmentDurationMs: 500,
-					FragmentNum:        5,
-					CleanupMode:        cleanupMode,
+			}
+			if ls.options.LowLatency {
+				llhls, err = l.startLLHLS(ls, outputPath, dvr, recording)
+				if err != nil {
+					l.logger.Error(context.TODO(), "Failed to start LL-HLS muxer: "+err.Error())
+					break
 				}
-				hlsMuxer := hls.NewMuxer(stream.name, &hlsMuxerConfig, nil)
+				rtmp2Mpegts = remux.NewRtmp2MpegtsRemuxer(llhls)
+			} else {
+				hlsMuxerConfig := muxerConfig(outputPath, ls.options)
+				hlsMuxer = hls.NewMuxer(ls.name, &hlsMuxerConfig, &playlistObserver{service: l, uuid: ls.uuid, dvr: dvr, recording: recording})
 				hlsMuxer.Start()
 				rtmp2Mpegts = remux.NewRtmp2MpegtsRemuxer(hlsMuxer)
-				stream.conn = conn
-				l.logger.Info(context.TODO(), "Started livestream: %s"+stream.name)
-			})
+			}
+			l.logger.Info(context.TODO(), "Started livestream: "+ls.name)
 		case base.RtmpTypeIdWinAckSize:
 			_ = session.DoWinAckSize(stream)
 		case base.RtmpTypeIdSetChunkSize:
@@ -115,51 +217,471 @@ func (l *LivestreamService) handleTcpConnect(conn net.Conn) error {
 		if rtmp2Mpegts != nil {
 			rtmp2Mpegts.FeedRtmpMessage(stream.ToAvMsg())
 		}
+		if relay != nil {
+			relay.feed(stream.ToAvMsg())
+		}
+		if flv != nil {
+			flv.feed(stream.ToAvMsg())
+		}
 		return nil
 	}
 	_ = session.RunLoop(task)
 	session.Dispose()
+	if relay != nil {
+		l.stopRestream(publishing.uuid, relay)
+	}
+	if flv != nil {
+		l.stopFlv(publishing.uuid, flv)
+	}
+	if hlsMuxer != nil {
+		hlsMuxer.Dispose()
+	}
+	if llhls != nil {
+		l.stopLLHLS(publishing.uuid, llhls)
+	}
+	if dvr != nil {
+		if err := dvr.close(); err != nil {
+			l.logger.Error(context.TODO(), "Failed to write DVR playlist: "+err.Error())
+		}
+	}
+	// The muxers flushed their last fragment, the recording is complete
+	// before the stop event queues its conversion
+	if recording != nil {
+		if err := recording.close(); err != nil {
+			l.logger.Error(context.TODO(), "Failed to end record playlist: "+err.Error())
+		}
+	}
+	if hlsMuxer != nil || llhls != nil {
+		if delay, cleanup := endOfPublishCleanup(publishing.options); cleanup {
+			l.cleanupHLSLater(publishing.uuid, hlsOutPath, delay)
+		}
+	}
+	if publishing != nil {
+		if l.streams.endPublish(publishing.uuid, conn) {
+			l.logger.Info(context.TODO(), "Publisher disconnected from livestream: "+publishing.name)
+		}
+		l.emitPublishEvent(streamInterface.PublishEvent{Type: streamInterface.PublishStopped, SessionID: sessionID, UUID: publishing.uuid, RemoteAddr: remoteAddr, At: time.Now(), Recorded: publishing.options.IsRecord})
+	}
 	return nil
 }
-func (l *LivestreamService) getLivestreamByUrl(url string) (*livestream, bool) {
-	for _, stream := range l.streams {
-		if stream.apiKey != "" && strings.Contains(url, stream.apiKey) {
-			return stream, true
+
+// hlsDir returns the HLS output directory of a livestream
+func (l *LivestreamService) hlsDir(uuid string) (string, error) {
+	if l.hlsRoot != "" {
+		return filepath.Join(l.hlsRoot, uuid), nil
+	}
+	rootPath, err := util.GetProjectRootPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(rootPath, "hls", uuid), nil
+}
+
+// muxerConfig builds the lal muxer settings of a publish from the stream
+// options, falling back to the defaults for unset values
+func muxerConfig(outputPath string, options streamInterface.StreamOptions) hls.MuxerConfig {
+	config := hls.MuxerConfig{
+		OutPath:            outputPath,
+		FragmentDurationMs: options.FragmentDurationMs,
+		FragmentNum:        options.FragmentNum,
+	}
+	if config.FragmentDurationMs == 0 {
+		config.FragmentDurationMs = livestreamEntity.DefaultFragmentDurationMs
+	}
+	if config.FragmentNum == 0 {
+		config.FragmentNum = livestreamEntity.DefaultFragmentNum
+	}
+	cleanupMode := options.CleanupMode
+	if cleanupMode == "" {
+		cleanupMode = livestreamEntity.DefaultCleanupMode
+	}
+	// The DVR playlist deletes fragments itself once they leave its window
+	if options.DVRWindowSeconds > 0 {
+		cleanupMode = livestreamEntity.CleanupModeNever
+	}
+	switch cleanupMode {
+	case livestreamEntity.CleanupModeNever:
+		config.CleanupMode = hls.CleanupModeNever
+	case livestreamEntity.CleanupModeInTheEnd:
+		config.CleanupMode = hls.CleanupModeInTheEnd
+	default:
+		config.CleanupMode = hls.CleanupModeAsap
+	}
+	return config
+}
+
+// endOfPublishCleanup returns how long after a publish ends its HLS files are
+// deleted, or false when they are kept. lal leaves the in_the_end cleanup mode
+// to its caller. With DVR the files stay rewindable for the whole window.
+// Recordings keep their own links to the fragments.
+func endOfPublishCleanup(options streamInterface.StreamOptions) (time.Duration, bool) {
+	cleanupMode := options.CleanupMode
+	if cleanupMode == "" {
+		cleanupMode = livestreamEntity.DefaultCleanupMode
+	}
+	if cleanupMode == livestreamEntity.CleanupModeNever {
+		return 0, false
+	}
+	if options.DVRWindowSeconds > 0 {
+		return time.Duration(options.DVRWindowSeconds) * time.Second, true
+	}
+	if cleanupMode == livestreamEntity.CleanupModeInTheEnd {
+		config := muxerConfig("", options)
+		return time.Duration(config.FragmentDurationMs*(config.FragmentNum+config.DeleteThreshold+1)) * time.Millisecond, true
+	}
+	return 0, false
+}
+
+// newDVR starts the DVR playlist of a publish. Segments sliding out of the
+// window are deleted unless the cleanup mode keeps them.
+func (l *LivestreamService) newDVR(ls livestream, outputPath string) *dvrPlaylist {
+	deleteExpired := ls.options.CleanupMode != livestreamEntity.CleanupModeNever
+	window := time.Duration(ls.options.DVRWindowSeconds) * time.Second
+	return newDVRPlaylist(outputPath, window, deleteExpired, func(playlistPath string) {
+		l.emitPlaylistUpdate(ls.uuid, playlistPath)
+	})
+}
+
+// cleanupHLSLater deletes the HLS files of a publish that ended once players
+// have had time to fetch the last fragments
+func (l *LivestreamService) cleanupHLSLater(uuid, dir string, delay time.Duration) {
+	publishes, _ := l.streams.publishCount(uuid)
+	time.AfterFunc(delay, func() {
+		// A new publish owns the directory now, even once it ended itself
+		status, exists := l.streams.status(uuid)
+		if !exists || status.State == livestreamEntity.LiveStatePublishing {
+			return
+		}
+		if count, _ := l.streams.publishCount(uuid); count != publishes {
+			return
+		}
+		if err := removeHLSFiles(dir); err != nil {
+			l.logger.Warn(context.TODO(), "Failed to clean up HLS files of livestream "+uuid+": "+err.Error())
+		}
+	})
+}
+
+// removeHLSFiles deletes the playlists and fragments directly inside dir
+func removeHLSFiles(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".ts" && ext != ".m3u8") {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// playlistObserver reports every playlist the lal muxer writes
+type playlistObserver struct {
+	service *LivestreamService
+	uuid    string
+	// dvr and recording, if set, get every closed fragment
+	dvr       *dvrPlaylist
+	recording *recordingSession
+}
+
+func (o *playlistObserver) OnHlsMakeTs(info base.HlsMakeTsInfo) {
+	// The live playlist is rewritten when a fragment is closed
+	if info.Event == "close" {
+		o.service.emitPlaylistUpdate(o.uuid, info.LiveM3u8File)
+		if o.dvr != nil {
+			if err := o.dvr.add(filepath.Base(info.TsFile), info.Duration, false); err != nil {
+				o.service.logger.Error(context.TODO(), "Failed to write DVR playlist: "+err.Error())
+			}
+		}
+		if o.recording != nil {
+			if err := o.recording.add(info.TsFile, info.Duration, false); err != nil {
+				o.service.logger.Error(context.TODO(), "Failed to record fragment: "+err.Error())
+			}
 		}
 	}
-	return nil, false
+}
+
+func (o *playlistObserver) OnFragmentOpen() {}
+
+// OnPlaylistUpdate registers a handler that is called whenever the live
+// playlist of a stream is rewritten. Handlers run on the RTMP connection
+// goroutine and should return quickly.
+func (l *LivestreamService) OnPlaylistUpdate(handler streamInterface.PlaylistUpdateHandler) {
+	l.playlistLock.Lock()
+	defer l.playlistLock.Unlock()
+	l.playlistHandlers = append(l.playlistHandlers, handler)
+}
+
+func (l *LivestreamService) emitPlaylistUpdate(uuid, playlistPath string) {
+	l.playlistLock.RLock()
+	handlers := l.playlistHandlers
+	l.playlistLock.RUnlock()
+	for _, handler := range handlers {
+		handler(uuid, playlistPath)
+	}
+}
+
+func (l *LivestreamService) startLLHLS(ls livestream, outputPath string, dvr *dvrPlaylist, recording *recordingSession) (*llhlsMuxer, error) {
+	muxer := newLLHLSMuxer(llhlsConfig{
+		outPath:        outputPath,
+		streamName:     ls.name,
+		partTarget:     defaultLLPartTarget,
+		segmentTarget:  defaultLLSegmentTarget,
+		windowSegments: defaultLLWindowSegments,
+	}, l.logger, func(playlistPath string) {
+		l.emitPlaylistUpdate(ls.uuid, playlistPath)
+	})
+	muxer.dvr = dvr
+	muxer.recording = recording
+	if err := muxer.start(); err != nil {
+		return nil, err
+	}
+	l.llhlsLock.Lock()
+	defer l.llhlsLock.Unlock()
+	l.llhlsMuxers[ls.uuid] = muxer
+	return muxer, nil
+}
+
+func (l *LivestreamService) stopLLHLS(uuid string, muxer *llhlsMuxer) {
+	l.llhlsLock.Lock()
+	if l.llhlsMuxers[uuid] == muxer {
+		delete(l.llhlsMuxers, uuid)
+	}
+	l.llhlsLock.Unlock()
+	muxer.close()
+}
+
+func (l *LivestreamService) getLLHLS(uuid string) *llhlsMuxer {
+	l.llhlsLock.Lock()
+	defer l.llhlsLock.Unlock()
+	return l.llhlsMuxers[uuid]
+}
+
+func (l *LivestreamService) WaitLowLatencyPart(ctx context.Context, uuid string, msn, part int) error {
+	muxer := l.getLLHLS(uuid)
+	if muxer == nil {
+		return errors.ErrNotFound
+	}
+	return muxer.waitPart(ctx, msn, part)
+}
+
+func (l *LivestreamService) WaitPreloadHint(ctx context.Context, uuid, filename string) error {
+	muxer := l.getLLHLS(uuid)
+	if muxer == nil {
+		return nil
+	}
+	return muxer.waitHint(ctx, filename)
+}
+
+// OnPublishEvent registers a handler for publish start/stop events. Handlers
+// run on the RTMP connection goroutine and should return quickly.
+func (l *LivestreamService) OnPublishEvent(handler streamInterface.PublishEventHandler) {
+	l.eventLock.Lock()
+	defer l.eventLock.Unlock()
+	l.eventHandlers = append(l.eventHandlers, handler)
+}
+
+func (l *LivestreamService) emitPublishEvent(event streamInterface.PublishEvent) {
+	l.eventLock.RLock()
+	handlers := l.eventHandlers
+	l.eventLock.RUnlock()
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// SetRestreamTargetSource sets where the restream targets of a stream are
+// loaded from when its publish starts
+func (l *LivestreamService) SetRestreamTargetSource(source streamInterface.RestreamTargetSource) {
+	l.restreamLock.Lock()
+	defer l.restreamLock.Unlock()
+	l.restreamSource = source
+}
+
+// startRestream starts relaying a new publish to the targets of the stream
+func (l *LivestreamService) startRestream(uuid string) *restreamer {
+	relay := newRestreamer(uuid, l.logger, l.restreamMinBackoff, l.restreamMaxBackoff)
+	l.restreamLock.Lock()
+	l.restreamers[uuid] = relay
+	l.restreamLock.Unlock()
+	if err := l.RefreshRestreamTargets(uuid); err != nil {
+		l.logger.Error(context.TODO(), "Failed to load restream targets of livestream "+uuid+": "+err.Error())
+	}
+	return relay
+}
+
+func (l *LivestreamService) stopRestream(uuid string, relay *restreamer) {
+	l.restreamLock.Lock()
+	if l.restreamers[uuid] == relay {
+		delete(l.restreamers, uuid)
+	}
+	l.restreamLock.Unlock()
+	relay.close()
+}
+
+// RefreshRestreamTargets reloads the targets of a stream that is being
+// published. Streams that are not live pick up their targets on the next
+// publish.
+func (l *LivestreamService) RefreshRestreamTargets(uuid string) error {
+	l.restreamLock.Lock()
+	relay := l.restreamers[uuid]
+	source := l.restreamSource
+	l.restreamLock.Unlock()
+	if relay == nil || source == nil {
+		return nil
+	}
+	targets, err := source(uuid)
+	if err != nil {
+		return err
+	}
+	relay.setTargets(targets)
+	return nil
+}
+
+func (l *LivestreamService) GetRestreamStatus(uuid string) map[string]livestreamEntity.RestreamStatus {
+	l.restreamLock.Lock()
+	relay := l.restreamers[uuid]
+	l.restreamLock.Unlock()
+	if relay == nil {
+		return map[string]livestreamEntity.RestreamStatus{}
+	}
+	return relay.statuses()
+}
+
+func (l *LivestreamService) startFlv(uuid string) *flvHub {
+	hub := newFlvHub(uuid, l.logger)
+	l.flvLock.Lock()
+	defer l.flvLock.Unlock()
+	l.flvHubs[uuid] = hub
+	return hub
+}
+
+func (l *LivestreamService) stopFlv(uuid string, hub *flvHub) {
+	l.flvLock.Lock()
+	if l.flvHubs[uuid] == hub {
+		delete(l.flvHubs, uuid)
+	}
+	l.flvLock.Unlock()
+	hub.close()
+}
+
+// SubscribeFLV adds an HTTP-FLV viewer to a stream that is being published
+func (l *LivestreamService) SubscribeFLV(uuid string) (streamInterface.FlvSubscription, error) {
+	l.flvLock.Lock()
+	hub := l.flvHubs[uuid]
+	l.flvLock.Unlock()
+	if hub == nil {
+		return nil, errors.ErrNotFound
+	}
+	viewer, err := hub.subscribe()
+	if err != nil {
+		return nil, err
+	}
+	return viewer, nil
+}
+
+// getLivestreamByUrl finds the stream whose key exactly equals the stream
+// name of the publish URL
+func (l *LivestreamService) getLivestreamByUrl(url string) (livestream, bool) {
+	_, streamKey, err := parsePublishURL(url)
+	if err != nil {
+		return livestream{}, false
+	}
+	return l.streams.find(func(stream *livestream) bool {
+		return stream.apiKey != "" && stream.apiKey == streamKey
+	})
+}
+
+func (l *LivestreamService) authorizePublish(session *rtmp.ServerSession, ls livestream, remoteAddr string) error {
+	if l.publishHook == nil {
+		return nil
+	}
+	app, streamName, err := parsePublishURL(session.Url())
+	if err != nil {
+		return err
+	}
+	return l.publishHook.OnPublish(context.TODO(), PublishRequest{
+		UUID:       ls.uuid,
+		Name:       ls.name,
+		App:        app,
+		Stream:     streamName,
+		Query:      session.RawQuery(),
+		RemoteAddr: remoteAddr,
+	})
 }
 func (l *LivestreamService) IsLiveStreamExist(uuid string) bool {
-	_, exists := l.streams[uuid]
+	_, exists := l.streams.get(uuid)
 	return exists
 }
 
-func (l *LivestreamService) OpenStream(name, uuid, apiKey string, isRecord bool) error {
+// GetLiveStatus returns the current ingest state of the livestream
+func (l *LivestreamService) GetLiveStatus(uuid string) (livestreamEntity.LiveStatus, bool) {
+	return l.streams.status(uuid)
+}
+
+func (l *LivestreamService) OpenStream(name, uuid, apiKey string, options streamInterface.StreamOptions) error {
 	// Create a new livestream instance
-	newStream := &livestream{
-		name:     name,
-		uuid:     uuid,
-		apiKey:   apiKey,
-		isRecord: isRecord,
-	}
+	l.streams.add(livestream{
+		name:    name,
+		uuid:    uuid,
+		apiKey:  apiKey,
+		options: options,
+	})
+
+	return nil
+}
+
+// UpdateStreamOptions changes the ingest options of an opened stream. A
+// publish in progress keeps the options it started with.
+func (l *LivestreamService) UpdateStreamOptions(uuid string, options streamInterface.StreamOptions) error {
+	return l.streams.setOptions(uuid, options)
+}
 
-	l.streams[uuid] = newStream
+// UpdateStreamKey swaps the key publishers must use. The current publisher
+// stays connected unless dropPublisher is set.
+func (l *LivestreamService) UpdateStreamKey(uuid, apiKey string, dropPublisher bool) error {
+	conn, err := l.streams.setAPIKey(uuid, apiKey)
+	if err != nil {
+		return err
+	}
+	if dropPublisher && conn != nil {
+		conn.Close()
+		l.logger.Info(context.TODO(), "Dropped publisher after stream key change: "+uuid)
+	}
+	return nil
+}
 
+// KickPublisher disconnects the current publisher and refuses new publishes
+// to the stream for blockFor
+func (l *LivestreamService) KickPublisher(uuid string, blockFor time.Duration) error {
+	conn, err := l.streams.kick(uuid, time.Now().Add(blockFor))
+	if err != nil {
+		return err
+	}
+	if conn != nil {
+		conn.Close()
+		l.logger.Info(context.TODO(), "Kicked publisher from livestream: "+uuid)
+	}
 	return nil
 }
 
 func (l *LivestreamService) CloseStream(uuid string) error {
-	if stream, exists := l.streams[uuid]; exists {
-		delete(l.streams, uuid)
+	if stream, exists := l.streams.remove(uuid); exists {
+		if stream.conn != nil {
+			stream.conn.Close()
+		}
 		// Delete the HLS directory for the closed stream
-		rootPath, err := util.GetProjectRootPath()
+		hlsDir, err := l.hlsDir(stream.uuid)
 		if err != nil {
 			l.logger.Error(context.TODO(), "Failed to get project root path: "+err.Error())
 		} else {
-			if stream.conn != nil {
-				stream.conn.Close()
-			}
-			hlsDir := filepath.Join(rootPath, "hls", stream.uuid)
 			err = os.RemoveAll(hlsDir)
 
 			if err != nil {
@@ -0,0 +1,444 @@
+package livestream
+
+import (
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/interface/logger"
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/mpegts"
+)
+
+const (
+	llhlsPlaylistName       = "playlist.m3u8"
+	defaultLLPartTarget     = 500 * time.Millisecond
+	defaultLLSegmentTarget  = 2 * time.Second
+	defaultLLWindowSegments = 6
+	// llhlsPartSegments is how many recent segments keep their parts listed
+	// in the playlist, next to the segment being written
+	llhlsPartSegments = 2
+	// llhlsMaxAhead is how far past the current segment a blocking reload may
+	// ask for before it is rejected
+	llhlsMaxAhead = 2
+)
+
+type llhlsConfig struct {
+	outPath        string
+	streamName     string
+	partTarget     time.Duration
+	segmentTarget  time.Duration
+	windowSegments int
+}
+
+type llhlsPart struct {
+	uri         string
+	duration    float64
+	independent bool
+}
+
+type llhlsSegment struct {
+	msn      int
+	uri      string
+	duration float64
+	parts    []llhlsPart
+	discont  bool
+}
+
+// llhlsMuxer writes an LL-HLS playlist with MPEG-TS segments and partial
+// segments. Every part is a standalone file that starts with PAT/PMT, and a
+// segment is the concatenation of its parts. Files are written to a temp
+// name and renamed, so readers never see them half written.
+//
+// The OnPatPmt/OnTsPackets side runs on the RTMP connection goroutine, while
+// waitPart/waitHint are called from HTTP handlers.
+type llhlsMuxer struct {
+	config   llhlsConfig
+	logger   logger.Logger
+	onUpdate func(playlistPath string)
+
+	// Muxing state, only used by the RTMP goroutine
+	patpmt      []byte
+	opened      bool
+	segStartTs  uint64
+	segStartMs  int64
+	segBuf      bytes.Buffer
+	partStartTs uint64
+	partBuf     bytes.Buffer
+	lastTs      uint64
+	// recording, if set, gets every closed segment
+	recording *recordingSession
+	// dvr, if set, gets every closed segment and deletes them itself
+	dvr *dvrPlaylist
+
+	mu sync.Mutex
+	// notify is closed and replaced every time the playlist changes
+	notify   chan struct{}
+	closed   bool
+	segments []*llhlsSegment
+	current  *llhlsSegment
+	// partURI is the part being written, advertised as the preload hint
+	partURI         string
+	partIndependent bool
+	partSeq         int
+	maxDuration     float64
+	// discontSeq counts the discontinuities that left the window
+	discontSeq int
+}
+
+func newLLHLSMuxer(config llhlsConfig, logger logger.Logger, onUpdate func(playlistPath string)) *llhlsMuxer {
+	return &llhlsMuxer{
+		config:   config,
+		logger:   logger,
+		onUpdate: onUpdate,
+		notify:   make(chan struct{}),
+	}
+}
+
+func (m *llhlsMuxer) start() error {
+	return os.MkdirAll(m.config.outPath, 0777)
+}
+
+// OnPatPmt implements remux.IRtmp2MpegtsRemuxerObserver
+func (m *llhlsMuxer) OnPatPmt(b []byte) {
+	m.patpmt = b
+}
+
+// OnTsPackets implements remux.IRtmp2MpegtsRemuxerObserver. A new segment
+// starts at the first boundary (keyframe) after the segment target; parts
+// are cut at any frame once the part target is reached.
+func (m *llhlsMuxer) OnTsPackets(tsPackets []byte, frame *mpegts.Frame, boundary bool) {
+	ts := frame.Dts
+	if frame.Sid == mpegts.StreamIdAudio {
+		ts = frame.Pts
+	}
+
+	if !m.opened {
+		if !boundary {
+			return
+		}
+		m.openSegment(ts, false)
+		m.openPart(ts, true)
+	} else {
+		segElapsed := elapsed90k(m.segStartTs, ts)
+		switch {
+		case ts+90000 < m.lastTs || elapsed90k(m.lastTs, ts) > 10*m.config.segmentTarget:
+			// Timestamps jumped, usually because the encoder restarted
+			m.closePart(m.lastTs)
+			m.closeSegment()
+			m.openSegment(ts, true)
+			m.openPart(ts, boundary)
+			m.publish()
+		case boundary && segElapsed >= m.config.segmentTarget:
+			m.closePart(ts)
+			m.closeSegment()
+			m.openSegment(ts, false)
+			m.openPart(ts, true)
+			m.publish()
+		case segElapsed > 10*m.config.segmentTarget:
+			// No keyframe for far too long; keep segments bounded anyway
+			m.logger.Warn(context.TODO(), "LL-HLS forced segment split for "+m.config.streamName)
+			m.closePart(ts)
+			m.closeSegment()
+			m.openSegment(ts, false)
+			m.openPart(ts, boundary)
+			m.publish()
+		case elapsed90k(m.partStartTs, ts) >= m.partCutAfter():
+			m.closePart(ts)
+			m.openPart(ts, boundary)
+			m.publish()
+		}
+	}
+	m.partBuf.Write(tsPackets)
+	m.lastTs = ts
+}
+
+// partCutAfter leaves room for one frame interval, so that no part is longer
+// than the advertised PART-TARGET
+func (m *llhlsMuxer) partCutAfter() time.Duration {
+	return m.config.partTarget * 9 / 10
+}
+
+func elapsed90k(from, to uint64) time.Duration {
+	if to <= from {
+		return 0
+	}
+	return time.Duration(to-from) * time.Second / 90000
+}
+
+func (m *llhlsMuxer) openSegment(ts uint64, discont bool) {
+	m.opened = true
+	m.segStartTs = ts
+	m.segStartMs = time.Now().UnixMilli()
+	m.segBuf.Reset()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	msn := 0
+	if m.current != nil {
+		msn = m.current.msn + 1
+	} else if n := len(m.segments); n > 0 {
+		msn = m.segments[n-1].msn + 1
+	}
+	m.current = &llhlsSegment{
+		msn:     msn,
+		uri:     fmt.Sprintf("%s-%d-%d.ts", m.config.streamName, m.segStartMs, msn),
+		discont: discont,
+	}
+}
+
+func (m *llhlsMuxer) openPart(ts uint64, independent bool) {
+	m.partStartTs = ts
+	m.partBuf.Reset()
+	m.partBuf.Write(m.patpmt)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.partURI = fmt.Sprintf("%s-%d-p%d.ts", m.config.streamName, time.Now().UnixMilli(), m.partSeq)
+	m.partIndependent = independent
+	m.partSeq++
+}
+
+// closePart writes the part being filled, which ends where ts starts
+func (m *llhlsMuxer) closePart(ts uint64) {
+	duration := elapsed90k(m.partStartTs, ts).Seconds()
+	m.mu.Lock()
+	uri := m.partURI
+	independent := m.partIndependent
+	m.mu.Unlock()
+
+	if err := m.writeFile(uri, m.partBuf.Bytes()); err != nil {
+		m.logger.Error(context.TODO(), "Failed to write LL-HLS part: "+err.Error())
+	}
+	m.segBuf.Write(m.partBuf.Bytes())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.current.parts = append(m.current.parts, llhlsPart{uri: uri, duration: duration, independent: independent})
+	m.current.duration += duration
+}
+
+// closeSegment writes the segment made of the closed parts and slides the
+// playlist window
+func (m *llhlsMuxer) closeSegment() {
+	if err := m.writeFile(m.current.uri, m.segBuf.Bytes()); err != nil {
+		m.logger.Error(context.TODO(), "Failed to write LL-HLS segment: "+err.Error())
+	}
+
+	m.mu.Lock()
+	segment := m.current
+	m.segments = append(m.segments, segment)
+	m.maxDuration = math.Max(m.maxDuration, segment.duration)
+	var expired []*llhlsSegment
+	if n := len(m.segments) - m.config.windowSegments; n > 0 {
+		expired = append(expired, m.segments[:n]...)
+		m.segments = m.segments[n:]
+		for _, old := range expired {
+			if old.discont {
+				m.discontSeq++
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	if m.recording != nil {
+		if err := m.recording.add(filepath.Join(m.config.outPath, segment.uri), segment.duration, segment.discont); err != nil {
+			m.logger.Error(context.TODO(), "Failed to record segment: "+err.Error())
+		}
+	}
+	if m.dvr != nil {
+		if err := m.dvr.add(segment.uri, segment.duration, segment.discont); err != nil {
+			m.logger.Error(context.TODO(), "Failed to write DVR playlist: "+err.Error())
+		}
+	}
+	for _, old := range expired {
+		m.removeFiles(old)
+	}
+}
+
+// removeFiles deletes the files of a segment that left the window. DVR
+// segments stay on disk until they leave the DVR window; recorded ones live
+// on in the recording's own directory.
+func (m *llhlsMuxer) removeFiles(segment *llhlsSegment) {
+	for _, part := range segment.parts {
+		_ = os.Remove(filepath.Join(m.config.outPath, part.uri))
+	}
+	if m.dvr == nil {
+		_ = os.Remove(filepath.Join(m.config.outPath, segment.uri))
+	}
+}
+
+// close finishes the playlist with EXT-X-ENDLIST and releases every waiting
+// request. It must be called once, after the last OnTsPackets.
+func (m *llhlsMuxer) close() {
+	if m.opened {
+		m.closePart(m.lastTs)
+		m.closeSegment()
+	}
+	m.mu.Lock()
+	m.closed = true
+	m.current = nil
+	m.partURI = ""
+	m.mu.Unlock()
+	m.publish()
+}
+
+// publish writes the playlist, tells the cache about it and then wakes every
+// blocked request
+func (m *llhlsMuxer) publish() {
+	m.mu.Lock()
+	content := m.renderLocked()
+	m.mu.Unlock()
+	if content == nil {
+		return
+	}
+	playlistPath := filepath.Join(m.config.outPath, llhlsPlaylistName)
+	if err := m.writeFile(llhlsPlaylistName, content); err != nil {
+		m.logger.Error(context.TODO(), "Failed to write LL-HLS playlist: "+err.Error())
+		return
+	}
+	if m.onUpdate != nil {
+		m.onUpdate(playlistPath)
+	}
+	m.mu.Lock()
+	close(m.notify)
+	m.notify = make(chan struct{})
+	m.mu.Unlock()
+}
+
+func (m *llhlsMuxer) renderLocked() []byte {
+	segments := m.segments
+	if m.current != nil && len(m.current.parts) > 0 {
+		segments = append(segments[:len(segments):len(segments)], m.current)
+	}
+	if len(segments) == 0 {
+		return nil
+	}
+	targetDuration := int(math.Ceil(math.Max(m.maxDuration, m.config.segmentTarget.Seconds())))
+	partTarget := m.config.partTarget.Seconds()
+
+	var buf bytes.Buffer
+	buf.WriteString("#EXTM3U\n")
+	buf.WriteString("#EXT-X-VERSION:9\n")
+	buf.WriteString(fmt.Sprintf("#EXT-X-TARGETDURATION:%d\n", targetDuration))
+	buf.WriteString(fmt.Sprintf("#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=%.3f\n", 3*partTarget))
+	buf.WriteString(fmt.Sprintf("#EXT-X-PART-INF:PART-TARGET=%.3f\n", partTarget))
+	buf.WriteString(fmt.Sprintf("#EXT-X-MEDIA-SEQUENCE:%d\n", segments[0].msn))
+	if m.discontSeq > 0 {
+		buf.WriteString(fmt.Sprintf("#EXT-X-DISCONTINUITY-SEQUENCE:%d\n", m.discontSeq))
+	}
+	buf.WriteString("\n")
+	for i, segment := range segments {
+		if segment.discont {
+			buf.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		if len(segments)-i <= llhlsPartSegments+1 {
+			for _, part := range segment.parts {
+				buf.WriteString(fmt.Sprintf("#EXT-X-PART:DURATION=%.3f,URI=\"%s\"", part.duration, part.uri))
+				if part.independent {
+					buf.WriteString(",INDEPENDENT=YES")
+				}
+				buf.WriteString("\n")
+			}
+		}
+		if segment != m.current {
+			buf.WriteString(fmt.Sprintf("#EXTINF:%.3f,\n%s\n", segment.duration, segment.uri))
+		}
+	}
+	if m.closed {
+		buf.WriteString("#EXT-X-ENDLIST\n")
+	} else if m.partURI != "" {
+		buf.WriteString(fmt.Sprintf("#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"%s\"\n", m.partURI))
+	}
+	return buf.Bytes()
+}
+
+func (m *llhlsMuxer) writeFile(name string, data []byte) error {
+	path := filepath.Join(m.config.outPath, name)
+	if err := os.WriteFile(path+".tmp", data, 0666); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// hasPartLocked reports whether the playlist has part `part` of segment msn,
+// or anything after it. A negative part asks for the whole segment.
+func (m *llhlsMuxer) hasPartLocked(msn, part int) bool {
+	if m.current == nil {
+		n := len(m.segments)
+		return n > 0 && msn <= m.segments[n-1].msn
+	}
+	if msn < m.current.msn {
+		return true
+	}
+	return msn == m.current.msn && part >= 0 && part < len(m.current.parts)
+}
+
+func (m *llhlsMuxer) currentMSNLocked() int {
+	if m.current != nil {
+		return m.current.msn
+	}
+	if n := len(m.segments); n > 0 {
+		return m.segments[n-1].msn
+	}
+	return 0
+}
+
+// waitPart implements the blocking playlist reload of LL-HLS
+func (m *llhlsMuxer) waitPart(ctx context.Context, msn, part int) error {
+	timeout := time.NewTimer(3 * m.config.segmentTarget)
+	defer timeout.Stop()
+	for {
+		m.mu.Lock()
+		if m.hasPartLocked(msn, part) {
+			m.mu.Unlock()
+			return nil
+		}
+		if m.closed {
+			m.mu.Unlock()
+			return errors.ErrNotFound
+		}
+		if msn > m.currentMSNLocked()+llhlsMaxAhead {
+			m.mu.Unlock()
+			return errors.ErrInvalidInput
+		}
+		notify := m.notify
+		m.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-timeout.C:
+			return errors.ErrTimeout
+		case <-ctx.Done():
+			return errors.ErrTimeout
+		}
+	}
+}
+
+// waitHint holds a request for the part being written until it is complete
+func (m *llhlsMuxer) waitHint(ctx context.Context, filename string) error {
+	timeout := time.NewTimer(3 * m.config.partTarget)
+	defer timeout.Stop()
+	for {
+		m.mu.Lock()
+		if filename != m.partURI {
+			m.mu.Unlock()
+			return nil
+		}
+		notify := m.notify
+		m.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-timeout.C:
+			return errors.ErrTimeout
+		case <-ctx.Done():
+			return errors.ErrTimeout
+		}
+	}
+}
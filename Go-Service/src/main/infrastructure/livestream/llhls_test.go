@@ -0,0 +1,343 @@
+package livestream
+
+import (
+	streamInterface "Go-Service/src/main/application/interface/stream"
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/infrastructure/util"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/base"
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/mpegts"
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/rtmp"
+)
+
+var testPatPmt = []byte("PATPMT")
+
+func newTestLLHLS(t *testing.T, record bool) (*llhlsMuxer, string) {
+	t.Helper()
+	dir := t.TempDir()
+	muxer := newLLHLSMuxer(llhlsConfig{
+		outPath:        dir,
+		streamName:     "stream",
+		partTarget:     500 * time.Millisecond,
+		segmentTarget:  2 * time.Second,
+		windowSegments: 3,
+	}, nopLogger{}, nil)
+	if err := muxer.start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if record {
+		recording, err := newRecordingSession(dir, "session-1")
+		if err != nil {
+			t.Fatalf("recording failed: %v", err)
+		}
+		muxer.recording = recording
+	}
+	muxer.OnPatPmt(testPatPmt)
+	return muxer, dir
+}
+
+// feedFrames feeds 10fps video with a keyframe every 2 seconds, starting at
+// frame index from
+func feedFrames(muxer *llhlsMuxer, from, to int) {
+	for i := from; i < to; i++ {
+		key := i%20 == 0
+		frame := &mpegts.Frame{Dts: uint64(i) * 9000, Pts: uint64(i) * 9000, Sid: mpegts.StreamIdVideo, Key: key}
+		muxer.OnTsPackets([]byte{byte(i)}, frame, key)
+	}
+}
+
+func readPlaylist(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("read %s failed: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestLLHLS_PartsAndSegments(t *testing.T) {
+	muxer, dir := newTestLLHLS(t, false)
+	// Two full segments, then one part into the third
+	feedFrames(muxer, 0, 50)
+
+	playlist := readPlaylist(t, dir, llhlsPlaylistName)
+	for _, tag := range []string{
+		"#EXT-X-VERSION:9",
+		"#EXT-X-TARGETDURATION:2",
+		"#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=1.500",
+		"#EXT-X-PART-INF:PART-TARGET=0.500",
+		"#EXT-X-MEDIA-SEQUENCE:0",
+		"#EXTINF:2.000,",
+		"#EXT-X-PRELOAD-HINT:TYPE=PART,URI=",
+	} {
+		if !strings.Contains(playlist, tag) {
+			t.Fatalf("playlist is missing %q:\n%s", tag, playlist)
+		}
+	}
+	if strings.Contains(playlist, "#EXT-X-ENDLIST") {
+		t.Fatal("a live playlist must not end")
+	}
+
+	muxer.mu.Lock()
+	segments := append([]*llhlsSegment(nil), muxer.segments...)
+	current := muxer.current
+	muxer.mu.Unlock()
+	if len(segments) != 2 || current.msn != 2 || len(current.parts) != 1 {
+		t.Fatalf("expected 2 segments and 1 part of msn 2, got %d segments, msn %d, %d parts", len(segments), current.msn, len(current.parts))
+	}
+	for i, part := range segments[0].parts {
+		if part.duration > 0.5 {
+			t.Fatalf("part %d is longer than PART-TARGET: %.3f", i, part.duration)
+		}
+		if part.independent != (i == 0) {
+			t.Fatalf("only the part starting on the keyframe is independent, part %d: %v", i, part.independent)
+		}
+	}
+
+	// A segment is its parts back to back, each starting with PAT/PMT
+	var joined []byte
+	for _, part := range segments[1].parts {
+		data, err := os.ReadFile(filepath.Join(dir, part.uri))
+		if err != nil {
+			t.Fatalf("part file missing: %v", err)
+		}
+		if !bytes.HasPrefix(data, testPatPmt) {
+			t.Fatalf("part %s does not start with PAT/PMT", part.uri)
+		}
+		joined = append(joined, data...)
+	}
+	segmentData, err := os.ReadFile(filepath.Join(dir, segments[1].uri))
+	if err != nil {
+		t.Fatalf("segment file missing: %v", err)
+	}
+	if !bytes.Equal(joined, segmentData) {
+		t.Fatal("segment is not the concatenation of its parts")
+	}
+}
+
+func TestLLHLS_BlockingReload(t *testing.T) {
+	muxer, dir := newTestLLHLS(t, false)
+	feedFrames(muxer, 0, 26)
+	ctx := context.Background()
+
+	// Already there
+	if err := muxer.waitPart(ctx, 1, 0); err != nil {
+		t.Fatalf("existing part should not block: %v", err)
+	}
+	if err := muxer.waitPart(ctx, 0, -1); err != nil {
+		t.Fatalf("existing segment should not block: %v", err)
+	}
+	// Too far ahead
+	if err := muxer.waitPart(ctx, 9, 0); err != errors.ErrInvalidInput {
+		t.Fatalf("expected ErrInvalidInput, got %v", err)
+	}
+
+	// The next part releases the request, and the playlist on disk already
+	// lists it by then
+	muxer.mu.Lock()
+	hint := muxer.partURI
+	muxer.mu.Unlock()
+	done := make(chan error, 2)
+	go func() { done <- muxer.waitPart(ctx, 1, 1) }()
+	go func() { done <- muxer.waitHint(ctx, hint) }()
+	select {
+	case err := <-done:
+		t.Fatalf("request returned before the part was written: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	feedFrames(muxer, 26, 31)
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("blocked request failed: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("blocked request was not released")
+		}
+	}
+	if playlist := readPlaylist(t, dir, llhlsPlaylistName); !strings.Contains(playlist, hint) {
+		t.Fatalf("released before the playlist listed %s:\n%s", hint, playlist)
+	}
+	if _, err := os.Stat(filepath.Join(dir, hint)); err != nil {
+		t.Fatalf("hinted part was not written: %v", err)
+	}
+
+	// A request that never gets its part times out
+	shortCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := muxer.waitPart(shortCtx, 2, 0); err != errors.ErrTimeout {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+}
+
+func TestLLHLS_WindowCleanup(t *testing.T) {
+	muxer, dir := newTestLLHLS(t, false)
+	feedFrames(muxer, 0, 20)
+	muxer.mu.Lock()
+	first := muxer.current
+	muxer.mu.Unlock()
+
+	// Five more segments push the first one out of a window of three
+	feedFrames(muxer, 20, 121)
+	for _, part := range first.parts {
+		if _, err := os.Stat(filepath.Join(dir, part.uri)); !os.IsNotExist(err) {
+			t.Fatalf("expired part %s still on disk", part.uri)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, first.uri)); !os.IsNotExist(err) {
+		t.Fatalf("expired segment %s still on disk", first.uri)
+	}
+	playlist := readPlaylist(t, dir, llhlsPlaylistName)
+	if !strings.Contains(playlist, "#EXT-X-MEDIA-SEQUENCE:3\n") {
+		t.Fatalf("media sequence did not follow the window:\n%s", playlist)
+	}
+	if strings.Count(playlist, "#EXTINF:") != 3 {
+		t.Fatalf("expected 3 segments in the window:\n%s", playlist)
+	}
+}
+
+func TestLLHLS_CloseEndsPlaylistAndRecords(t *testing.T) {
+	muxer, dir := newTestLLHLS(t, true)
+	feedFrames(muxer, 0, 130)
+
+	waiting := make(chan error, 1)
+	go func() { waiting <- muxer.waitPart(context.Background(), 7, 0) }()
+	time.Sleep(20 * time.Millisecond)
+	muxer.close()
+	select {
+	case err := <-waiting:
+		if err != errors.ErrNotFound {
+			t.Fatalf("expected ErrNotFound after close, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("close did not release the waiting request")
+	}
+
+	playlist := readPlaylist(t, dir, llhlsPlaylistName)
+	if !strings.HasSuffix(playlist, "#EXT-X-ENDLIST\n") || strings.Contains(playlist, "PRELOAD-HINT") {
+		t.Fatalf("closed playlist must end without a hint:\n%s", playlist)
+	}
+
+	// Every segment is kept in the session directory and its record.m3u8,
+	// while the live directory only keeps the window
+	sessionDir := util.RecordingDir(dir, "session-1")
+	if strings.Contains(readPlaylist(t, sessionDir, util.RecordPlaylistName), "#EXT-X-ENDLIST") {
+		t.Fatal("record playlist ended before the publish did")
+	}
+	if err := muxer.recording.close(); err != nil {
+		t.Fatalf("closing the recording failed: %v", err)
+	}
+	record := readPlaylist(t, sessionDir, util.RecordPlaylistName)
+	if got := strings.Count(record, "#EXTINF:"); got != 7 {
+		t.Fatalf("expected 7 recorded segments, got %d:\n%s", got, record)
+	}
+	var recorded []string
+	for _, line := range strings.Split(record, "\n") {
+		if strings.HasSuffix(line, ".ts") {
+			recorded = append(recorded, line)
+			if _, err := os.Stat(filepath.Join(sessionDir, line)); err != nil {
+				t.Fatalf("recorded segment %s is missing", line)
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, recorded[0])); !os.IsNotExist(err) {
+		t.Fatalf("live directory kept %s after it left the window", recorded[0])
+	}
+	if !strings.HasPrefix(record, "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-PLAYLIST-TYPE:EVENT\n") || !strings.HasSuffix(record, "#EXT-X-ENDLIST\n") {
+		t.Fatalf("record playlist did not end:\n%s", record)
+	}
+
+	// The next publish records into a session of its own
+	next := newLLHLSMuxer(muxer.config, nopLogger{}, nil)
+	next.recording, _ = newRecordingSession(dir, "session-2")
+	next.OnPatPmt(testPatPmt)
+	feedFrames(next, 0, 21)
+	if record := readPlaylist(t, util.RecordingDir(dir, "session-2"), util.RecordPlaylistName); strings.Count(record, "#EXTINF:") != 1 {
+		t.Fatalf("expected 1 segment in the new session:\n%s", record)
+	}
+	if readPlaylist(t, sessionDir, util.RecordPlaylistName) != record {
+		t.Fatal("the next publish changed the previous recording")
+	}
+}
+
+func TestLLHLS_TimestampJumpMarksDiscontinuity(t *testing.T) {
+	muxer, dir := newTestLLHLS(t, false)
+	feedFrames(muxer, 0, 30)
+	// The encoder restarts from zero
+	feedFrames(muxer, 0, 10)
+
+	playlist := readPlaylist(t, dir, llhlsPlaylistName)
+	if !strings.Contains(playlist, "#EXT-X-DISCONTINUITY\n") {
+		t.Fatalf("expected a discontinuity:\n%s", playlist)
+	}
+}
+
+func TestLivestreamService_LowLatencyPublish(t *testing.T) {
+	service := NewLivestreamService(nopLogger{}, "127.0.0.1:0", nil)
+	addr := startTestServer(t, service)
+	updates := make(chan string, 64)
+	service.OnPlaylistUpdate(func(uuid, playlistPath string) {
+		updates <- playlistPath
+	})
+	if err := service.UpdateStreamOptions("uuid-1", streamInterface.StreamOptions{LowLatency: true}); err != errors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound for a stream that is not opened, got %v", err)
+	}
+	service.OpenStream("stream", "uuid-1", "key-1", streamInterface.StreamOptions{})
+	if err := service.UpdateStreamOptions("uuid-1", streamInterface.StreamOptions{LowLatency: true}); err != nil {
+		t.Fatalf("UpdateStreamOptions failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := service.WaitLowLatencyPart(ctx, "uuid-1", 0, 0); err != errors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound before publishing, got %v", err)
+	}
+
+	push := rtmp.NewPushSession()
+	if err := push.Start("rtmp://" + addr + "/live/key-1"); err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+	// The remuxer holds back the first 16 messages while it probes for audio
+	msgs := []base.RtmpMsg{testSeqHeader()}
+	for ts := uint32(0); ts <= 3000; ts += 100 {
+		msgs = append(msgs, testFrame(ts%500 == 0, ts))
+	}
+	for _, msg := range msgs {
+		if err := push.WriteMsg(msg); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	push.Flush()
+
+	var playlistPath string
+	select {
+	case playlistPath = <-updates:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no playlist update after publishing")
+	}
+	if err := service.WaitLowLatencyPart(ctx, "uuid-1", 0, 0); err != nil {
+		t.Fatalf("WaitLowLatencyPart failed: %v", err)
+	}
+	if playlist, err := os.ReadFile(playlistPath); err != nil || !strings.Contains(string(playlist), "#EXT-X-PART:") {
+		t.Fatalf("expected an LL-HLS playlist at %s (%v):\n%s", playlistPath, err, playlist)
+	}
+
+	// Ending the publish finishes the playlist
+	push.Dispose()
+	deadline := time.Now().Add(5 * time.Second)
+	for service.WaitLowLatencyPart(ctx, "uuid-1", 0, 0) != errors.ErrNotFound {
+		if time.Now().After(deadline) {
+			t.Fatal("LL-HLS muxer not stopped with the publisher")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if playlist, _ := os.ReadFile(playlistPath); !strings.HasSuffix(string(playlist), "#EXT-X-ENDLIST\n") {
+		t.Fatalf("playlist not ended:\n%s", playlist)
+	}
+}
@@ -0,0 +1,123 @@
+package livestream
+
+import (
+	streamInterface "Go-Service/src/main/application/interface/stream"
+	livestreamEntity "Go-Service/src/main/domain/entity/livestream"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/hls"
+)
+
+func TestMuxerConfig(t *testing.T) {
+	cases := []struct {
+		name     string
+		options  streamInterface.StreamOptions
+		expected hls.MuxerConfig
+	}{
+		{"defaults", streamInterface.StreamOptions{},
+			hls.MuxerConfig{OutPath: "/hls/uuid-1", FragmentDurationMs: 500, FragmentNum: 5, CleanupMode: hls.CleanupModeAsap}},
+		{"stability", streamInterface.StreamOptions{FragmentDurationMs: 4000, FragmentNum: 10, CleanupMode: livestreamEntity.CleanupModeInTheEnd},
+			hls.MuxerConfig{OutPath: "/hls/uuid-1", FragmentDurationMs: 4000, FragmentNum: 10, CleanupMode: hls.CleanupModeInTheEnd}},
+		// Recordings keep their own links to the fragments
+		{"record cleans up as usual", streamInterface.StreamOptions{IsRecord: true, CleanupMode: livestreamEntity.CleanupModeAsap},
+			hls.MuxerConfig{OutPath: "/hls/uuid-1", FragmentDurationMs: 500, FragmentNum: 5, CleanupMode: hls.CleanupModeAsap}},
+		{"dvr deletes fragments itself", streamInterface.StreamOptions{DVRWindowSeconds: 3600},
+			hls.MuxerConfig{OutPath: "/hls/uuid-1", FragmentDurationMs: 500, FragmentNum: 5, CleanupMode: hls.CleanupModeNever}},
+	}
+	for _, c := range cases {
+		if config := muxerConfig("/hls/uuid-1", c.options); config != c.expected {
+			t.Errorf("%s: expected %+v, got %+v", c.name, c.expected, config)
+		}
+	}
+}
+
+func TestCleanupHLSLater_RemovesFilesAfterPublish(t *testing.T) {
+	service := NewLivestreamService(nopLogger{}, "127.0.0.1:0", nil)
+	service.OpenStream("stream", "uuid-1", "key-1", streamInterface.StreamOptions{})
+	dir := t.TempDir()
+	for _, name := range []string{"playlist.m3u8", "record.m3u8", "stream-1.ts", "keep.txt"} {
+		os.WriteFile(filepath.Join(dir, name), []byte("data"), 0666)
+	}
+	os.Mkdir(filepath.Join(dir, "720p"), 0777)
+
+	// A publish that is still live keeps its files
+	conn, peer := net.Pipe()
+	defer peer.Close()
+	service.streams.beginPublish("uuid-1", conn)
+	service.cleanupHLSLater("uuid-1", dir, 30*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	if _, err := os.Stat(filepath.Join(dir, "playlist.m3u8")); err != nil {
+		t.Fatal("files of a live publish were removed")
+	}
+
+	service.streams.endPublish("uuid-1", conn)
+	service.cleanupHLSLater("uuid-1", dir, 30*time.Millisecond)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "playlist.m3u8")); os.IsNotExist(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("HLS files were not cleaned up")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	entries, _ := os.ReadDir(dir)
+	var left []string
+	for _, entry := range entries {
+		left = append(left, entry.Name())
+	}
+	if len(left) != 2 || left[0] != "720p" || left[1] != "keep.txt" {
+		t.Fatalf("only playlists and fragments should be removed, left %v", left)
+	}
+}
+
+func TestCleanupHLSLater_SkipsFilesOfNewerPublish(t *testing.T) {
+	service := NewLivestreamService(nopLogger{}, "127.0.0.1:0", nil)
+	service.OpenStream("stream", "uuid-1", "key-1", streamInterface.StreamOptions{})
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "playlist.m3u8"), []byte("data"), 0666)
+
+	// Publish A ends and schedules its cleanup
+	connA, peerA := net.Pipe()
+	defer peerA.Close()
+	service.streams.beginPublish("uuid-1", connA)
+	service.cleanupHLSLater("uuid-1", dir, 50*time.Millisecond)
+	service.streams.endPublish("uuid-1", connA)
+
+	// Publish B starts and ends within A's delay
+	connB, peerB := net.Pipe()
+	defer peerB.Close()
+	service.streams.beginPublish("uuid-1", connB)
+	service.streams.endPublish("uuid-1", connB)
+
+	time.Sleep(150 * time.Millisecond)
+	if _, err := os.Stat(filepath.Join(dir, "playlist.m3u8")); err != nil {
+		t.Fatal("files of the newer publish were removed by an older cleanup")
+	}
+}
+
+func TestEndOfPublishCleanup(t *testing.T) {
+	cases := []struct {
+		name    string
+		options streamInterface.StreamOptions
+		delay   time.Duration
+		cleanup bool
+	}{
+		{"asap", streamInterface.StreamOptions{}, 0, false},
+		{"never", streamInterface.StreamOptions{CleanupMode: livestreamEntity.CleanupModeNever, DVRWindowSeconds: 60}, 0, false},
+		{"record", streamInterface.StreamOptions{IsRecord: true, CleanupMode: livestreamEntity.CleanupModeInTheEnd, FragmentDurationMs: 1000, FragmentNum: 4}, 5 * time.Second, true},
+		{"in the end", streamInterface.StreamOptions{CleanupMode: livestreamEntity.CleanupModeInTheEnd, FragmentDurationMs: 1000, FragmentNum: 4}, 5 * time.Second, true},
+		{"dvr window", streamInterface.StreamOptions{DVRWindowSeconds: 7200}, 2 * time.Hour, true},
+	}
+	for _, c := range cases {
+		delay, cleanup := endOfPublishCleanup(c.options)
+		if delay != c.delay || cleanup != c.cleanup {
+			t.Errorf("%s: expected %v/%v, got %v/%v", c.name, c.delay, c.cleanup, delay, cleanup)
+		}
+	}
+}
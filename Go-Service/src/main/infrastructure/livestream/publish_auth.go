@@ -0,0 +1,95 @@
+package livestream
+
+import (
+	"Go-Service/src/main/domain/entity/errors"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PublishRequest describes an RTMP publish attempt that matched a stream key
+type PublishRequest struct {
+	Action     string `json:"action"`
+	UUID       string `json:"uuid"`
+	Name       string `json:"name"`
+	App        string `json:"app"`
+	Stream     string `json:"stream"`
+	Query      string `json:"query"`
+	RemoteAddr string `json:"remote_addr"`
+}
+
+// PublishHook lets an external service veto a publish. Returning an error
+// rejects the publisher.
+type PublishHook interface {
+	OnPublish(ctx context.Context, req PublishRequest) error
+}
+
+// HTTPPublishHook posts the publish attempt as JSON to a configured URL.
+// Any 2xx response approves the publish; everything else, including a
+// timeout, denies it.
+type HTTPPublishHook struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPPublishHook(callbackURL string, timeout time.Duration) *HTTPPublishHook {
+	return &HTTPPublishHook{
+		url:    callbackURL,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (h *HTTPPublishHook) OnPublish(ctx context.Context, req PublishRequest) error {
+	req.Action = "on_publish"
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: on_publish returned status %d", errors.ErrUnauthorized, resp.StatusCode)
+	}
+	return nil
+}
+
+// parsePublishURL splits an RTMP publish URL into app and stream name.
+// Accepted forms are rtmp://host[:port]/<stream> and
+// rtmp://host[:port]/<app>/<stream>; the query string is not part of the
+// stream name.
+func parsePublishURL(rawURL string) (app string, streamName string, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", errors.ErrInvalidInput
+	}
+	if u.Scheme != "" && u.Scheme != "rtmp" && u.Scheme != "rtmps" {
+		return "", "", errors.ErrInvalidInput
+	}
+	path := strings.Trim(u.Path, "/")
+	if path == "" {
+		return "", "", errors.ErrInvalidInput
+	}
+	segments := strings.Split(path, "/")
+	switch len(segments) {
+	case 1:
+		streamName = segments[0]
+	case 2:
+		app, streamName = segments[0], segments[1]
+	default:
+		return "", "", errors.ErrInvalidInput
+	}
+	return app, streamName, nil
+}
@@ -0,0 +1,114 @@
+package livestream
+
+import (
+	streamInterface "Go-Service/src/main/application/interface/stream"
+	"Go-Service/src/main/domain/entity/errors"
+	"context"
+	"encoding/json"
+	goErrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParsePublishURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		wantApp    string
+		wantStream string
+		wantErr    bool
+	}{
+		{"Stream Only", "rtmp://localhost:1935/abc123", "", "abc123", false},
+		{"Empty App From tcUrl", "rtmp://localhost:1935//abc123", "", "abc123", false},
+		{"App And Stream", "rtmp://localhost/live/abc123", "live", "abc123", false},
+		{"Query Stripped", "rtmp://localhost/live/abc123?token=x", "live", "abc123", false},
+		{"Trailing Slash", "rtmp://localhost/abc123/", "", "abc123", false},
+		{"RTMPS", "rtmps://localhost:443/abc123", "", "abc123", false},
+		{"No Stream", "rtmp://localhost:1935/", "", "", true},
+		{"Too Many Segments", "rtmp://localhost/live/extra/abc123", "", "", true},
+		{"Wrong Scheme", "http://localhost/abc123", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, stream, err := parsePublishURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePublishURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if app != tt.wantApp || stream != tt.wantStream {
+				t.Errorf("parsePublishURL() = (%q, %q), want (%q, %q)", app, stream, tt.wantApp, tt.wantStream)
+			}
+		})
+	}
+}
+
+func TestGetLivestreamByUrl_ExactMatch(t *testing.T) {
+	service := NewLivestreamService(nopLogger{}, "127.0.0.1:0", nil)
+	service.OpenStream("short", "uuid-short", "abc", streamInterface.StreamOptions{})
+	service.OpenStream("long", "uuid-long", "abcdef", streamInterface.StreamOptions{})
+
+	// Overlapping keys must resolve to the exact key regardless of map order
+	for i := 0; i < 20; i++ {
+		ls, found := service.getLivestreamByUrl("rtmp://localhost:1935/abcdef")
+		if !found || ls.uuid != "uuid-long" {
+			t.Fatalf("expected uuid-long, got %q (found=%v)", ls.uuid, found)
+		}
+		ls, found = service.getLivestreamByUrl("rtmp://localhost:1935/abc")
+		if !found || ls.uuid != "uuid-short" {
+			t.Fatalf("expected uuid-short, got %q (found=%v)", ls.uuid, found)
+		}
+	}
+
+	if _, found := service.getLivestreamByUrl("rtmp://localhost:1935/xabcdefx"); found {
+		t.Fatal("a URL merely containing a key must not match")
+	}
+	if _, found := service.getLivestreamByUrl("rtmp://localhost:1935/ab"); found {
+		t.Fatal("a key prefix must not match")
+	}
+}
+
+func TestHTTPPublishHook(t *testing.T) {
+	var received PublishRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if received.Query == "token=good" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	hook := NewHTTPPublishHook(server.URL, time.Second)
+
+	err := hook.OnPublish(context.Background(), PublishRequest{UUID: "uuid-1", Stream: "abc", Query: "token=good"})
+	if err != nil {
+		t.Fatalf("expected publish to be approved, got %v", err)
+	}
+	if received.Action != "on_publish" || received.UUID != "uuid-1" || received.Stream != "abc" {
+		t.Errorf("unexpected callback payload: %+v", received)
+	}
+
+	err = hook.OnPublish(context.Background(), PublishRequest{UUID: "uuid-1", Stream: "abc", Query: "token=bad"})
+	if !goErrors.Is(err, errors.ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestHTTPPublishHook_TimeoutDenies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	hook := NewHTTPPublishHook(server.URL, 50*time.Millisecond)
+	if err := hook.OnPublish(context.Background(), PublishRequest{UUID: "uuid-1"}); err == nil {
+		t.Fatal("a callback that times out must deny the publish")
+	}
+}
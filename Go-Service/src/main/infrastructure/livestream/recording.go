@@ -0,0 +1,122 @@
+package livestream
+
+import (
+	"Go-Service/src/main/infrastructure/util"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// recordingSession records one publish into its own directory. Every closed
+// segment is hard linked there, so the live directory cleans up as usual,
+// and listed in the session's record.m3u8, which close ends once the publish
+// is over.
+type recordingSession struct {
+	dir      string
+	playlist *recordPlaylist
+}
+
+func newRecordingSession(outputPath, sessionID string) (*recordingSession, error) {
+	dir := util.RecordingDir(outputPath, sessionID)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	return &recordingSession{
+		dir:      dir,
+		playlist: &recordPlaylist{path: filepath.Join(dir, util.RecordPlaylistName)},
+	}, nil
+}
+
+// add records a closed segment
+func (r *recordingSession) add(segmentPath string, duration float64, discont bool) error {
+	if r.playlist.ended {
+		return nil
+	}
+	name := filepath.Base(segmentPath)
+	if err := linkOrCopy(segmentPath, filepath.Join(r.dir, name)); err != nil {
+		return err
+	}
+	return r.playlist.append(name, duration, discont)
+}
+
+// close ends the record playlist
+func (r *recordingSession) close() error {
+	return r.playlist.close()
+}
+
+// linkOrCopy copies a file where it cannot be hard linked
+func linkOrCopy(source, target string) error {
+	if err := os.Link(source, target); err == nil || os.IsExist(err) {
+		return nil
+	}
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(target + ".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(target + ".tmp")
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(target + ".tmp")
+		return err
+	}
+	return os.Rename(target+".tmp", target)
+}
+
+// recordPlaylist appends segments to record.m3u8. It is an EVENT playlist
+// that gets its EXT-X-ENDLIST when the publish ends, so the recording can be
+// played while it grows and a cut off recording is told apart.
+type recordPlaylist struct {
+	path        string
+	body        bytes.Buffer
+	maxDuration int
+	ended       bool
+}
+
+func (r *recordPlaylist) append(uri string, duration float64, discont bool) error {
+	if discont {
+		r.body.WriteString("#EXT-X-DISCONTINUITY\n")
+	}
+	r.maxDuration = max(r.maxDuration, int(math.Ceil(duration)))
+	r.body.WriteString(fmt.Sprintf("#EXTINF:%.3f,\n%s\n", duration, uri))
+	return r.write()
+}
+
+// close ends the playlist. Nothing is written when no segment was recorded.
+func (r *recordPlaylist) close() error {
+	if r.ended {
+		return nil
+	}
+	r.ended = true
+	if r.body.Len() == 0 {
+		return nil
+	}
+	return r.write()
+}
+
+func (r *recordPlaylist) write() error {
+	var buf bytes.Buffer
+	buf.WriteString("#EXTM3U\n")
+	buf.WriteString("#EXT-X-VERSION:3\n")
+	buf.WriteString("#EXT-X-PLAYLIST-TYPE:EVENT\n")
+	buf.WriteString(fmt.Sprintf("#EXT-X-TARGETDURATION:%d\n", r.maxDuration))
+	buf.WriteString("#EXT-X-MEDIA-SEQUENCE:0\n\n")
+	buf.Write(r.body.Bytes())
+	if r.ended {
+		buf.WriteString("#EXT-X-ENDLIST\n")
+	}
+	if err := os.WriteFile(r.path+".tmp", buf.Bytes(), 0666); err != nil {
+		return err
+	}
+	return os.Rename(r.path+".tmp", r.path)
+}
@@ -0,0 +1,87 @@
+package livestream
+
+import (
+	"Go-Service/src/main/infrastructure/util"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/base"
+)
+
+func TestRecordingSession_KeepsClosedFragments(t *testing.T) {
+	service := NewLivestreamService(nopLogger{}, "127.0.0.1:0", nil)
+	dir := t.TempDir()
+	recording, err := newRecordingSession(dir, "session-1")
+	if err != nil {
+		t.Fatalf("newRecordingSession failed: %v", err)
+	}
+	observer := &playlistObserver{service: service, uuid: "uuid-1", recording: recording}
+
+	for _, name := range []string{"stream-1.ts", "stream-2.ts"} {
+		fragment := filepath.Join(dir, name)
+		os.WriteFile(fragment, []byte(name), 0666)
+		observer.OnHlsMakeTs(base.HlsMakeTsInfo{Event: "close", TsFile: fragment, LiveM3u8File: filepath.Join(dir, "playlist.m3u8"), Duration: 2})
+		// The live directory cleans up right away
+		os.Remove(fragment)
+	}
+
+	sessionDir := util.RecordingDir(dir, "session-1")
+	if data, err := os.ReadFile(filepath.Join(sessionDir, "stream-1.ts")); err != nil || string(data) != "stream-1.ts" {
+		t.Fatalf("recorded fragment was lost: %q, %v", data, err)
+	}
+	record := readPlaylist(t, sessionDir, util.RecordPlaylistName)
+	if !strings.HasSuffix(record, "#EXTINF:2.000,\nstream-1.ts\n#EXTINF:2.000,\nstream-2.ts\n") {
+		t.Fatalf("unexpected record playlist:\n%s", record)
+	}
+}
+
+func TestRecordingSession_CloseEndsPlaylist(t *testing.T) {
+	dir := t.TempDir()
+	recording, err := newRecordingSession(dir, "session-1")
+	if err != nil {
+		t.Fatalf("newRecordingSession failed: %v", err)
+	}
+	fragment := filepath.Join(dir, "stream-1.ts")
+	os.WriteFile(fragment, []byte("ts"), 0666)
+	recording.add(fragment, 2, false)
+
+	sessionDir := util.RecordingDir(dir, "session-1")
+	if record := readPlaylist(t, sessionDir, util.RecordPlaylistName); strings.Contains(record, "#EXT-X-ENDLIST") {
+		t.Fatalf("playlist ended while recording:\n%s", record)
+	}
+	if err := recording.close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	expected := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-PLAYLIST-TYPE:EVENT\n#EXT-X-TARGETDURATION:2\n#EXT-X-MEDIA-SEQUENCE:0\n\n" +
+		"#EXTINF:2.000,\nstream-1.ts\n#EXT-X-ENDLIST\n"
+	if record := readPlaylist(t, sessionDir, util.RecordPlaylistName); record != expected {
+		t.Fatalf("unexpected record playlist:\n%s", record)
+	}
+
+	// A fragment flushed after the end is not recorded
+	late := filepath.Join(dir, "stream-2.ts")
+	os.WriteFile(late, []byte("ts"), 0666)
+	recording.add(late, 2, false)
+	if _, err := os.Stat(filepath.Join(sessionDir, "stream-2.ts")); !os.IsNotExist(err) {
+		t.Fatal("fragment linked after close")
+	}
+	if record := readPlaylist(t, sessionDir, util.RecordPlaylistName); record != expected {
+		t.Fatalf("playlist changed after close:\n%s", record)
+	}
+}
+
+func TestRecordingSession_CloseWithoutSegments(t *testing.T) {
+	dir := t.TempDir()
+	recording, err := newRecordingSession(dir, "session-1")
+	if err != nil {
+		t.Fatalf("newRecordingSession failed: %v", err)
+	}
+	if err := recording.close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(util.RecordingDir(dir, "session-1"), util.RecordPlaylistName)); !os.IsNotExist(err) {
+		t.Fatal("an empty recording got a playlist")
+	}
+}
@@ -0,0 +1,167 @@
+package livestream
+
+import (
+	streamInterface "Go-Service/src/main/application/interface/stream"
+	"Go-Service/src/main/domain/entity/errors"
+	livestreamEntity "Go-Service/src/main/domain/entity/livestream"
+	"net"
+	"sync"
+	"time"
+)
+
+// streamRegistry holds every opened livestream. HTTP handlers write it through
+// OpenStream/CloseStream while RTMP goroutines read it, so all access goes
+// through the lock and callers only ever get copies of the entries.
+type streamRegistry struct {
+	mu      sync.RWMutex
+	streams map[string]*livestream
+}
+
+func newStreamRegistry() *streamRegistry {
+	return &streamRegistry{streams: make(map[string]*livestream)}
+}
+
+// add registers a stream, replacing any previous entry with the same uuid.
+// A publisher that is still connected keeps its live state.
+func (r *streamRegistry) add(s livestream) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if old, exists := r.streams[s.uuid]; exists {
+		s.conn = old.conn
+		s.status = old.status
+		s.blockedUntil = old.blockedUntil
+		s.publishes = old.publishes
+	} else {
+		s.status = livestreamEntity.LiveStatus{State: livestreamEntity.LiveStateIdle}
+	}
+	r.streams[s.uuid] = &s
+}
+
+func (r *streamRegistry) remove(uuid string) (livestream, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, exists := r.streams[uuid]
+	if !exists {
+		return livestream{}, false
+	}
+	delete(r.streams, uuid)
+	return *s, true
+}
+
+func (r *streamRegistry) get(uuid string) (livestream, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, exists := r.streams[uuid]
+	if !exists {
+		return livestream{}, false
+	}
+	return *s, true
+}
+
+// find returns the first stream matching the predicate
+func (r *streamRegistry) find(match func(s *livestream) bool) (livestream, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, s := range r.streams {
+		if match(s) {
+			return *s, true
+		}
+	}
+	return livestream{}, false
+}
+
+// setAPIKey replaces the stream key and returns the connected publisher, if any
+func (r *streamRegistry) setAPIKey(uuid string, apiKey string) (net.Conn, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, exists := r.streams[uuid]
+	if !exists {
+		return nil, errors.ErrNotFound
+	}
+	s.apiKey = apiKey
+	return s.conn, nil
+}
+
+// setOptions replaces the ingest options, which apply from the next publish
+func (r *streamRegistry) setOptions(uuid string, options streamInterface.StreamOptions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, exists := r.streams[uuid]
+	if !exists {
+		return errors.ErrNotFound
+	}
+	s.options = options
+	return nil
+}
+
+// kick blocks new publishes until the given time and returns the connected
+// publisher, if any
+func (r *streamRegistry) kick(uuid string, blockedUntil time.Time) (net.Conn, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, exists := r.streams[uuid]
+	if !exists {
+		return nil, errors.ErrNotFound
+	}
+	s.blockedUntil = blockedUntil
+	return s.conn, nil
+}
+
+// beginPublish marks the stream as publishing from conn. Only one publisher
+// may be connected to a stream at a time.
+func (r *streamRegistry) beginPublish(uuid string, conn net.Conn) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, exists := r.streams[uuid]
+	if !exists {
+		return errors.ErrNotFound
+	}
+	if s.status.State == livestreamEntity.LiveStatePublishing {
+		return errors.ErrExists
+	}
+	if time.Now().Before(s.blockedUntil) {
+		return errors.ErrUnauthorized
+	}
+	s.conn = conn
+	s.publishes++
+	s.status = livestreamEntity.LiveStatus{
+		State:      livestreamEntity.LiveStatePublishing,
+		RemoteAddr: conn.RemoteAddr().String(),
+		StartedAt:  time.Now(),
+	}
+	return nil
+}
+
+// endPublish marks the stream as disconnected if conn is still its publisher
+func (r *streamRegistry) endPublish(uuid string, conn net.Conn) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, exists := r.streams[uuid]
+	if !exists || s.conn != conn {
+		return false
+	}
+	s.conn = nil
+	s.status.State = livestreamEntity.LiveStateDisconnected
+	return true
+}
+
+func (r *streamRegistry) status(uuid string) (livestreamEntity.LiveStatus, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, exists := r.streams[uuid]
+	if !exists {
+		return livestreamEntity.LiveStatus{}, false
+	}
+	return s.status, true
+}
+
+// publishCount returns how many publishes the stream has begun
+func (r *streamRegistry) publishCount(uuid string) (uint64, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, exists := r.streams[uuid]
+	if !exists {
+		return 0, false
+	}
+	return s.publishes, true
+}
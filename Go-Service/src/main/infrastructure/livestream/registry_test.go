@@ -0,0 +1,149 @@
+package livestream
+
+import (
+	streamInterface "Go-Service/src/main/application/interface/stream"
+	"Go-Service/src/main/domain/entity/errors"
+	livestreamEntity "Go-Service/src/main/domain/entity/livestream"
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Panic(ctx context.Context, msg string) {}
+func (nopLogger) Fatal(ctx context.Context, msg string) {}
+func (nopLogger) Error(ctx context.Context, msg string) {}
+func (nopLogger) Warn(ctx context.Context, msg string)  {}
+func (nopLogger) Info(ctx context.Context, msg string)  {}
+func (nopLogger) Debug(ctx context.Context, msg string) {}
+func (nopLogger) Trace(ctx context.Context, msg string) {}
+
+func TestStreamRegistry_StateTransitions(t *testing.T) {
+	service := NewLivestreamService(nopLogger{}, "127.0.0.1:0", nil)
+	service.OpenStream("stream", "uuid-1", "key-1", streamInterface.StreamOptions{})
+
+	status, ok := service.GetLiveStatus("uuid-1")
+	if !ok || status.State != livestreamEntity.LiveStateIdle {
+		t.Fatalf("expected idle state, got %+v (found=%v)", status, ok)
+	}
+
+	conn, peer := net.Pipe()
+	defer peer.Close()
+	if err := service.streams.beginPublish("uuid-1", conn); err != nil {
+		t.Fatalf("beginPublish failed: %v", err)
+	}
+	status, _ = service.GetLiveStatus("uuid-1")
+	if status.State != livestreamEntity.LiveStatePublishing {
+		t.Fatalf("expected publishing state, got %s", status.State)
+	}
+	if status.RemoteAddr != conn.RemoteAddr().String() || status.StartedAt.IsZero() {
+		t.Fatalf("publisher info not recorded: %+v", status)
+	}
+
+	// Re-opening the stream must not drop the connected publisher
+	service.OpenStream("renamed", "uuid-1", "key-1", streamInterface.StreamOptions{IsRecord: true})
+	status, _ = service.GetLiveStatus("uuid-1")
+	if status.State != livestreamEntity.LiveStatePublishing {
+		t.Fatalf("expected publishing state after reopen, got %s", status.State)
+	}
+
+	if !service.streams.endPublish("uuid-1", conn) {
+		t.Fatal("endPublish should succeed for the current publisher")
+	}
+	status, _ = service.GetLiveStatus("uuid-1")
+	if status.State != livestreamEntity.LiveStateDisconnected {
+		t.Fatalf("expected disconnected state, got %s", status.State)
+	}
+}
+
+func TestStreamRegistry_SinglePublisher(t *testing.T) {
+	registry := newStreamRegistry()
+	registry.add(livestream{name: "stream", uuid: "uuid-1", apiKey: "key-1"})
+
+	first, firstPeer := net.Pipe()
+	defer firstPeer.Close()
+	second, secondPeer := net.Pipe()
+	defer secondPeer.Close()
+
+	if err := registry.beginPublish("uuid-1", first); err != nil {
+		t.Fatalf("beginPublish failed: %v", err)
+	}
+	if err := registry.beginPublish("uuid-1", second); err != errors.ErrExists {
+		t.Fatalf("expected ErrExists for second publisher, got %v", err)
+	}
+	if registry.endPublish("uuid-1", second) {
+		t.Fatal("endPublish must ignore a connection that is not the publisher")
+	}
+	if err := registry.beginPublish("missing", first); err != errors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound for unknown stream, got %v", err)
+	}
+}
+
+func TestUpdateStreamKey_OldKeyRejected(t *testing.T) {
+	service := NewLivestreamService(nopLogger{}, "127.0.0.1:0", nil)
+	service.OpenStream("stream", "uuid-1", "old-key", streamInterface.StreamOptions{})
+
+	conn, peer := net.Pipe()
+	defer peer.Close()
+	if err := service.streams.beginPublish("uuid-1", conn); err != nil {
+		t.Fatalf("beginPublish failed: %v", err)
+	}
+
+	if err := service.UpdateStreamKey("uuid-1", "new-key", true); err != nil {
+		t.Fatalf("UpdateStreamKey failed: %v", err)
+	}
+	if _, found := service.getLivestreamByUrl("rtmp://localhost/old-key"); found {
+		t.Fatal("old key must no longer match")
+	}
+	if _, found := service.getLivestreamByUrl("rtmp://localhost/new-key"); !found {
+		t.Fatal("new key must match")
+	}
+	// The dropped publisher's connection is closed
+	if _, err := conn.Write([]byte{0}); err == nil {
+		t.Fatal("expected publisher connection to be closed")
+	}
+	if err := service.UpdateStreamKey("missing", "key", false); err != errors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// Run with -race: opens, closes, lookups and publishes hit the registry from
+// many goroutines at once, like HTTP handlers and RTMP sessions do.
+func TestStreamRegistry_ConcurrentOpenClosePublish(t *testing.T) {
+	service := NewLivestreamService(nopLogger{}, "127.0.0.1:0", nil)
+	const workers = 16
+	const iterations = 200
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			uuid := "race-stream-" + strconv.Itoa(w%4)
+			apiKey := "race-key-" + strconv.Itoa(w%4)
+			for i := 0; i < iterations; i++ {
+				switch i % 4 {
+				case 0:
+					service.OpenStream("race", uuid, apiKey, streamInterface.StreamOptions{IsRecord: i%2 == 0})
+				case 1:
+					conn, peer := net.Pipe()
+					if service.streams.beginPublish(uuid, conn) == nil {
+						service.GetLiveStatus(uuid)
+						service.streams.endPublish(uuid, conn)
+					}
+					conn.Close()
+					peer.Close()
+				case 2:
+					service.getLivestreamByUrl("rtmp://localhost/" + apiKey)
+					service.IsLiveStreamExist(uuid)
+				case 3:
+					service.CloseStream(uuid)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+}
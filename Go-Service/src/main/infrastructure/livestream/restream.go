@@ -0,0 +1,301 @@
+package livestream
+
+import (
+	"Go-Service/src/main/domain/entity/errors"
+	livestreamEntity "Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/domain/interface/logger"
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/base"
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/remux"
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/rtmp"
+)
+
+const (
+	// restreamQueueSize is how many messages a relay may fall behind before
+	// messages are dropped and the relay waits for the next keyframe
+	restreamQueueSize       = 1024
+	restreamPushTimeoutMs   = 10000
+	restreamWriteTimeoutMs  = 10000
+	defaultRestreamMinDelay = time.Second
+	defaultRestreamMaxDelay = 30 * time.Second
+)
+
+// restreamer relays one publish to the restream targets of a livestream. Each
+// target has its own push session and goroutine, so a slow or failing target
+// never stalls the publisher or the other targets.
+type restreamer struct {
+	uuid       string
+	logger     logger.Logger
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mu             sync.Mutex
+	relays         map[string]*restreamRelay
+	closed         bool
+	metadata       *base.RtmpMsg
+	videoSeqHeader *base.RtmpMsg
+	audioSeqHeader *base.RtmpMsg
+}
+
+func newRestreamer(uuid string, logger logger.Logger, minBackoff, maxBackoff time.Duration) *restreamer {
+	return &restreamer{
+		uuid:       uuid,
+		logger:     logger,
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+		relays:     make(map[string]*restreamRelay),
+	}
+}
+
+// setTargets starts relays for new targets and stops relays whose target was
+// removed, disabled or pointed at another URL
+func (r *restreamer) setTargets(targets []livestreamEntity.RestreamTarget) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	wanted := make(map[string]livestreamEntity.RestreamTarget, len(targets))
+	for _, target := range targets {
+		if target.Enabled {
+			wanted[target.ID] = target
+		}
+	}
+	for id, relay := range r.relays {
+		if target, ok := wanted[id]; !ok || target.URL != relay.target.URL {
+			relay.stop()
+			delete(r.relays, id)
+		}
+	}
+	for id, target := range wanted {
+		if _, ok := r.relays[id]; ok {
+			continue
+		}
+		relay := newRestreamRelay(target, r)
+		r.relays[id] = relay
+		go relay.run()
+	}
+}
+
+// feed hands a message from the publisher to every relay without blocking
+func (r *restreamer) feed(msg base.RtmpMsg) {
+	switch msg.Header.MsgTypeId {
+	case base.RtmpTypeIdAudio, base.RtmpTypeIdVideo:
+		if len(msg.Payload) < 2 {
+			return
+		}
+	case base.RtmpTypeIdMetadata:
+	default:
+		return
+	}
+	// The payload belongs to the session's read buffer
+	msg = msg.Clone()
+	msg.Header = remux.MakeDefaultRtmpHeader(msg.Header)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case msg.Header.MsgTypeId == base.RtmpTypeIdMetadata:
+		r.metadata = &msg
+	case isVideoSeqHeader(msg):
+		r.videoSeqHeader = &msg
+	case msg.IsAacSeqHeader():
+		r.audioSeqHeader = &msg
+	}
+	for _, relay := range r.relays {
+		relay.deliver(msg)
+	}
+}
+
+// headers returns what a freshly connected relay must send before media
+func (r *restreamer) headers() (msgs []base.RtmpMsg) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, msg := range []*base.RtmpMsg{r.metadata, r.videoSeqHeader, r.audioSeqHeader} {
+		if msg != nil {
+			msgs = append(msgs, *msg)
+		}
+	}
+	return msgs
+}
+
+func (r *restreamer) statuses() map[string]livestreamEntity.RestreamStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statuses := make(map[string]livestreamEntity.RestreamStatus, len(r.relays))
+	for id, relay := range r.relays {
+		statuses[id] = relay.getStatus()
+	}
+	return statuses
+}
+
+// close stops every relay. It does not wait for the push sessions to end.
+func (r *restreamer) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	for id, relay := range r.relays {
+		relay.stop()
+		delete(r.relays, id)
+	}
+}
+
+// restreamRelay pushes to one target, reconnecting with exponential backoff
+// until it is stopped
+type restreamRelay struct {
+	target   livestreamEntity.RestreamTarget
+	owner    *restreamer
+	msgs     chan base.RtmpMsg
+	dropped  atomic.Bool
+	done     chan struct{}
+	stopOnce sync.Once
+	exited   chan struct{}
+
+	statusLock sync.Mutex
+	status     livestreamEntity.RestreamStatus
+}
+
+func newRestreamRelay(target livestreamEntity.RestreamTarget, owner *restreamer) *restreamRelay {
+	return &restreamRelay{
+		target: target,
+		owner:  owner,
+		msgs:   make(chan base.RtmpMsg, restreamQueueSize),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+		status: livestreamEntity.RestreamStatus{State: livestreamEntity.RestreamStateConnecting, Since: time.Now()},
+	}
+}
+
+func (rr *restreamRelay) deliver(msg base.RtmpMsg) {
+	select {
+	case rr.msgs <- msg:
+	default:
+		rr.dropped.Store(true)
+	}
+}
+
+func (rr *restreamRelay) stop() {
+	rr.stopOnce.Do(func() { close(rr.done) })
+}
+
+func (rr *restreamRelay) getStatus() livestreamEntity.RestreamStatus {
+	rr.statusLock.Lock()
+	defer rr.statusLock.Unlock()
+	return rr.status
+}
+
+func (rr *restreamRelay) setStatus(state livestreamEntity.RestreamState, lastError string, attempts int) {
+	rr.statusLock.Lock()
+	defer rr.statusLock.Unlock()
+	rr.status = livestreamEntity.RestreamStatus{State: state, LastError: lastError, Attempts: attempts, Since: time.Now()}
+}
+
+func (rr *restreamRelay) run() {
+	defer close(rr.exited)
+	backoff := rr.owner.minBackoff
+	attempts := 0
+	for {
+		rr.setStatus(livestreamEntity.RestreamStateConnecting, "", attempts)
+		connected, err := rr.push()
+		select {
+		case <-rr.done:
+			return
+		default:
+		}
+		if connected {
+			// The target accepted us, so start over with a short delay
+			backoff = rr.owner.minBackoff
+			attempts = 0
+		}
+		attempts++
+		if err == nil {
+			err = errors.ErrConnectionClosed
+		}
+		rr.setStatus(livestreamEntity.RestreamStateRetrying, err.Error(), attempts)
+		rr.owner.logger.Warn(context.TODO(), "Restream to target "+rr.target.ID+" of livestream "+rr.owner.uuid+" failed (attempt "+strconv.Itoa(attempts)+"): "+err.Error())
+
+		select {
+		case <-rr.done:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > rr.owner.maxBackoff {
+			backoff = rr.owner.maxBackoff
+		}
+	}
+}
+
+// push runs one push session until it fails or the relay is stopped.
+// connected reports whether the target accepted the publish.
+func (rr *restreamRelay) push() (connected bool, err error) {
+	session := rtmp.NewPushSession(func(option *rtmp.PushSessionOption) {
+		option.PushTimeoutMs = restreamPushTimeoutMs
+		option.WriteAvTimeoutMs = restreamWriteTimeoutMs
+	})
+	defer session.Dispose()
+	if err := session.Start(rr.target.URL); err != nil {
+		return false, err
+	}
+
+	// Anything queued while disconnected is stale
+	for len(rr.msgs) > 0 {
+		<-rr.msgs
+	}
+	rr.dropped.Store(false)
+	for _, msg := range rr.owner.headers() {
+		if err := session.WriteMsg(msg); err != nil {
+			return true, err
+		}
+	}
+	if err := session.Flush(); err != nil {
+		return true, err
+	}
+	rr.setStatus(livestreamEntity.RestreamStateLive, "", 0)
+	rr.owner.logger.Info(context.TODO(), "Restreaming livestream "+rr.owner.uuid+" to target "+rr.target.ID)
+
+	// Video may only resume on a keyframe, after connecting or dropping
+	waitKeyframe := true
+	for {
+		select {
+		case <-rr.done:
+			return true, nil
+		case err := <-session.WaitChan():
+			return true, err
+		case msg := <-rr.msgs:
+			if rr.dropped.Swap(false) {
+				waitKeyframe = true
+			}
+			if waitKeyframe && msg.Header.MsgTypeId == base.RtmpTypeIdVideo && !isVideoSeqHeader(msg) {
+				if !isVideoKeyframe(msg) {
+					continue
+				}
+				waitKeyframe = false
+			}
+			if err := session.WriteMsg(msg); err != nil {
+				return true, err
+			}
+			// The session buffers writes; flush once the queue is drained
+			if len(rr.msgs) == 0 {
+				if err := session.Flush(); err != nil {
+					return true, err
+				}
+			}
+		}
+	}
+}
+
+// isVideoSeqHeader and isVideoKeyframe guard the lal helpers, which index
+// into the payload unchecked
+func isVideoSeqHeader(msg base.RtmpMsg) bool {
+	return msg.Header.MsgTypeId == base.RtmpTypeIdVideo && len(msg.Payload) >= 5 && msg.IsVideoKeySeqHeader()
+}
+
+func isVideoKeyframe(msg base.RtmpMsg) bool {
+	return msg.Header.MsgTypeId == base.RtmpTypeIdVideo && len(msg.Payload) >= 5 && msg.IsVideoKeyNalu()
+}
@@ -0,0 +1,249 @@
+package livestream
+
+import (
+	streamInterface "Go-Service/src/main/application/interface/stream"
+	livestreamEntity "Go-Service/src/main/domain/entity/livestream"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/base"
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/rtmp"
+)
+
+// rtmpTarget is a minimal RTMP server that records what is published to it
+type rtmpTarget struct {
+	listener net.Listener
+	msgs     chan base.RtmpMsg
+	mu       sync.Mutex
+	conns    []net.Conn
+	urls     []string
+}
+
+func startRTMPTarget(t *testing.T, addr string) *rtmpTarget {
+	t.Helper()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("target listen failed: %v", err)
+	}
+	target := &rtmpTarget{listener: listener, msgs: make(chan base.RtmpMsg, 256)}
+	go target.acceptLoop()
+	t.Cleanup(target.close)
+	return target
+}
+
+func (tg *rtmpTarget) acceptLoop() {
+	for {
+		conn, err := tg.listener.Accept()
+		if err != nil {
+			return
+		}
+		tg.mu.Lock()
+		tg.conns = append(tg.conns, conn)
+		tg.mu.Unlock()
+		go tg.serve(conn)
+	}
+}
+
+func (tg *rtmpTarget) serve(conn net.Conn) {
+	session := rtmp.NewServerSession(conn)
+	_ = session.RunLoop(func(stream *rtmp.Stream) error {
+		switch stream.Header.MsgTypeId {
+		case base.RtmpTypeIdCommandMessageAmf0:
+			_ = session.DoCommandMessage(stream)
+			if session.Url() != "" {
+				tg.mu.Lock()
+				tg.urls = append(tg.urls, session.Url())
+				tg.mu.Unlock()
+			}
+		case base.RtmpTypeIdWinAckSize:
+			_ = session.DoWinAckSize(stream)
+		case base.RtmpTypeIdAck:
+			_ = session.DoAck(stream)
+		case base.RtmpTypeIdUserControl:
+			_ = session.DoUserControl(stream)
+		case base.RtmpTypeIdAudio, base.RtmpTypeIdVideo, base.RtmpTypeIdMetadata:
+			tg.msgs <- stream.ToAvMsg().Clone()
+		}
+		return nil
+	})
+	session.Dispose()
+}
+
+// close stops accepting and drops every connected pusher
+func (tg *rtmpTarget) close() {
+	tg.listener.Close()
+	tg.mu.Lock()
+	defer tg.mu.Unlock()
+	for _, conn := range tg.conns {
+		conn.Close()
+	}
+	tg.conns = nil
+}
+
+func (tg *rtmpTarget) waitMsg(t *testing.T, match func(msg base.RtmpMsg) bool) base.RtmpMsg {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case msg := <-tg.msgs:
+			if match(msg) {
+				return msg
+			}
+		case <-deadline:
+			t.Fatal("timed out waiting for relayed message")
+			return base.RtmpMsg{}
+		}
+	}
+}
+
+// Baseline H.264 SPS/PPS of a 128x96 picture
+var (
+	testSps = []byte{0x67, 0x42, 0x00, 0x0a, 0xf8, 0x41, 0xa2}
+	testPps = []byte{0x68, 0xce, 0x38, 0x80}
+)
+
+func testVideoMsg(payload []byte, timestamp uint32) base.RtmpMsg {
+	return base.RtmpMsg{
+		Header: base.RtmpHeader{
+			Csid:         rtmp.CsidVideo,
+			MsgLen:       uint32(len(payload)),
+			MsgTypeId:    base.RtmpTypeIdVideo,
+			MsgStreamId:  rtmp.Msid1,
+			TimestampAbs: timestamp,
+		},
+		Payload: payload,
+	}
+}
+
+func testSeqHeader() base.RtmpMsg {
+	payload := []byte{0x17, 0x00, 0, 0, 0, 0x01, testSps[1], testSps[2], testSps[3], 0xff, 0xe1, 0x00, byte(len(testSps))}
+	payload = append(payload, testSps...)
+	payload = append(payload, 0x01, 0x00, byte(len(testPps)))
+	payload = append(payload, testPps...)
+	return testVideoMsg(payload, 0)
+}
+
+func testFrame(keyframe bool, timestamp uint32) base.RtmpMsg {
+	frameType := byte(0x27)
+	nalType := byte(0x41)
+	if keyframe {
+		frameType, nalType = 0x17, 0x65
+	}
+	return testVideoMsg([]byte{frameType, 0x01, 0, 0, 0, 0, 0, 0, 2, nalType, 0x88}, timestamp)
+}
+
+func waitRestreamState(t *testing.T, service *LivestreamService, uuid, targetID string, state livestreamEntity.RestreamState) livestreamEntity.RestreamStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if status, ok := service.GetRestreamStatus(uuid)[targetID]; ok && status.State == state {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("restream target %s never reached state %s: %+v", targetID, state, service.GetRestreamStatus(uuid))
+	return livestreamEntity.RestreamStatus{}
+}
+
+func TestRestream_RelaysAndReconnects(t *testing.T) {
+	target := startRTMPTarget(t, "127.0.0.1:0")
+	targetAddr := target.listener.Addr().String()
+
+	service := NewLivestreamService(nopLogger{}, "127.0.0.1:0", nil)
+	service.restreamMinBackoff = 20 * time.Millisecond
+	service.restreamMaxBackoff = 100 * time.Millisecond
+	var targetsLock sync.Mutex
+	targets := []livestreamEntity.RestreamTarget{
+		{ID: "target-1", URL: "rtmp://" + targetAddr + "/live/relay-key", Enabled: true},
+		{ID: "disabled", URL: "rtmp://" + targetAddr + "/live/other", Enabled: false},
+	}
+	service.SetRestreamTargetSource(func(uuid string) ([]livestreamEntity.RestreamTarget, error) {
+		targetsLock.Lock()
+		defer targetsLock.Unlock()
+		return targets, nil
+	})
+	addr := startTestServer(t, service)
+	service.OpenStream("stream", "uuid-1", "key-1", streamInterface.StreamOptions{})
+
+	push := rtmp.NewPushSession()
+	if err := push.Start("rtmp://" + addr + "/live/key-1"); err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+	defer push.Dispose()
+
+	waitRestreamState(t, service, "uuid-1", "target-1", livestreamEntity.RestreamStateLive)
+	if _, ok := service.GetRestreamStatus("uuid-1")["disabled"]; ok {
+		t.Fatal("disabled targets must not be relayed")
+	}
+
+	// Inter frames before the first keyframe are held back
+	for _, msg := range []base.RtmpMsg{testSeqHeader(), testFrame(false, 10), testFrame(true, 40), testFrame(false, 80)} {
+		if err := push.WriteMsg(msg); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	push.Flush()
+	if msg := target.waitMsg(t, func(msg base.RtmpMsg) bool { return msg.Header.MsgTypeId == base.RtmpTypeIdVideo }); !msg.IsAvcKeySeqHeader() {
+		t.Fatal("expected the sequence header to be relayed first")
+	}
+	if msg := target.waitMsg(t, func(msg base.RtmpMsg) bool { return true }); !msg.IsAvcKeyNalu() || msg.Header.TimestampAbs != 40 {
+		t.Fatalf("expected the keyframe next, got %s", msg.DebugString())
+	}
+
+	// Drop the target: the relay retries, then resumes with the cached
+	// sequence header once the target is back
+	target.close()
+	status := waitRestreamState(t, service, "uuid-1", "target-1", livestreamEntity.RestreamStateRetrying)
+	if status.Attempts < 1 || status.LastError == "" {
+		t.Fatalf("retrying status should carry the failure: %+v", status)
+	}
+	restarted := startRTMPTarget(t, targetAddr)
+	waitRestreamState(t, service, "uuid-1", "target-1", livestreamEntity.RestreamStateLive)
+	if msg := restarted.waitMsg(t, func(msg base.RtmpMsg) bool { return msg.Header.MsgTypeId == base.RtmpTypeIdVideo }); !msg.IsAvcKeySeqHeader() {
+		t.Fatal("expected the cached sequence header after reconnecting")
+	}
+
+	// Removing the target stops its relay while still live
+	targetsLock.Lock()
+	targets = nil
+	targetsLock.Unlock()
+	if err := service.RefreshRestreamTargets("uuid-1"); err != nil {
+		t.Fatalf("RefreshRestreamTargets failed: %v", err)
+	}
+	if statuses := service.GetRestreamStatus("uuid-1"); len(statuses) != 0 {
+		t.Fatalf("expected no relays after removing the target, got %+v", statuses)
+	}
+}
+
+func TestRestream_StopsWithPublisher(t *testing.T) {
+	target := startRTMPTarget(t, "127.0.0.1:0")
+	service := NewLivestreamService(nopLogger{}, "127.0.0.1:0", nil)
+	service.SetRestreamTargetSource(func(uuid string) ([]livestreamEntity.RestreamTarget, error) {
+		return []livestreamEntity.RestreamTarget{
+			{ID: "target-1", URL: "rtmp://" + target.listener.Addr().String() + "/live/relay-key", Enabled: true},
+		}, nil
+	})
+	addr := startTestServer(t, service)
+	service.OpenStream("stream", "uuid-1", "key-1", streamInterface.StreamOptions{})
+
+	push := rtmp.NewPushSession()
+	if err := push.Start("rtmp://" + addr + "/live/key-1"); err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+	waitRestreamState(t, service, "uuid-1", "target-1", livestreamEntity.RestreamStateLive)
+
+	push.Dispose()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(service.GetRestreamStatus("uuid-1")) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("relays must stop when the publisher disconnects")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Not being published, so there is nothing to refresh
+	if err := service.RefreshRestreamTargets("uuid-1"); err != nil {
+		t.Fatalf("RefreshRestreamTargets failed: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package livestream
+
+import (
+	streamInterface "Go-Service/src/main/application/interface/stream"
+	livestreamEntity "Go-Service/src/main/domain/entity/livestream"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cool9850311/lal-StreamPlatformLite/pkg/rtmp"
+)
+
+// selfSignedCert generates a certificate for 127.0.0.1 and a pool that
+// trusts it
+func selfSignedCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+func TestRTMPS_Publish(t *testing.T) {
+	cert, pool := selfSignedCert(t)
+	service := NewLivestreamService(nopLogger{}, "127.0.0.1:0", nil)
+	service.EnableTLS("127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	events := make(chan streamInterface.PublishEvent, 8)
+	service.OnPublishEvent(func(event streamInterface.PublishEvent) {
+		events <- event
+	})
+	startTestServer(t, service)
+	service.OpenStream("stream", "uuid-1", "key-1", streamInterface.StreamOptions{})
+	tlsAddr := service.tlsListener.Addr().String()
+
+	push := rtmp.NewPushSession(func(option *rtmp.PushSessionOption) {
+		option.TlsConfig = &tls.Config{RootCAs: pool}
+	})
+	if err := push.Start("rtmps://" + tlsAddr + "/live/key-1"); err != nil {
+		t.Fatalf("rtmps push failed: %v", err)
+	}
+	defer push.Dispose()
+
+	started := waitEvent(t, events)
+	if started.Type != streamInterface.PublishStarted || started.UUID != "uuid-1" {
+		t.Fatalf("unexpected start event: %+v", started)
+	}
+}
+
+func TestRTMPS_RejectsPlaintext(t *testing.T) {
+	cert, _ := selfSignedCert(t)
+	service := NewLivestreamService(nopLogger{}, "127.0.0.1:0", nil)
+	service.EnableTLS("127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	startTestServer(t, service)
+	service.OpenStream("stream", "uuid-1", "key-1", streamInterface.StreamOptions{})
+
+	// A plain RTMP handshake against the TLS port never gets to publish
+	push := rtmp.NewPushSession(func(option *rtmp.PushSessionOption) {
+		option.PushTimeoutMs = 1000
+	})
+	defer push.Dispose()
+	if err := push.Start("rtmp://" + service.tlsListener.Addr().String() + "/live/key-1"); err == nil {
+		t.Fatal("plaintext publish to the RTMPS listener must fail")
+	}
+	if status, _ := service.GetLiveStatus("uuid-1"); status.State != livestreamEntity.LiveStateIdle {
+		t.Fatalf("expected idle state, got %s", status.State)
+	}
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"Go-Service/src/main/application/interface/repository"
+	domainErrors "Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/infrastructure/repository/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PostgresBroadcastSessionRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresBroadcastSessionRepository(db *gorm.DB) repository.BroadcastSessionRepository {
+	return &PostgresBroadcastSessionRepository{db: db}
+}
+
+func (r *PostgresBroadcastSessionRepository) Create(session *livestream.BroadcastSession) error {
+	m := model.BroadcastSessionModel{
+		ID:             session.ID,
+		LivestreamUUID: session.LivestreamUUID,
+		StartedAt:      session.StartedAt,
+		EndedAt:        session.EndedAt,
+		PeakViewers:    session.PeakViewers,
+		PublisherIP:    session.PublisherIP,
+	}
+	return r.db.Create(&m).Error
+}
+
+func (r *PostgresBroadcastSessionRepository) End(id string, endedAt time.Time) error {
+	result := r.db.Model(&model.BroadcastSessionModel{}).
+		Where("id = ? AND ended_at IS NULL", id).
+		Update("ended_at", endedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domainErrors.ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresBroadcastSessionRepository) UpdatePeakViewers(livestreamUUID string, viewers int) error {
+	return r.db.Exec(
+		"UPDATE broadcast_sessions SET peak_viewers = ? WHERE livestream_uuid = ? AND ended_at IS NULL AND peak_viewers < ?",
+		viewers, livestreamUUID, viewers,
+	).Error
+}
+
+func (r *PostgresBroadcastSessionRepository) CloseOpen(endedAt time.Time) error {
+	return r.db.Model(&model.BroadcastSessionModel{}).
+		Where("ended_at IS NULL").
+		Update("ended_at", endedAt).Error
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"Go-Service/src/main/application/interface/repository"
+	domainErrors "Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/infrastructure/repository/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PostgresConversionJobRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresConversionJobRepository(db *gorm.DB) repository.ConversionJobRepository {
+	return &PostgresConversionJobRepository{db: db}
+}
+
+func toConversionJobEntity(m model.ConversionJobModel) *livestream.ConversionJob {
+	return &livestream.ConversionJob{
+		ID:             m.ID,
+		RecordingID:    m.RecordingID,
+		LivestreamUUID: m.LivestreamUUID,
+		Status:         livestream.ConversionStatus(m.Status),
+		Progress:       m.Progress,
+		Attempts:       m.Attempts,
+		NextAttemptAt:  m.NextAttemptAt,
+		LastError:      m.LastError,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+	}
+}
+
+func toConversionJobModel(job *livestream.ConversionJob) model.ConversionJobModel {
+	return model.ConversionJobModel{
+		ID:             job.ID,
+		RecordingID:    job.RecordingID,
+		LivestreamUUID: job.LivestreamUUID,
+		Status:         string(job.Status),
+		Progress:       job.Progress,
+		Attempts:       job.Attempts,
+		NextAttemptAt:  job.NextAttemptAt,
+		LastError:      job.LastError,
+		CreatedAt:      job.CreatedAt,
+		UpdatedAt:      job.UpdatedAt,
+	}
+}
+
+func (r *PostgresConversionJobRepository) GetByRecordingID(recordingID string) (*livestream.ConversionJob, error) {
+	var m model.ConversionJobModel
+	result := r.db.Where("recording_id = ?", recordingID).First(&m)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, domainErrors.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return toConversionJobEntity(m), nil
+}
+
+func (r *PostgresConversionJobRepository) Enqueue(job *livestream.ConversionJob) error {
+	return r.db.Exec(`
+		INSERT INTO conversion_jobs (id, recording_id, livestream_uuid, status, progress, attempts, next_attempt_at, last_error, created_at, updated_at)
+		VALUES (?, ?, ?, ?, 0, 0, ?, '', ?, ?)
+		ON CONFLICT (recording_id) DO UPDATE
+		SET status = EXCLUDED.status, progress = 0, attempts = 0, next_attempt_at = EXCLUDED.next_attempt_at,
+			last_error = '', updated_at = EXCLUDED.updated_at
+		WHERE conversion_jobs.status IN (?, ?)`,
+		job.ID, job.RecordingID, job.LivestreamUUID, string(livestream.ConversionStatusQueued), job.NextAttemptAt, job.CreatedAt, job.UpdatedAt,
+		string(livestream.ConversionStatusDone), string(livestream.ConversionStatusFailed),
+	).Error
+}
+
+func (r *PostgresConversionJobRepository) Claim(now, lockedUntil time.Time) (*livestream.ConversionJob, error) {
+	// SKIP LOCKED lets every worker claim a different job without waiting
+	var models []model.ConversionJobModel
+	err := r.db.Raw(`
+		UPDATE conversion_jobs
+		SET status = ?, progress = 0, attempts = attempts + 1, locked_until = ?, updated_at = ?
+		WHERE id = (
+			SELECT id FROM conversion_jobs
+			WHERE (status = ? AND next_attempt_at <= ?) OR (status = ? AND locked_until <= ?)
+			ORDER BY next_attempt_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		string(livestream.ConversionStatusRunning), lockedUntil, now,
+		string(livestream.ConversionStatusQueued), now, string(livestream.ConversionStatusRunning), now,
+	).Scan(&models).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(models) == 0 {
+		return nil, domainErrors.ErrNotFound
+	}
+	return toConversionJobEntity(models[0]), nil
+}
+
+// held matches the running attempt a worker claimed
+func (r *PostgresConversionJobRepository) held(id string, attempt int) *gorm.DB {
+	return r.db.Model(&model.ConversionJobModel{}).
+		Where("id = ? AND status = ? AND attempts = ?", id, string(livestream.ConversionStatusRunning), attempt)
+}
+
+// affected maps an update of a held attempt to ErrNotFound once it was lost
+func affected(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domainErrors.ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresConversionJobRepository) RenewLease(id string, attempt int, lockedUntil time.Time) error {
+	return affected(r.held(id, attempt).Update("locked_until", lockedUntil))
+}
+
+func (r *PostgresConversionJobRepository) UpdateProgress(id string, progress float64) error {
+	return r.db.Model(&model.ConversionJobModel{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"progress": progress, "updated_at": time.Now()}).Error
+}
+
+func (r *PostgresConversionJobRepository) Update(job *livestream.ConversionJob) error {
+	// The model leaves locked_until nil, ending the lease
+	m := toConversionJobModel(job)
+	return affected(r.held(job.ID, job.Attempts).
+		Select("status", "progress", "next_attempt_at", "last_error", "locked_until", "updated_at").
+		Updates(&m))
+}
+
+func (r *PostgresConversionJobRepository) Release(id string, attempt int, now time.Time) error {
+	return affected(r.held(id, attempt).
+		Updates(map[string]interface{}{
+			"status":          string(livestream.ConversionStatusQueued),
+			"progress":        0,
+			"attempts":        gorm.Expr("attempts - 1"),
+			"next_attempt_at": now,
+			"locked_until":    nil,
+			"updated_at":      now,
+		}))
+}
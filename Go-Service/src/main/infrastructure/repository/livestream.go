@@ -21,19 +21,34 @@ func NewPostgresLivestreamRepository(db *gorm.DB) repository.LivestreamRepositor
 
 func toLivestreamEntity(m model.LivestreamModel) *livestream.Livestream {
 	return &livestream.Livestream{
-		UUID:        m.UUID,
-		Name:        m.Name,
-		APIKey:      m.APIKey,
-		OwnerUserId: m.OwnerUserID,
-		Visibility:  livestream.Visibility(m.Visibility),
-		Title:       m.Title,
-		Information: m.Information,
-		BanList:     []string(m.BanList),
-		MuteList:    []string(m.MuteList),
-		IsRecord:    m.IsRecord,
+		UUID:               m.UUID,
+		Name:               m.Name,
+		APIKey:             m.APIKey,
+		OwnerUserId:        m.OwnerUserID,
+		Visibility:         livestream.Visibility(m.Visibility),
+		Title:              m.Title,
+		Information:        m.Information,
+		BanList:            []string(m.BanList),
+		MuteList:           []string(m.MuteList),
+		IsRecord:           m.IsRecord,
+		LowLatency:         m.LowLatency,
+		Renditions:         toRenditions(m.Renditions),
+		FragmentDurationMs: m.FragmentDurationMs,
+		FragmentNum:        m.FragmentNum,
+		CleanupMode:        livestream.CleanupMode(m.CleanupMode),
+		DVRWindowSeconds:   m.DVRWindowSeconds,
+		Encrypted:          m.Encrypted,
 	}
 }
 
+func toRenditions(values pq.StringArray) []livestream.Rendition {
+	renditions := make([]livestream.Rendition, 0, len(values))
+	for _, value := range values {
+		renditions = append(renditions, livestream.Rendition(value))
+	}
+	return renditions
+}
+
 func toModel(ls *livestream.Livestream) model.LivestreamModel {
 	banList := ls.BanList
 	if banList == nil {
@@ -43,17 +58,28 @@ func toModel(ls *livestream.Livestream) model.LivestreamModel {
 	if muteList == nil {
 		muteList = []string{}
 	}
+	renditions := make([]string, 0, len(ls.Renditions))
+	for _, rendition := range ls.Renditions {
+		renditions = append(renditions, string(rendition))
+	}
 	return model.LivestreamModel{
-		UUID:        ls.UUID,
-		Name:        ls.Name,
-		APIKey:      ls.APIKey,
-		OwnerUserID: ls.OwnerUserId,
-		Visibility:  string(ls.Visibility),
-		Title:       ls.Title,
-		Information: ls.Information,
-		BanList:     pq.StringArray(banList),
-		MuteList:    pq.StringArray(muteList),
-		IsRecord:    ls.IsRecord,
+		UUID:               ls.UUID,
+		Name:               ls.Name,
+		APIKey:             ls.APIKey,
+		OwnerUserID:        ls.OwnerUserId,
+		Visibility:         string(ls.Visibility),
+		Title:              ls.Title,
+		Information:        ls.Information,
+		BanList:            pq.StringArray(banList),
+		MuteList:           pq.StringArray(muteList),
+		IsRecord:           ls.IsRecord,
+		LowLatency:         ls.LowLatency,
+		Renditions:         pq.StringArray(renditions),
+		FragmentDurationMs: ls.FragmentDurationMs,
+		FragmentNum:        ls.FragmentNum,
+		CleanupMode:        string(ls.CleanupMode),
+		DVRWindowSeconds:   ls.DVRWindowSeconds,
+		Encrypted:          ls.Encrypted,
 	}
 }
 
@@ -81,16 +107,17 @@ func (r *PostgresLivestreamRepository) GetByOwnerID(ownerID string) (*livestream
 	return toLivestreamEntity(m), nil
 }
 
-func (r *PostgresLivestreamRepository) GetOne() (*livestream.Livestream, error) {
-	var m model.LivestreamModel
-	result := r.db.First(&m)
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return nil, domainErrors.ErrNotFound
-	}
+func (r *PostgresLivestreamRepository) List() ([]*livestream.Livestream, error) {
+	var models []model.LivestreamModel
+	result := r.db.Order("name").Find(&models)
 	if result.Error != nil {
 		return nil, result.Error
 	}
-	return toLivestreamEntity(m), nil
+	livestreams := make([]*livestream.Livestream, 0, len(models))
+	for _, m := range models {
+		livestreams = append(livestreams, toLivestreamEntity(m))
+	}
+	return livestreams, nil
 }
 
 func (r *PostgresLivestreamRepository) Create(ls *livestream.Livestream) error {
@@ -98,9 +125,25 @@ func (r *PostgresLivestreamRepository) Create(ls *livestream.Livestream) error {
 	return r.db.Create(&m).Error
 }
 
+// Update saves the editable fields of a livestream. The API key and owner are
+// never touched here; the key only changes through UpdateAPIKey.
 func (r *PostgresLivestreamRepository) Update(ls *livestream.Livestream) error {
 	m := toModel(ls)
-	return r.db.Where("uuid = ?", ls.UUID).Save(&m).Error
+	return r.db.Model(&model.LivestreamModel{}).
+		Where("uuid = ?", ls.UUID).
+		Select("name", "visibility", "title", "information", "ban_list", "mute_list", "is_record", "low_latency", "renditions", "fragment_duration_ms", "fragment_num", "cleanup_mode", "dvr_window_seconds", "encrypted").
+		Updates(&m).Error
+}
+
+func (r *PostgresLivestreamRepository) UpdateAPIKey(id string, apiKey string) error {
+	result := r.db.Model(&model.LivestreamModel{}).Where("uuid = ?", id).Update("api_key", apiKey)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domainErrors.ErrNotFound
+	}
+	return nil
 }
 
 func (r *PostgresLivestreamRepository) Delete(id string) error {
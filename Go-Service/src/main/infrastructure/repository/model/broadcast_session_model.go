@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+type BroadcastSessionModel struct {
+	ID             string     `gorm:"primaryKey"`
+	LivestreamUUID string     `gorm:"column:livestream_uuid;not null"`
+	StartedAt      time.Time  `gorm:"not null"`
+	EndedAt        *time.Time `gorm:"column:ended_at"`
+	PeakViewers    int        `gorm:"not null;default:0"`
+	PublisherIP    string     `gorm:"column:publisher_ip;not null;default:''"`
+}
+
+func (BroadcastSessionModel) TableName() string { return "broadcast_sessions" }
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+type ConversionJobModel struct {
+	ID             string     `gorm:"primaryKey"`
+	RecordingID    string     `gorm:"column:recording_id;not null;uniqueIndex"`
+	LivestreamUUID string     `gorm:"column:livestream_uuid;not null"`
+	Status         string     `gorm:"not null"`
+	Progress       float64    `gorm:"not null;default:0"`
+	Attempts       int        `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time  `gorm:"column:next_attempt_at;not null"`
+	LockedUntil    *time.Time `gorm:"column:locked_until"`
+	LastError      string     `gorm:"column:last_error;not null;default:''"`
+	CreatedAt      time.Time  `gorm:"not null"`
+	UpdatedAt      time.Time  `gorm:"not null"`
+}
+
+func (ConversionJobModel) TableName() string { return "conversion_jobs" }
@@ -3,16 +3,23 @@ package model
 import "github.com/lib/pq"
 
 type LivestreamModel struct {
-	UUID        string         `gorm:"primaryKey"`
-	Name        string         `gorm:"not null"`
-	APIKey      string         `gorm:"column:api_key;not null"`
-	OwnerUserID string         `gorm:"column:owner_user_id;not null"`
-	Visibility  string         `gorm:"not null"`
-	Title       string         `gorm:"not null;default:''"`
-	Information string         `gorm:"not null;default:''"`
-	BanList     pq.StringArray `gorm:"type:text[];not null;default:'{}'"`
-	MuteList    pq.StringArray `gorm:"type:text[];not null;default:'{}'"`
-	IsRecord    bool           `gorm:"column:is_record;not null;default:false"`
+	UUID               string         `gorm:"primaryKey"`
+	Name               string         `gorm:"not null"`
+	APIKey             string         `gorm:"column:api_key;not null"`
+	OwnerUserID        string         `gorm:"column:owner_user_id;not null"`
+	Visibility         string         `gorm:"not null"`
+	Title              string         `gorm:"not null;default:''"`
+	Information        string         `gorm:"not null;default:''"`
+	BanList            pq.StringArray `gorm:"type:text[];not null;default:'{}'"`
+	MuteList           pq.StringArray `gorm:"type:text[];not null;default:'{}'"`
+	IsRecord           bool           `gorm:"column:is_record;not null;default:false"`
+	LowLatency         bool           `gorm:"column:low_latency;not null;default:false"`
+	Renditions         pq.StringArray `gorm:"type:text[];not null;default:'{}'"`
+	FragmentDurationMs int            `gorm:"column:fragment_duration_ms;not null;default:500"`
+	FragmentNum        int            `gorm:"column:fragment_num;not null;default:5"`
+	CleanupMode        string         `gorm:"column:cleanup_mode;not null;default:'asap'"`
+	DVRWindowSeconds   int            `gorm:"column:dvr_window_seconds;not null;default:0"`
+	Encrypted          bool           `gorm:"column:encrypted;not null;default:false"`
 }
 
 func (LivestreamModel) TableName() string { return "livestreams" }
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+type RecordingModel struct {
+	ID              string     `gorm:"primaryKey"`
+	LivestreamUUID  string     `gorm:"column:livestream_uuid;not null"`
+	StartedAt       time.Time  `gorm:"not null"`
+	EndedAt         *time.Time `gorm:"column:ended_at"`
+	DurationSeconds float64    `gorm:"column:duration_seconds;not null;default:0"`
+	SizeBytes       int64      `gorm:"column:size_bytes;not null;default:0"`
+	Status          string     `gorm:"not null"`
+	Visibility      string     `gorm:"not null;default:private"`
+}
+
+func (RecordingModel) TableName() string { return "recordings" }
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+type RestreamTargetModel struct {
+	ID             string    `gorm:"primaryKey"`
+	LivestreamUUID string    `gorm:"column:livestream_uuid;not null"`
+	Name           string    `gorm:"not null;default:''"`
+	URL            string    `gorm:"column:url;not null"`
+	Enabled        bool      `gorm:"not null;default:true"`
+	CreatedAt      time.Time `gorm:"not null"`
+}
+
+func (RestreamTargetModel) TableName() string { return "restream_targets" }
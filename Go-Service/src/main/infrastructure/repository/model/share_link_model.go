@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type ShareLinkModel struct {
+	ID             string    `gorm:"primaryKey"`
+	LivestreamUUID string    `gorm:"column:livestream_uuid;not null"`
+	Name           string    `gorm:"not null;default:''"`
+	ExpiresAt      time.Time `gorm:"not null"`
+	CreatedAt      time.Time `gorm:"not null"`
+}
+
+func (ShareLinkModel) TableName() string { return "share_links" }
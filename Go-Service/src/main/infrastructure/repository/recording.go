@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"Go-Service/src/main/application/interface/repository"
+	domainErrors "Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/infrastructure/repository/model"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type PostgresRecordingRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresRecordingRepository(db *gorm.DB) repository.RecordingRepository {
+	return &PostgresRecordingRepository{db: db}
+}
+
+func toRecordingEntity(m model.RecordingModel) *livestream.Recording {
+	return &livestream.Recording{
+		ID:              m.ID,
+		LivestreamUUID:  m.LivestreamUUID,
+		StartedAt:       m.StartedAt,
+		EndedAt:         m.EndedAt,
+		DurationSeconds: m.DurationSeconds,
+		SizeBytes:       m.SizeBytes,
+		Status:          livestream.RecordingStatus(m.Status),
+		Visibility:      livestream.Visibility(m.Visibility),
+	}
+}
+
+func toRecordingModel(recording *livestream.Recording) model.RecordingModel {
+	return model.RecordingModel{
+		ID:              recording.ID,
+		LivestreamUUID:  recording.LivestreamUUID,
+		StartedAt:       recording.StartedAt,
+		EndedAt:         recording.EndedAt,
+		DurationSeconds: recording.DurationSeconds,
+		SizeBytes:       recording.SizeBytes,
+		Status:          string(recording.Status),
+		Visibility:      string(recording.Visibility),
+	}
+}
+
+func (r *PostgresRecordingRepository) find(query *gorm.DB) ([]*livestream.Recording, error) {
+	var models []model.RecordingModel
+	if err := query.Order("started_at DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+	recordings := make([]*livestream.Recording, 0, len(models))
+	for _, m := range models {
+		recordings = append(recordings, toRecordingEntity(m))
+	}
+	return recordings, nil
+}
+
+func (r *PostgresRecordingRepository) GetByID(id string) (*livestream.Recording, error) {
+	var m model.RecordingModel
+	result := r.db.Where("id = ?", id).First(&m)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, domainErrors.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return toRecordingEntity(m), nil
+}
+
+func (r *PostgresRecordingRepository) ListByLivestream(livestreamUUID string) ([]*livestream.Recording, error) {
+	return r.find(r.db.Where("livestream_uuid = ?", livestreamUUID))
+}
+
+func (r *PostgresRecordingRepository) ListByStatus(status livestream.RecordingStatus) ([]*livestream.Recording, error) {
+	return r.find(r.db.Where("status = ?", string(status)))
+}
+
+func (r *PostgresRecordingRepository) Create(recording *livestream.Recording) error {
+	m := toRecordingModel(recording)
+	return r.db.Create(&m).Error
+}
+
+func (r *PostgresRecordingRepository) Update(recording *livestream.Recording) error {
+	m := toRecordingModel(recording)
+	result := r.db.Model(&model.RecordingModel{}).
+		Where("id = ?", recording.ID).
+		Select("ended_at", "duration_seconds", "size_bytes", "status").
+		Updates(&m)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domainErrors.ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRecordingRepository) UpdateVisibility(id string, visibility livestream.Visibility) error {
+	result := r.db.Model(&model.RecordingModel{}).
+		Where("id = ?", id).
+		Update("visibility", string(visibility))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domainErrors.ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRecordingRepository) Delete(id string) error {
+	return r.db.Where("id = ?", id).Delete(&model.RecordingModel{}).Error
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"Go-Service/src/main/application/interface/repository"
+	domainErrors "Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/infrastructure/repository/model"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type PostgresRestreamTargetRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresRestreamTargetRepository(db *gorm.DB) repository.RestreamTargetRepository {
+	return &PostgresRestreamTargetRepository{db: db}
+}
+
+func toRestreamTargetEntity(m model.RestreamTargetModel) *livestream.RestreamTarget {
+	return &livestream.RestreamTarget{
+		ID:             m.ID,
+		LivestreamUUID: m.LivestreamUUID,
+		Name:           m.Name,
+		URL:            m.URL,
+		Enabled:        m.Enabled,
+		CreatedAt:      m.CreatedAt,
+	}
+}
+
+func (r *PostgresRestreamTargetRepository) GetByID(id string) (*livestream.RestreamTarget, error) {
+	var m model.RestreamTargetModel
+	result := r.db.Where("id = ?", id).First(&m)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, domainErrors.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return toRestreamTargetEntity(m), nil
+}
+
+func (r *PostgresRestreamTargetRepository) ListByLivestream(livestreamUUID string) ([]*livestream.RestreamTarget, error) {
+	var models []model.RestreamTargetModel
+	result := r.db.Where("livestream_uuid = ?", livestreamUUID).Order("created_at").Find(&models)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	targets := make([]*livestream.RestreamTarget, 0, len(models))
+	for _, m := range models {
+		targets = append(targets, toRestreamTargetEntity(m))
+	}
+	return targets, nil
+}
+
+func (r *PostgresRestreamTargetRepository) Create(target *livestream.RestreamTarget) error {
+	m := model.RestreamTargetModel{
+		ID:             target.ID,
+		LivestreamUUID: target.LivestreamUUID,
+		Name:           target.Name,
+		URL:            target.URL,
+		Enabled:        target.Enabled,
+		CreatedAt:      target.CreatedAt,
+	}
+	return r.db.Create(&m).Error
+}
+
+func (r *PostgresRestreamTargetRepository) Update(target *livestream.RestreamTarget) error {
+	result := r.db.Model(&model.RestreamTargetModel{}).
+		Where("id = ?", target.ID).
+		Select("name", "url", "enabled").
+		Updates(&model.RestreamTargetModel{Name: target.Name, URL: target.URL, Enabled: target.Enabled})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domainErrors.ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRestreamTargetRepository) Delete(id string) error {
+	return r.db.Where("id = ?", id).Delete(&model.RestreamTargetModel{}).Error
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"Go-Service/src/main/application/interface/repository"
+	domainErrors "Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/infrastructure/repository/model"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type PostgresShareLinkRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresShareLinkRepository(db *gorm.DB) repository.ShareLinkRepository {
+	return &PostgresShareLinkRepository{db: db}
+}
+
+func toShareLinkEntity(m model.ShareLinkModel) *livestream.ShareLink {
+	return &livestream.ShareLink{
+		ID:             m.ID,
+		LivestreamUUID: m.LivestreamUUID,
+		Name:           m.Name,
+		ExpiresAt:      m.ExpiresAt,
+		CreatedAt:      m.CreatedAt,
+	}
+}
+
+func (r *PostgresShareLinkRepository) GetByID(id string) (*livestream.ShareLink, error) {
+	var m model.ShareLinkModel
+	result := r.db.Where("id = ?", id).First(&m)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, domainErrors.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return toShareLinkEntity(m), nil
+}
+
+func (r *PostgresShareLinkRepository) ListByLivestream(livestreamUUID string) ([]*livestream.ShareLink, error) {
+	var models []model.ShareLinkModel
+	result := r.db.Where("livestream_uuid = ?", livestreamUUID).Order("created_at").Find(&models)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	links := make([]*livestream.ShareLink, 0, len(models))
+	for _, m := range models {
+		links = append(links, toShareLinkEntity(m))
+	}
+	return links, nil
+}
+
+func (r *PostgresShareLinkRepository) Create(link *livestream.ShareLink) error {
+	m := model.ShareLinkModel{
+		ID:             link.ID,
+		LivestreamUUID: link.LivestreamUUID,
+		Name:           link.Name,
+		ExpiresAt:      link.ExpiresAt,
+		CreatedAt:      link.CreatedAt,
+	}
+	return r.db.Create(&m).Error
+}
+
+func (r *PostgresShareLinkRepository) Delete(id string) error {
+	return r.db.Where("id = ?", id).Delete(&model.ShareLinkModel{}).Error
+}
@@ -12,8 +12,10 @@ import (
 	"Go-Service/src/main/infrastructure/middleware"
 	"Go-Service/src/main/infrastructure/repository"
 	"Go-Service/src/main/infrastructure/util"
+	"context"
 	"fmt"
 	"net/http"
+	"path/filepath"
 
 	// "github.com/gin-contrib/cors"
 	"github.com/gin-contrib/cors"
@@ -49,9 +51,9 @@ func setupMiddlewares(r *gin.Engine) {
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     allowedOrigins,
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-XSRF-TOKEN"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "Cache-Control", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
+		AllowMethods:     []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-XSRF-TOKEN", "Range", "If-Range"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "Cache-Control", "Content-Range", "Accept-Ranges", "ETag", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
 		AllowCredentials: true,
 	}))
 
@@ -70,10 +72,29 @@ func setupRoutes(r *gin.Engine, db *gorm.DB, log logger.Logger, liveStreamServic
 	livestreamRepo := repository.NewPostgresLivestreamRepository(db)
 	viewerCountCache := cache.NewRedisViewerCount(redisClient)
 	chatCache := cache.NewRedisChat(redisClient)
-	fileCache := cache.NewFileCache()
+	fileCache := cache.NewFileCache(config.AppConfig.Cache.HLSMaxBytes)
 	ffmpegLibrary := util.NewFfmpegLibrary()
-	livestreamUseCase := usecase.NewLivestreamUsecase(livestreamRepo, log, config.AppConfig, liveStreamService, viewerCountCache, chatCache, fileCache, ffmpegLibrary)
+	shareLinkRepo := repository.NewPostgresShareLinkRepository(db)
+	rootPath, err := util.GetProjectRootPath()
+	if err != nil {
+		log.Fatal(context.TODO(), "Failed to get project root path: "+err.Error())
+	}
+	hlsKeyStore := util.NewFileHLSKeyStore(filepath.Join(rootPath, "hls"))
+	recordingRepo := repository.NewPostgresRecordingRepository(db)
+	conversionJobRepo := repository.NewPostgresConversionJobRepository(db)
+	livestreamUseCase := usecase.NewLivestreamUsecase(livestreamRepo, log, config.AppConfig, liveStreamService, viewerCountCache, chatCache, fileCache, shareLinkRepo, jwtGenerator, hlsKeyStore, recordingRepo, conversionJobRepo)
+	liveStreamService.OnPlaylistUpdate(livestreamUseCase.HandlePlaylistUpdate)
 	livestreamController := controller.NewLivestreamController(log, livestreamUseCase, jwtGenerator)
+	restreamTargetRepo := repository.NewPostgresRestreamTargetRepository(db)
+	restreamUseCase := usecase.NewRestreamUsecase(restreamTargetRepo, livestreamRepo, log, liveStreamService)
+	restreamController := controller.NewRestreamController(log, restreamUseCase)
+	shareLinkUseCase := usecase.NewShareLinkUsecase(shareLinkRepo, livestreamRepo, log, config.AppConfig, jwtGenerator)
+	shareLinkController := controller.NewShareLinkController(log, shareLinkUseCase)
+	thumbnailStore := util.NewFileThumbnailStore(filepath.Join(rootPath, "hls"))
+	thumbnailUseCase := usecase.NewThumbnailUsecase(livestreamRepo, log, config.AppConfig, liveStreamService, ffmpegLibrary, thumbnailStore, shareLinkRepo, jwtGenerator)
+	thumbnailController := controller.NewThumbnailController(log, thumbnailUseCase)
+	recordingUseCase := usecase.NewRecordingUsecase(recordingRepo, livestreamRepo, conversionJobRepo, log, util.NewFileRecordingStore(filepath.Join(rootPath, "hls")))
+	recordingController := controller.NewRecordingController(log, recordingUseCase)
 
 	// Health check — public, no auth, used by Docker HEALTHCHECK
 	r.GET("/health", func(c *gin.Context) {
@@ -83,17 +104,46 @@ func setupRoutes(r *gin.Engine, db *gorm.DB, log logger.Logger, liveStreamServic
 	livestream := r.Group("/livestream")
 	{
 		// 观看相关端点：使用OptionalJWT中间件（允许匿名访问public直播）
+		livestream.GET("/:uuid/live.flv", middleware.OptionalJWTAuthMiddleware(log), livestreamController.GetLiveFLV)
+		livestream.GET("/:uuid/thumbnail.jpg", middleware.OptionalJWTAuthMiddleware(log), thumbnailController.GetThumbnail)
+		livestream.GET("/:uuid/key/:key_id", middleware.OptionalJWTAuthMiddleware(log), livestreamController.GetKey)
 		livestream.GET("/:uuid/:filename", middleware.OptionalJWTAuthMiddleware(log), livestreamController.GetFile)
+		// Rendition files; gin needs the rendition directory to reuse the :filename wildcard name
+		livestream.GET("/:uuid/:filename/:segment", middleware.OptionalJWTAuthMiddleware(log), livestreamController.GetFile)
+		livestream.GET("/list", middleware.OptionalJWTAuthMiddleware(log), livestreamController.ListLivestreams)
+		// Deprecated: /one returns the first livestream, use /one/:uuid
 		livestream.GET("/one", middleware.OptionalJWTAuthMiddleware(log), livestreamController.GetLivestreamOne)
+		livestream.GET("/one/:uuid", middleware.OptionalJWTAuthMiddleware(log), livestreamController.GetLivestreamOne)
 		livestream.GET("/:uuid", middleware.OptionalJWTAuthMiddleware(log), livestreamController.GetLivestreamByID)
 		livestream.GET("/ping-viewer-count/:uuid", middleware.OptionalJWTAuthMiddleware(log), livestreamController.PingViewerCount)
+		livestream.GET("/:uuid/vod", middleware.OptionalJWTAuthMiddleware(log), recordingController.ListVODs)
 
 		// 管理端点：保持强制JWT（需要Admin权限）
 		livestream.POST("", middleware.JWTAuthMiddleware(log), livestreamController.CreateLivestream)
 		livestream.PATCH("/:uuid", middleware.JWTAuthMiddleware(log), livestreamController.UpdateLivestream)
 		livestream.DELETE("/:uuid", middleware.JWTAuthMiddleware(log), livestreamController.DeleteLivestream)
+		livestream.POST("/:uuid/stream-key", middleware.JWTAuthMiddleware(log), livestreamController.RotateStreamKey)
+		livestream.DELETE("/:uuid/stream-key", middleware.JWTAuthMiddleware(log), livestreamController.RevokeStreamKey)
+		livestream.POST("/:uuid/kick", middleware.JWTAuthMiddleware(log), livestreamController.KickPublisher)
+		livestream.GET("/:uuid/restream-targets", middleware.JWTAuthMiddleware(log), restreamController.ListTargets)
+		livestream.POST("/:uuid/restream-targets", middleware.JWTAuthMiddleware(log), restreamController.CreateTarget)
+		livestream.PUT("/:uuid/restream-targets/:target_id", middleware.JWTAuthMiddleware(log), restreamController.UpdateTarget)
+		livestream.DELETE("/:uuid/restream-targets/:target_id", middleware.JWTAuthMiddleware(log), restreamController.DeleteTarget)
+		livestream.GET("/:uuid/share-links", middleware.JWTAuthMiddleware(log), shareLinkController.ListShareLinks)
+		livestream.POST("/:uuid/share-links", middleware.JWTAuthMiddleware(log), shareLinkController.CreateShareLink)
+		livestream.DELETE("/:uuid/share-links/:link_id", middleware.JWTAuthMiddleware(log), shareLinkController.RevokeShareLink)
+		livestream.GET("/:uuid/thumbnails", middleware.JWTAuthMiddleware(log), thumbnailController.ListThumbnails)
+		livestream.GET("/:uuid/thumbnails/:thumbnail_id", middleware.JWTAuthMiddleware(log), thumbnailController.GetThumbnailByID)
+		livestream.PUT("/:uuid/poster", middleware.JWTAuthMiddleware(log), thumbnailController.SetPoster)
+		livestream.GET("/:uuid/recordings", middleware.JWTAuthMiddleware(log), recordingController.ListRecordings)
+		livestream.GET("/:uuid/recordings/:recording_id", middleware.JWTAuthMiddleware(log), recordingController.GetRecording)
+		livestream.DELETE("/:uuid/recordings/:recording_id", middleware.JWTAuthMiddleware(log), recordingController.DeleteRecording)
+		livestream.PUT("/:uuid/recordings/:recording_id/visibility", middleware.JWTAuthMiddleware(log), recordingController.UpdateVisibility)
+		livestream.GET("/cache-stats", middleware.JWTAuthMiddleware(log), livestreamController.GetCacheStats)
 		livestream.GET("/owner/:user_id", middleware.JWTAuthMiddleware(log), livestreamController.GetLivestreamByOwnerId)
 		livestream.GET("/record/:uuid", middleware.JWTAuthMiddleware(log), livestreamController.GetRecord)
+		livestream.HEAD("/record/:uuid", middleware.JWTAuthMiddleware(log), livestreamController.GetRecord)
+		livestream.GET("/record/:uuid/status", middleware.JWTAuthMiddleware(log), livestreamController.GetRecordStatus)
 
 		chat := livestream.Group("/chat")
 		{
@@ -109,4 +159,11 @@ func setupRoutes(r *gin.Engine, db *gorm.DB, log logger.Logger, liveStreamServic
 		// 禁言功能：需要强制JWT
 		livestream.POST("/mute-user", middleware.JWTAuthMiddleware(log), livestreamController.MuteUser)
 	}
+
+	// 录制回放：按录制自身的Visibility决定是否允许匿名观看
+	vod := r.Group("/vod")
+	{
+		vod.GET("/:recording_id/:filename", middleware.OptionalJWTAuthMiddleware(log), recordingController.GetVODFile)
+		vod.HEAD("/:recording_id/:filename", middleware.OptionalJWTAuthMiddleware(log), recordingController.GetVODFile)
+	}
 }
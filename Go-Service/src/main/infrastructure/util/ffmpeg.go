@@ -1,27 +1,387 @@
 package util
 
 import (
+	domainErrors "Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/domain/interface/libarary/ffmpeg"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
-type FfmpegLibrary struct{}
+const (
+	transcodeMinBackoff = time.Second
+	transcodeMaxBackoff = 30 * time.Second
+	// transcodeHealthyRun is how long ffmpeg must have run for a failure to
+	// restart it without backing off further
+	transcodeHealthyRun = 30 * time.Second
+	// transcodeWaitDelay bounds how long a dead ffmpeg waits for its stdin
+	// copy to stop
+	transcodeWaitDelay    = time.Second
+	transcodeSegmentTime  = 2
+	transcodeListSize     = 6
+	transcodeMasterName   = "master.m3u8"
+	transcodePlaylistName = "playlist.m3u8"
+	// thumbnailTimeout bounds one snapshot so a broken segment cannot stall
+	// the capture job
+	thumbnailTimeout = 10 * time.Second
+	thumbnailHeight  = 360
+	// probeTimeout bounds the check of a converted MP4
+	probeTimeout = 30 * time.Second
+	// probeMinDurationSlack is how far, at least, the duration of an MP4 may
+	// be off the recording's; otherwise 5% of it
+	probeMinDurationSlack = 2.0
+)
+
+type FfmpegLibrary struct {
+	// binary and probeBinary are the ffmpeg and ffprobe executables; tests
+	// point them at fakes
+	binary      string
+	probeBinary string
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+}
 
 func NewFfmpegLibrary() *FfmpegLibrary {
-	return &FfmpegLibrary{}
+	return &FfmpegLibrary{
+		binary:      "ffmpeg",
+		probeBinary: "ffprobe",
+		minBackoff:  transcodeMinBackoff,
+		maxBackoff:  transcodeMaxBackoff,
+	}
 }
 
-func (f *FfmpegLibrary) ConvertStreamToMp4(filePath string, fileName string) error {
-	// Get directory path from filePath
-	dir := filepath.Dir(filePath)
+// ConvertToMp4 copies the streams of a recorded playlist into an MP4. It is
+// written next to OutputPath first and only renamed once ffprobe finds it
+// playable, so a half written or broken file is never served. Progress comes
+// from ffmpeg's -progress output.
+func (f *FfmpegLibrary) ConvertToMp4(ctx context.Context, job ffmpeg.ConvertJob) error {
+	partPath := job.OutputPath + ".part"
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, f.binary,
+		"-hide_banner", "-loglevel", "error", "-nostats", "-y",
+		"-i", job.PlaylistPath,
+		"-c", "copy", "-bsf:a", "aac_adtstoasc",
+		"-f", "mp4",
+		"-progress", "pipe:1",
+		partPath,
+	)
+	cmd.Dir = filepath.Dir(job.PlaylistPath)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		seconds, ok := parseProgressLine(scanner.Text())
+		if !ok || job.OnProgress == nil || job.DurationSeconds <= 0 {
+			continue
+		}
+		job.OnProgress(min(seconds/job.DurationSeconds, 1))
+	}
+	if err := cmd.Wait(); err != nil {
+		os.Remove(partPath)
+		if stderr.Len() > 0 {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return err
+	}
+	if err := f.validateMp4(ctx, partPath, job.DurationSeconds); err != nil {
+		os.Remove(partPath)
+		return err
+	}
+	return os.Rename(partPath, job.OutputPath)
+}
 
-	// Create command and set working directory
-	cmd := exec.Command("ffmpeg", "-i", filePath, "-c", "copy", "-bsf:a", "aac_adtstoasc", fileName+".mp4")
-	cmd.Dir = dir
+// probeResult is the part of ffprobe's JSON output validateMp4 looks at
+type probeResult struct {
+	Streams []struct {
+		CodecType string `json:"codec_type"`
+	} `json:"streams"`
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+}
 
-	err := cmd.Run()
-	if err != nil {
+// validateMp4 checks with ffprobe that an MP4 has audio or video and lasts
+// about as long as the recording it was converted from
+func (f *FfmpegLibrary) validateMp4(ctx context.Context, path string, expectedSeconds float64) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, f.probeBinary,
+		"-v", "error",
+		"-show_entries", "format=duration:stream=codec_type",
+		"-of", "json",
+		path,
+	)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return fmt.Errorf("ffprobe: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return fmt.Errorf("ffprobe: %w", err)
+	}
+	var result probeResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		return fmt.Errorf("ffprobe: %w", err)
+	}
+	media := false
+	for _, stream := range result.Streams {
+		if stream.CodecType == "video" || stream.CodecType == "audio" {
+			media = true
+		}
+	}
+	if !media {
+		return errors.New("converted MP4 has no audio or video")
+	}
+	duration, err := strconv.ParseFloat(result.Format.Duration, 64)
+	if err != nil || duration <= 0 {
+		return errors.New("converted MP4 has no duration")
+	}
+	if expectedSeconds > 0 && math.Abs(duration-expectedSeconds) > max(probeMinDurationSlack, expectedSeconds*0.05) {
+		return fmt.Errorf("converted MP4 lasts %.1fs, the recording %.1fs", duration, expectedSeconds)
+	}
+	return nil
+}
+
+// parseProgressLine reads the output position, in seconds, from a line of
+// ffmpeg's -progress output. out_time_ms is in microseconds as well.
+func parseProgressLine(line string) (float64, bool) {
+	key, value, found := strings.Cut(strings.TrimSpace(line), "=")
+	if !found || (key != "out_time_us" && key != "out_time_ms") {
+		return 0, false
+	}
+	us, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || us < 0 {
+		return 0, false
+	}
+	return float64(us) / 1e6, true
+}
+
+// CaptureThumbnail decodes the first video frame of a segment, scales it to
+// 360 lines and writes it to outputPath as a JPEG
+func (f *FfmpegLibrary) CaptureThumbnail(segmentPath string, outputPath string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), thumbnailTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, f.binary,
+		"-hide_banner", "-loglevel", "error", "-y",
+		"-i", segmentPath,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale=-2:%d", thumbnailHeight),
+		"-q:v", "4",
+		"-f", "image2", "-c:v", "mjpeg",
+		outputPath,
+	)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
 		return err
 	}
 	return nil
 }
+
+// StartTranscode writes master.m3u8 for the ladder and starts ffmpeg, which
+// is restarted with exponential backoff whenever it exits until Stop is called
+func (f *FfmpegLibrary) StartTranscode(job ffmpeg.TranscodeJob) (ffmpeg.Transcoder, error) {
+	if len(job.Renditions) == 0 {
+		return nil, domainErrors.ErrInvalidInput
+	}
+	for _, rendition := range job.Renditions {
+		if !rendition.IsValid() {
+			return nil, domainErrors.ErrInvalidInput
+		}
+		// Drop what an earlier publish left behind
+		dir := filepath.Join(job.OutputDir, string(rendition))
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return nil, err
+		}
+	}
+	masterPath := filepath.Join(job.OutputDir, transcodeMasterName)
+	if err := os.WriteFile(masterPath+".tmp", masterPlaylist(job.Renditions), 0666); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(masterPath+".tmp", masterPath); err != nil {
+		return nil, err
+	}
+
+	t := &transcoder{
+		library: f,
+		job:     job,
+		done:    make(chan struct{}),
+		exited:  make(chan struct{}),
+	}
+	go t.run()
+	return t, nil
+}
+
+// masterPlaylist lists every rendition of the ladder, best first as given
+func masterPlaylist(renditions []livestream.Rendition) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("#EXTM3U\n")
+	buf.WriteString("#EXT-X-VERSION:3\n")
+	buf.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n")
+	for _, rendition := range renditions {
+		preset := livestream.RenditionPresets[rendition]
+		bandwidth := (peakBitrate(preset.VideoBitrate) + preset.AudioBitrate) * 1000
+		if preset.Height > 0 {
+			buf.WriteString(fmt.Sprintf("#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d\n", bandwidth, preset.Width, preset.Height))
+		} else {
+			buf.WriteString(fmt.Sprintf("#EXT-X-STREAM-INF:BANDWIDTH=%d,CODECS=\"mp4a.40.2\"\n", bandwidth))
+		}
+		buf.WriteString(string(rendition) + "/" + transcodePlaylistName + "\n")
+	}
+	return buf.Bytes()
+}
+
+// peakBitrate is the maxrate ffmpeg is given for a target bitrate
+func peakBitrate(kbps int) int {
+	return kbps * 107 / 100
+}
+
+// transcodeArgs builds one ffmpeg run that reads FLV from stdin and writes
+// every rendition to <rendition>/playlist.m3u8, relative to the output dir.
+// Keyframes are forced on segment boundaries so the renditions line up.
+// Segment names carry the start of the run: a restarted ffmpeg counts from
+// zero again, and a segment name must never be reused since segments are
+// served as immutable.
+func transcodeArgs(renditions []livestream.Rendition, started time.Time) []string {
+	args := []string{"-hide_banner", "-loglevel", "error", "-f", "flv", "-i", "pipe:0"}
+	var streamMap []string
+	video, audio := 0, 0
+	for _, rendition := range renditions {
+		preset := livestream.RenditionPresets[rendition]
+		if preset.Height > 0 {
+			args = append(args, "-map", "0:v:0",
+				fmt.Sprintf("-filter:v:%d", video), fmt.Sprintf("scale=-2:%d", preset.Height),
+				fmt.Sprintf("-b:v:%d", video), fmt.Sprintf("%dk", preset.VideoBitrate),
+				fmt.Sprintf("-maxrate:v:%d", video), fmt.Sprintf("%dk", peakBitrate(preset.VideoBitrate)),
+				fmt.Sprintf("-bufsize:v:%d", video), fmt.Sprintf("%dk", 2*preset.VideoBitrate))
+		}
+		args = append(args, "-map", "0:a:0",
+			fmt.Sprintf("-b:a:%d", audio), fmt.Sprintf("%dk", preset.AudioBitrate))
+		if preset.Height > 0 {
+			streamMap = append(streamMap, fmt.Sprintf("v:%d,a:%d,name:%s", video, audio, rendition))
+			video++
+		} else {
+			streamMap = append(streamMap, fmt.Sprintf("a:%d,name:%s", audio, rendition))
+		}
+		audio++
+	}
+	args = append(args,
+		"-c:v", "libx264", "-preset", "veryfast", "-profile:v", "main",
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", transcodeSegmentTime), "-sc_threshold", "0",
+		"-c:a", "aac", "-ar", "48000",
+		"-f", "hls",
+		"-hls_time", fmt.Sprint(transcodeSegmentTime),
+		"-hls_list_size", fmt.Sprint(transcodeListSize),
+		"-hls_flags", "delete_segments+independent_segments+temp_file",
+		"-var_stream_map", strings.Join(streamMap, " "),
+		"-hls_segment_filename", fmt.Sprintf("%%v/segment-%d-%%d.ts", started.UnixMilli()),
+		"%v/"+transcodePlaylistName,
+	)
+	return args
+}
+
+type transcoder struct {
+	library  *FfmpegLibrary
+	job      ffmpeg.TranscodeJob
+	done     chan struct{}
+	stopOnce sync.Once
+	exited   chan struct{}
+}
+
+func (t *transcoder) Stop() {
+	t.stopOnce.Do(func() { close(t.done) })
+	<-t.exited
+}
+
+func (t *transcoder) run() {
+	defer close(t.exited)
+	backoff := t.library.minBackoff
+	for {
+		started := time.Now()
+		err := t.runOnce()
+		select {
+		case <-t.done:
+			return
+		default:
+		}
+		if time.Since(started) >= transcodeHealthyRun {
+			backoff = t.library.minBackoff
+		}
+		if err == nil {
+			err = errors.New("ffmpeg exited")
+		}
+		if t.job.OnFailure != nil {
+			t.job.OnFailure(err)
+		}
+
+		select {
+		case <-t.done:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > t.library.maxBackoff {
+			backoff = t.library.maxBackoff
+		}
+	}
+}
+
+// runOnce runs ffmpeg until it exits or the transcoder is stopped
+func (t *transcoder) runOnce() error {
+	source, err := t.job.Source()
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(t.library.binary, transcodeArgs(t.job.Renditions, time.Now())...)
+	cmd.Dir = t.job.OutputDir
+	cmd.Stdin = source
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = transcodeWaitDelay
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	waitErr := make(chan error, 1)
+	go func() { waitErr <- cmd.Wait() }()
+
+	select {
+	case err := <-waitErr:
+		if err != nil && stderr.Len() > 0 {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return err
+	case <-t.done:
+		_ = cmd.Process.Kill()
+		<-waitErr
+		return nil
+	}
+}
@@ -0,0 +1,282 @@
+package util
+
+import (
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/domain/interface/libarary/ffmpeg"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeFfmpeg writes a shell script standing in for ffmpeg
+func fakeFfmpeg(t *testing.T, script string) *FfmpegLibrary {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ffmpeg needs a POSIX shell")
+	}
+	binary := filepath.Join(t.TempDir(), "ffmpeg")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatalf("write fake ffmpeg failed: %v", err)
+	}
+	return &FfmpegLibrary{binary: binary, minBackoff: 10 * time.Millisecond, maxBackoff: 40 * time.Millisecond}
+}
+
+// fakeProbe points the library at a shell script standing in for ffprobe
+// that prints output
+func fakeProbe(t *testing.T, library *FfmpegLibrary, output string) {
+	t.Helper()
+	binary := filepath.Join(t.TempDir(), "ffprobe")
+	script := "#!/bin/sh\ncat <<'EOF'\n" + output + "\nEOF\n"
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatalf("write fake ffprobe failed: %v", err)
+	}
+	library.probeBinary = binary
+}
+
+const probeOutput = `{"streams": [{"codec_type": "video"}, {"codec_type": "audio"}], "format": {"duration": "%s"}}`
+
+func staticSource() (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("FLV")), nil
+}
+
+func TestStartTranscode_WritesMasterAndArgs(t *testing.T) {
+	library := fakeFfmpeg(t, `echo "$@" > args; cat > input; sleep 30`)
+	outputDir := t.TempDir()
+	// Left over from an earlier publish
+	os.MkdirAll(filepath.Join(outputDir, "720p"), 0777)
+	os.WriteFile(filepath.Join(outputDir, "720p", "segment-99.ts"), []byte("old"), 0666)
+
+	transcoder, err := library.StartTranscode(ffmpeg.TranscodeJob{
+		Source:     staticSource,
+		OutputDir:  outputDir,
+		Renditions: []livestream.Rendition{livestream.Rendition720p, livestream.RenditionAudioOnly},
+	})
+	if err != nil {
+		t.Fatalf("StartTranscode failed: %v", err)
+	}
+	defer transcoder.Stop()
+
+	master, err := os.ReadFile(filepath.Join(outputDir, "master.m3u8"))
+	if err != nil {
+		t.Fatalf("master.m3u8 not written: %v", err)
+	}
+	expected := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-INDEPENDENT-SEGMENTS\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=3124000,RESOLUTION=1280x720\n720p/playlist.m3u8\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=64000,CODECS=\"mp4a.40.2\"\naudio/playlist.m3u8\n"
+	if string(master) != expected {
+		t.Fatalf("unexpected master playlist:\n%s", master)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "720p", "segment-99.ts")); !os.IsNotExist(err) {
+		t.Fatal("stale rendition output was not removed")
+	}
+
+	var args string
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.HasSuffix(args, "\n") {
+		if time.Now().After(deadline) {
+			t.Fatal("fake ffmpeg was not started")
+		}
+		time.Sleep(10 * time.Millisecond)
+		data, _ := os.ReadFile(filepath.Join(outputDir, "args"))
+		args = string(data)
+	}
+	for _, want := range []string{"-i pipe:0", "-var_stream_map v:0,a:0,name:720p a:1,name:audio", "%v/playlist.m3u8"} {
+		if !strings.Contains(args, want) {
+			t.Fatalf("ffmpeg args missing %q: %s", want, args)
+		}
+	}
+	if input, _ := os.ReadFile(filepath.Join(outputDir, "input")); string(input) != "FLV" {
+		t.Fatalf("ffmpeg did not get the source on stdin: %q", input)
+	}
+}
+
+// A restarted ffmpeg must not reuse the segment names of the previous run
+func TestTranscodeArgs_SegmentNamesPerRun(t *testing.T) {
+	renditions := []livestream.Rendition{livestream.Rendition720p}
+	first := strings.Join(transcodeArgs(renditions, time.UnixMilli(1700000000000)), " ")
+	second := strings.Join(transcodeArgs(renditions, time.UnixMilli(1700000060000)), " ")
+	if !strings.Contains(first, "-hls_segment_filename %v/segment-1700000000000-%d.ts") {
+		t.Fatalf("unexpected segment names: %s", first)
+	}
+	if !strings.Contains(second, "-hls_segment_filename %v/segment-1700000060000-%d.ts") {
+		t.Fatalf("unexpected segment names: %s", second)
+	}
+}
+
+func TestStartTranscode_RestartsUntilStopped(t *testing.T) {
+	library := fakeFfmpeg(t, `echo boom >&2; exit 1`)
+	var sources, failures atomic.Int32
+	failed := make(chan error, 16)
+	transcoder, err := library.StartTranscode(ffmpeg.TranscodeJob{
+		Source: func() (io.ReadCloser, error) {
+			sources.Add(1)
+			return staticSource()
+		},
+		OutputDir:  t.TempDir(),
+		Renditions: []livestream.Rendition{livestream.Rendition480p},
+		OnFailure: func(err error) {
+			if failures.Add(1) <= 16 {
+				failed <- err
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("StartTranscode failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case err := <-failed:
+			if !strings.Contains(err.Error(), "boom") {
+				t.Fatalf("failure should carry ffmpeg's stderr: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("ffmpeg was not restarted")
+		}
+	}
+	transcoder.Stop()
+	restarts := sources.Load()
+	time.Sleep(100 * time.Millisecond)
+	if sources.Load() != restarts {
+		t.Fatal("ffmpeg restarted after Stop")
+	}
+}
+
+func TestStartTranscode_StopKillsRunningFfmpeg(t *testing.T) {
+	library := fakeFfmpeg(t, `exec sleep 30`)
+	transcoder, err := library.StartTranscode(ffmpeg.TranscodeJob{
+		Source:     staticSource,
+		OutputDir:  t.TempDir(),
+		Renditions: []livestream.Rendition{livestream.Rendition1080p},
+	})
+	if err != nil {
+		t.Fatalf("StartTranscode failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	stopped := make(chan struct{})
+	go func() {
+		transcoder.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not end ffmpeg")
+	}
+}
+
+func TestStartTranscode_RejectsUnknownRendition(t *testing.T) {
+	library := NewFfmpegLibrary()
+	for _, renditions := range [][]livestream.Rendition{nil, {"4k"}} {
+		if _, err := library.StartTranscode(ffmpeg.TranscodeJob{Source: staticSource, OutputDir: t.TempDir(), Renditions: renditions}); err == nil {
+			t.Fatalf("expected an error for renditions %v", renditions)
+		}
+	}
+}
+
+func TestConvertToMp4_ReportsProgressAndRenames(t *testing.T) {
+	library := fakeFfmpeg(t, `for a; do out="$a"; done
+echo "out_time_us=5000000"
+echo "progress=continue"
+echo "out_time_ms=20000000"
+echo "progress=end"
+echo mp4 > "$out"`)
+	fakeProbe(t, library, fmt.Sprintf(probeOutput, "10.020000"))
+	dir := t.TempDir()
+	var progress []float64
+	err := library.ConvertToMp4(context.Background(), ffmpeg.ConvertJob{
+		PlaylistPath:    filepath.Join(dir, "record.m3u8"),
+		OutputPath:      filepath.Join(dir, "show.mp4"),
+		DurationSeconds: 10,
+		OnProgress:      func(p float64) { progress = append(progress, p) },
+	})
+	if err != nil {
+		t.Fatalf("ConvertToMp4 failed: %v", err)
+	}
+	if len(progress) != 2 || progress[0] != 0.5 || progress[1] != 1 {
+		t.Fatalf("unexpected progress: %v", progress)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "show.mp4")); string(data) != "mp4\n" {
+		t.Fatalf("MP4 not renamed into place: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "show.mp4.part")); !os.IsNotExist(err) {
+		t.Fatal("partial file left behind")
+	}
+}
+
+func TestConvertToMp4_FailureLeavesNoFile(t *testing.T) {
+	library := fakeFfmpeg(t, `for a; do out="$a"; done
+echo half > "$out"
+echo "Invalid data found" >&2
+exit 1`)
+	dir := t.TempDir()
+	err := library.ConvertToMp4(context.Background(), ffmpeg.ConvertJob{
+		PlaylistPath: filepath.Join(dir, "record.m3u8"),
+		OutputPath:   filepath.Join(dir, "show.mp4"),
+	})
+	if err == nil || !strings.Contains(err.Error(), "Invalid data found") {
+		t.Fatalf("expected the ffmpeg error, got %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Fatalf("expected no output, found %d files", len(entries))
+	}
+}
+
+func TestConvertToMp4_RejectsUnplayableMp4(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{"No streams", `{"streams": [], "format": {"duration": "10.0"}}`},
+		{"Data only", `{"streams": [{"codec_type": "data"}], "format": {"duration": "10.0"}}`},
+		{"No duration", `{"streams": [{"codec_type": "video"}], "format": {}}`},
+		{"Too short", fmt.Sprintf(probeOutput, "6.5")},
+		{"Not JSON", "moov atom not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			library := fakeFfmpeg(t, `for a; do out="$a"; done
+echo mp4 > "$out"`)
+			fakeProbe(t, library, tt.output)
+			dir := t.TempDir()
+			err := library.ConvertToMp4(context.Background(), ffmpeg.ConvertJob{
+				PlaylistPath:    filepath.Join(dir, "record.m3u8"),
+				OutputPath:      filepath.Join(dir, "show.mp4"),
+				DurationSeconds: 10,
+			})
+			if err == nil {
+				t.Fatal("expected the MP4 to be rejected")
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Fatalf("expected no output, found %d files", len(entries))
+			}
+		})
+	}
+}
+
+func TestParseProgressLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		seconds float64
+		ok      bool
+	}{
+		{"out_time_us=1500000", 1.5, true},
+		{"out_time_ms=2000000", 2, true},
+		{"out_time=00:00:02.000000", 0, false},
+		{"out_time_us=N/A", 0, false},
+		{"progress=end", 0, false},
+	}
+	for _, tt := range tests {
+		seconds, ok := parseProgressLine(tt.line)
+		if seconds != tt.seconds || ok != tt.ok {
+			t.Errorf("parseProgressLine(%q) = %v, %v", tt.line, seconds, ok)
+		}
+	}
+}
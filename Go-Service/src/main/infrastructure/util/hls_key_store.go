@@ -0,0 +1,217 @@
+package util
+
+import (
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	hlsKeyDirName = "keys"
+	hlsKeyExt     = ".key"
+	hlsKeySize    = 16
+	// maxRememberedSegments bounds the segment to key id lookups kept per
+	// store; they are only a shortcut for the file timestamps
+	maxRememberedSegments = 10000
+	// maxRememberedKeys bounds the keys kept in memory; the files hold them
+	maxRememberedKeys = 1000
+)
+
+// FileHLSKeyStore keeps the keys of a livestream in <hlsRoot>/<uuid>/keys,
+// so they are removed together with the HLS output when the livestream is
+// deleted. A segment is encrypted with the key of the rotation period it was
+// written in.
+type FileHLSKeyStore struct {
+	hlsRoot  string
+	rotation time.Duration
+
+	lock sync.Mutex
+	// keyIDs maps <uuid>/<segment> to its key id; segments are not
+	// rewritten, so the mapping never changes
+	keyIDs map[string]string
+	// keys maps <uuid>/<id> to the key, within maxRememberedKeys
+	keys map[string][]byte
+}
+
+func NewFileHLSKeyStore(hlsRoot string) *FileHLSKeyStore {
+	return &FileHLSKeyStore{
+		hlsRoot:  hlsRoot,
+		rotation: livestream.HLSKeyRotationSeconds * time.Second,
+		keyIDs:   make(map[string]string),
+		keys:     make(map[string][]byte),
+	}
+}
+
+func (s *FileHLSKeyStore) dir(uuid string) string {
+	return filepath.Join(s.hlsRoot, uuid, hlsKeyDirName)
+}
+
+// KeyID picks the key by the time the segment was written. A segment that is
+// already gone falls back to the current key, it cannot be served anyway.
+func (s *FileHLSKeyStore) KeyID(uuid, segment string) (string, error) {
+	cacheKey := uuid + "/" + segment
+	s.lock.Lock()
+	id, ok := s.keyIDs[cacheKey]
+	s.lock.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	writtenAt := time.Now()
+	info, err := os.Stat(filepath.Join(s.hlsRoot, uuid, segment))
+	if err == nil {
+		writtenAt = info.ModTime()
+	}
+	id = strconv.FormatInt(writtenAt.UnixNano()/int64(s.rotation), 10)
+	if _, err := s.createKey(uuid, id); err != nil {
+		return "", err
+	}
+	if info != nil {
+		s.lock.Lock()
+		if len(s.keyIDs) >= maxRememberedSegments {
+			s.keyIDs = make(map[string]string)
+		}
+		s.keyIDs[cacheKey] = id
+		s.lock.Unlock()
+	}
+	return id, nil
+}
+
+// Key only returns keys that were created by KeyID
+func (s *FileHLSKeyStore) Key(uuid, id string) ([]byte, error) {
+	if !validHLSKeyID(id) {
+		return nil, errors.ErrInvalidInput
+	}
+	s.lock.Lock()
+	key, ok := s.keys[uuid+"/"+id]
+	s.lock.Unlock()
+	if ok {
+		return key, nil
+	}
+	key, err := os.ReadFile(filepath.Join(s.dir(uuid), id+hlsKeyExt))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.ErrNotFound
+		}
+		return nil, err
+	}
+	s.remember(uuid, id, key)
+	return key, nil
+}
+
+// createKey writes a random key unless the key exists. Concurrent callers
+// all end up with the key that was written first.
+func (s *FileHLSKeyStore) createKey(uuid, id string) ([]byte, error) {
+	if key, err := s.Key(uuid, id); err != errors.ErrNotFound {
+		return key, err
+	}
+	dir := s.dir(uuid)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	key := make([]byte, hlsKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, id+hlsKeyExt), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return s.Key(uuid, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = file.Write(key)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.remember(uuid, id, key)
+	return key, nil
+}
+
+func (s *FileHLSKeyStore) remember(uuid, id string, key []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.keys) >= maxRememberedKeys {
+		s.keys = make(map[string][]byte)
+	}
+	s.keys[uuid+"/"+id] = key
+}
+
+// Prune deletes the keys of the rotation periods that ended more than maxAge
+// ago, whose segments have all left the playlists by then
+func (s *FileHLSKeyStore) Prune(uuid string, maxAge time.Duration) error {
+	entries, err := os.ReadDir(s.dir(uuid))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// Periods before this one ended before the cutoff
+	oldest := time.Now().Add(-maxAge).UnixNano() / int64(s.rotation)
+	for _, entry := range entries {
+		id := strings.TrimSuffix(entry.Name(), hlsKeyExt)
+		if id == entry.Name() || !validHLSKeyID(id) {
+			continue
+		}
+		period, err := strconv.ParseInt(id, 10, 64)
+		if err != nil || period >= oldest {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir(uuid), entry.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		s.lock.Lock()
+		delete(s.keys, uuid+"/"+id)
+		s.lock.Unlock()
+	}
+	return nil
+}
+
+// validHLSKeyID only accepts the rotation period numbers written by KeyID
+func validHLSKeyID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, c := range id {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// SegmentIV derives the IV of a segment from its path, so the playlist and
+// the segment request agree on it without keeping state
+func SegmentIV(segment string) []byte {
+	sum := sha256.Sum256([]byte(filepath.ToSlash(filepath.Clean(segment))))
+	return sum[:aes.BlockSize]
+}
+
+// EncryptSegment encrypts a segment the way HLS AES-128 expects: AES-128-CBC
+// over the whole file with PKCS#7 padding
+func EncryptSegment(data, key, iv []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	encrypted := make([]byte, 0, len(data)+padding)
+	encrypted = append(encrypted, data...)
+	encrypted = append(encrypted, bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+	return encrypted, nil
+}
@@ -0,0 +1,149 @@
+package util
+
+import (
+	"Go-Service/src/main/domain/entity/errors"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// writeSegment creates a segment written at modTime
+func writeSegment(t *testing.T, root, uuid, segment string, modTime time.Time) {
+	t.Helper()
+	path := filepath.Join(root, uuid, segment)
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	os.WriteFile(path, []byte("ts"), 0666)
+	os.Chtimes(path, modTime, modTime)
+}
+
+func TestFileHLSKeyStore_RotatesByWriteTime(t *testing.T) {
+	root := t.TempDir()
+	store := NewFileHLSKeyStore(root)
+	store.rotation = time.Minute
+	base := time.Now().Truncate(time.Minute).Add(-time.Hour)
+	writeSegment(t, root, "uuid-1", "stream-1.ts", base)
+	writeSegment(t, root, "uuid-1", "720p/segment-1.ts", base.Add(30*time.Second))
+	writeSegment(t, root, "uuid-1", "stream-2.ts", base.Add(time.Minute))
+
+	first, err := store.KeyID("uuid-1", "stream-1.ts")
+	if err != nil {
+		t.Fatalf("KeyID failed: %v", err)
+	}
+	rendition, _ := store.KeyID("uuid-1", "720p/segment-1.ts")
+	next, _ := store.KeyID("uuid-1", "stream-2.ts")
+	if first != rendition {
+		t.Fatalf("segments of one period got keys %s and %s", first, rendition)
+	}
+	if first == next {
+		t.Fatal("key did not rotate")
+	}
+
+	firstKey, err := store.Key("uuid-1", first)
+	if err != nil || len(firstKey) != 16 {
+		t.Fatalf("expected a 16 byte key, got %d bytes, %v", len(firstKey), err)
+	}
+	nextKey, _ := store.Key("uuid-1", next)
+	if bytes.Equal(firstKey, nextKey) {
+		t.Fatal("rotated key is the same")
+	}
+
+	// A new store, e.g. after a restart, reads the same keys
+	again, _ := NewFileHLSKeyStore(root).Key("uuid-1", first)
+	if !bytes.Equal(firstKey, again) {
+		t.Fatal("key changed after reload")
+	}
+}
+
+func TestFileHLSKeyStore_Key(t *testing.T) {
+	store := NewFileHLSKeyStore(t.TempDir())
+
+	if _, err := store.Key("uuid-1", "12345"); err != errors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound for a key never created, got %v", err)
+	}
+	if _, err := store.Key("uuid-1", "../uuid-2/1"); err != errors.ErrInvalidInput {
+		t.Fatalf("expected ErrInvalidInput, got %v", err)
+	}
+	// A segment that is gone gets the current key
+	id, err := store.KeyID("uuid-1", "missing.ts")
+	if err != nil {
+		t.Fatalf("KeyID failed: %v", err)
+	}
+	if _, err := store.Key("uuid-1", id); err != nil {
+		t.Fatalf("current key was not created: %v", err)
+	}
+}
+
+func TestFileHLSKeyStore_Prune(t *testing.T) {
+	root := t.TempDir()
+	store := NewFileHLSKeyStore(root)
+	store.rotation = time.Minute
+	now := time.Now()
+	writeSegment(t, root, "uuid-1", "old.ts", now.Add(-time.Hour))
+	writeSegment(t, root, "uuid-1", "recent.ts", now.Add(-2*time.Minute))
+	old, _ := store.KeyID("uuid-1", "old.ts")
+	recent, _ := store.KeyID("uuid-1", "recent.ts")
+	current, _ := store.KeyID("uuid-1", "missing.ts")
+
+	if err := store.Prune("uuid-1", 5*time.Minute); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+
+	if _, err := store.Key("uuid-1", old); err != errors.ErrNotFound {
+		t.Fatalf("expected the old key to be deleted, got %v", err)
+	}
+	for _, id := range []string{recent, current} {
+		if _, err := store.Key("uuid-1", id); err != nil {
+			t.Fatalf("key %s of a segment still in the playlist was deleted: %v", id, err)
+		}
+	}
+	// Livestreams without keys have nothing to prune
+	if err := store.Prune("uuid-2", time.Minute); err != nil {
+		t.Fatalf("Prune of a livestream without keys failed: %v", err)
+	}
+}
+
+func TestFileHLSKeyStore_RemembersBoundedKeys(t *testing.T) {
+	store := NewFileHLSKeyStore(t.TempDir())
+
+	for i := 0; i <= maxRememberedKeys; i++ {
+		store.remember("uuid-1", strconv.Itoa(i), []byte("key"))
+	}
+
+	if len(store.keys) > maxRememberedKeys {
+		t.Fatalf("expected at most %d keys in memory, got %d", maxRememberedKeys, len(store.keys))
+	}
+}
+
+func TestEncryptSegment(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	iv := SegmentIV("720p/segment-1.ts")
+	if !bytes.Equal(iv, SegmentIV(filepath.Join(".", "720p", "segment-1.ts"))) {
+		t.Fatal("IV depends on how the path is written")
+	}
+
+	for _, size := range []int{0, 15, 16, 188 * 7} {
+		data := bytes.Repeat([]byte{0x47}, size)
+		encrypted, err := EncryptSegment(data, key, iv)
+		if err != nil {
+			t.Fatalf("EncryptSegment failed: %v", err)
+		}
+		if len(encrypted)%aes.BlockSize != 0 || len(encrypted) <= size {
+			t.Fatalf("expected padded ciphertext for %d bytes, got %d", size, len(encrypted))
+		}
+
+		block, _ := aes.NewCipher(key)
+		decrypted := make([]byte, len(encrypted))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, encrypted)
+		padding := int(decrypted[len(decrypted)-1])
+		if !bytes.Equal(decrypted[:len(decrypted)-padding], data) {
+			t.Fatalf("round trip of %d bytes failed", size)
+		}
+	}
+}
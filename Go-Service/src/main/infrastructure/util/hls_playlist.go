@@ -0,0 +1,67 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var playlistURIAttribute = regexp.MustCompile(`URI="([^"]*)"`)
+
+// AppendPlaylistQuery adds query to every URI of an HLS playlist: the URI
+// lines of segments and variant playlists, and the URI attributes of tags
+// such as EXT-X-PART, EXT-X-PRELOAD-HINT, EXT-X-MAP and EXT-X-KEY
+func AppendPlaylistQuery(playlist []byte, query string) []byte {
+	lines := strings.Split(string(playlist), "\n")
+	for i, line := range lines {
+		content := strings.TrimRight(line, "\r")
+		suffix := line[len(content):]
+		switch {
+		case content == "":
+		case strings.HasPrefix(content, "#"):
+			lines[i] = playlistURIAttribute.ReplaceAllStringFunc(content, func(attribute string) string {
+				uri := playlistURIAttribute.FindStringSubmatch(attribute)[1]
+				return `URI="` + appendQuery(uri, query) + `"`
+			}) + suffix
+		default:
+			lines[i] = appendQuery(content, query) + suffix
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+func appendQuery(uri, query string) string {
+	if strings.Contains(uri, "?") {
+		return uri + "&" + query
+	}
+	return uri + "?" + query
+}
+
+// InsertSegmentKeys puts an AES-128 EXT-X-KEY tag in front of every media
+// segment of a playlist, with the key URI and IV segmentKey returns for the
+// segment URI. Playlists without segments are returned unchanged.
+func InsertSegmentKeys(playlist []byte, segmentKey func(uri string) (string, []byte, error)) ([]byte, error) {
+	lines := strings.Split(string(playlist), "\n")
+	result := make([]string, 0, 2*len(lines))
+	// extinf is the index in result of the EXTINF tag of the next segment
+	extinf := -1
+	for _, line := range lines {
+		content := strings.TrimRight(line, "\r")
+		switch {
+		case strings.HasPrefix(content, "#EXTINF:"):
+			extinf = len(result)
+		case content == "" || strings.HasPrefix(content, "#") || extinf < 0:
+		default:
+			keyURI, iv, err := segmentKey(content)
+			if err != nil {
+				return nil, err
+			}
+			tag := fmt.Sprintf(`#EXT-X-KEY:METHOD=AES-128,URI="%s",IV=0x%x`, keyURI, iv) + line[len(content):]
+			result = slices.Insert(result, extinf, tag)
+			extinf = -1
+		}
+		result = append(result, line)
+	}
+	return []byte(strings.Join(result, "\n")), nil
+}
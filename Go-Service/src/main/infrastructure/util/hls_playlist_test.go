@@ -0,0 +1,71 @@
+package util
+
+import "testing"
+
+func TestAppendPlaylistQuery(t *testing.T) {
+	playlist := "#EXTM3U\r\n" +
+		"#EXT-X-VERSION:9\n" +
+		"#EXT-X-MEDIA-SEQUENCE:3\n" +
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"key?v=1\"\n" +
+		"#EXTINF:2.000,\n" +
+		"stream-1700000000000-3.ts\n" +
+		"#EXT-X-PART:DURATION=0.5,URI=\"stream-1700000002000-p8.ts\",INDEPENDENT=YES\n" +
+		"#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"stream-1700000002000-p9.ts\"\n" +
+		"720p/playlist.m3u8\r\n"
+
+	signed := AppendPlaylistQuery([]byte(playlist), "token=abc")
+
+	expected := "#EXTM3U\r\n" +
+		"#EXT-X-VERSION:9\n" +
+		"#EXT-X-MEDIA-SEQUENCE:3\n" +
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"key?v=1&token=abc\"\n" +
+		"#EXTINF:2.000,\n" +
+		"stream-1700000000000-3.ts?token=abc\n" +
+		"#EXT-X-PART:DURATION=0.5,URI=\"stream-1700000002000-p8.ts?token=abc\",INDEPENDENT=YES\n" +
+		"#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"stream-1700000002000-p9.ts?token=abc\"\n" +
+		"720p/playlist.m3u8?token=abc\r\n"
+	if string(signed) != expected {
+		t.Fatalf("unexpected playlist:\n%s", signed)
+	}
+}
+
+func TestInsertSegmentKeys(t *testing.T) {
+	playlist := "#EXTM3U\n" +
+		"#EXT-X-VERSION:3\n" +
+		"#EXT-X-MEDIA-SEQUENCE:3\n" +
+		"\n" +
+		"#EXT-X-DISCONTINUITY\n" +
+		"#EXTINF:2.000,\r\n" +
+		"stream-3.ts\r\n" +
+		"#EXTINF:2.000,\n" +
+		"stream-4.ts\n"
+
+	encrypted, err := InsertSegmentKeys([]byte(playlist), func(uri string) (string, []byte, error) {
+		return "https://example.com/key/" + uri[7:8], []byte{0xab, 0x01}, nil
+	})
+
+	expected := "#EXTM3U\n" +
+		"#EXT-X-VERSION:3\n" +
+		"#EXT-X-MEDIA-SEQUENCE:3\n" +
+		"\n" +
+		"#EXT-X-DISCONTINUITY\n" +
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"https://example.com/key/3\",IV=0xab01\r\n" +
+		"#EXTINF:2.000,\r\n" +
+		"stream-3.ts\r\n" +
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"https://example.com/key/4\",IV=0xab01\n" +
+		"#EXTINF:2.000,\n" +
+		"stream-4.ts\n"
+	if err != nil || string(encrypted) != expected {
+		t.Fatalf("unexpected playlist (%v):\n%s", err, encrypted)
+	}
+
+	// Variant playlists of a master playlist are not segments
+	master := "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=2800000\n720p/playlist.m3u8\n"
+	unchanged, _ := InsertSegmentKeys([]byte(master), func(uri string) (string, []byte, error) {
+		t.Fatalf("key requested for %s", uri)
+		return "", nil, nil
+	})
+	if string(unchanged) != master {
+		t.Fatalf("master playlist changed:\n%s", unchanged)
+	}
+}
@@ -0,0 +1,126 @@
+package util
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// segmentMaxAge is a year, the longest max-age caches are expected to honour
+const segmentMaxAge = 365 * 24 * 60 * 60
+
+// HLSCacheControl returns the Cache-Control of an HLS file. Segment names are
+// never reused, so segments are immutable; playlists change with every
+// segment and are cached for playlistMaxAge seconds at most. Files of streams
+// not everyone may watch are kept out of shared caches.
+func HLSCacheControl(filename string, public bool, playlistMaxAge int) string {
+	scope := "private"
+	if public {
+		scope = "public"
+	}
+	if filepath.Ext(filename) == ".ts" {
+		return fmt.Sprintf("%s, max-age=%d, immutable", scope, segmentMaxAge)
+	}
+	return shortCacheControl(scope, playlistMaxAge)
+}
+
+// VODCacheControl returns the Cache-Control of a file of a recording played
+// back as VOD. The files never change, but the recording can be taken off
+// public view, so caches may only keep any of them for maxAge seconds.
+func VODCacheControl(public bool, maxAge int) string {
+	scope := "private"
+	if public {
+		scope = "public"
+	}
+	return shortCacheControl(scope, maxAge)
+}
+
+// shortCacheControl lets caches keep a file for maxAge seconds, or makes
+// them revalidate it every time when maxAge is not positive
+func shortCacheControl(scope string, maxAge int) string {
+	if maxAge <= 0 {
+		return scope + ", no-cache"
+	}
+	return fmt.Sprintf("%s, max-age=%d", scope, maxAge)
+}
+
+// ETag returns a strong entity tag of a response body
+func ETag(data []byte) string {
+	hash := fnv.New64a()
+	hash.Write(data)
+	return fmt.Sprintf(`"%x-%x"`, len(data), hash.Sum64())
+}
+
+// FileETag returns a strong entity tag of a file from its size and
+// modification time, so a large file is not read to tag it. Files served
+// this way are replaced by rename, never rewritten in place.
+func FileETag(size int64, modTime time.Time) string {
+	return fmt.Sprintf(`"%x-%x"`, size, modTime.UnixNano())
+}
+
+// ServeFile sends a file for inline use. Range, If-Range, conditional
+// requests and HEAD are answered as http.ServeContent does, so players can
+// seek and downloads can be resumed. An error is only returned before
+// anything is written.
+func ServeFile(w http.ResponseWriter, r *http.Request, path, contentType string) error {
+	return serveFile(w, r, path, contentType, false)
+}
+
+// ServeDownload sends a file as an attachment named after it, as ServeFile
+// does otherwise
+func ServeDownload(w http.ResponseWriter, r *http.Request, path, contentType string) error {
+	return serveFile(w, r, path, contentType, true)
+}
+
+func serveFile(w http.ResponseWriter, r *http.Request, path, contentType string, attachment bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return os.ErrNotExist
+	}
+
+	name := filepath.Base(path)
+	header := w.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("ETag", FileETag(info.Size(), info.ModTime()))
+	if attachment {
+		header.Set("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", EncodeRFC5987(name)))
+	}
+	http.ServeContent(w, r, name, info.ModTime(), file)
+	return nil
+}
+
+// NotModified reports whether a conditional GET can be answered with 304.
+// If-None-Match takes precedence over If-Modified-Since, as in RFC 9110.
+func NotModified(header http.Header, etag string, modTime time.Time) bool {
+	if ifNoneMatch := header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimSpace(candidate)
+			// If-None-Match uses the weak comparison
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if modTime.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	// Last-Modified has a one second resolution
+	return !modTime.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,250 @@
+package util
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHLSCacheControl(t *testing.T) {
+	tests := []struct {
+		filename string
+		public   bool
+		maxAge   int
+		expected string
+	}{
+		{"stream-1700000000000-3.ts", true, 1, "public, max-age=31536000, immutable"},
+		{"720p/segment-1700000000000-3.ts", false, 1, "private, max-age=31536000, immutable"},
+		{"playlist.m3u8", true, 1, "public, max-age=1"},
+		{"dvr.m3u8", false, 2, "private, max-age=2"},
+		{"master.m3u8", true, 0, "public, no-cache"},
+	}
+	for _, tt := range tests {
+		if got := HLSCacheControl(tt.filename, tt.public, tt.maxAge); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.filename, tt.expected, got)
+		}
+	}
+}
+
+func TestVODCacheControl(t *testing.T) {
+	tests := []struct {
+		public   bool
+		maxAge   int
+		expected string
+	}{
+		{true, 2, "public, max-age=2"},
+		{false, 2, "private, max-age=2"},
+		{true, 0, "public, no-cache"},
+	}
+	for _, tt := range tests {
+		if got := VODCacheControl(tt.public, tt.maxAge); got != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestETag(t *testing.T) {
+	if ETag([]byte("a")) == ETag([]byte("b")) {
+		t.Fatal("different bodies got the same ETag")
+	}
+	if ETag([]byte("a")) != ETag([]byte("a")) {
+		t.Fatal("ETag is not stable")
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	etag := ETag([]byte("segment"))
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 500_000_000, time.UTC)
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected bool
+	}{
+		{"no conditions", http.Header{}, false},
+		{"matching ETag", http.Header{"If-None-Match": {`"other", ` + etag}}, true},
+		{"weak matching ETag", http.Header{"If-None-Match": {"W/" + etag}}, true},
+		{"any ETag", http.Header{"If-None-Match": {"*"}}, true},
+		{"changed ETag", http.Header{"If-None-Match": {`"other"`}}, false},
+		// If-Modified-Since is ignored when If-None-Match is present
+		{"changed ETag not modified since", http.Header{"If-None-Match": {`"other"`}, "If-Modified-Since": {modTime.Add(time.Hour).Format(http.TimeFormat)}}, false},
+		{"not modified since", http.Header{"If-Modified-Since": {modTime.Format(http.TimeFormat)}}, true},
+		{"modified since", http.Header{"If-Modified-Since": {modTime.Add(-time.Hour).Format(http.TimeFormat)}}, false},
+		{"invalid date", http.Header{"If-Modified-Since": {"yesterday"}}, false},
+	}
+	for _, tt := range tests {
+		if got := NotModified(tt.header, etag, modTime); got != tt.expected {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.expected, got)
+		}
+	}
+	if NotModified(http.Header{"If-Modified-Since": {modTime.Format(http.TimeFormat)}}, etag, time.Time{}) {
+		t.Fatal("unknown modification time was not modified")
+	}
+}
+
+// download writes a recording and serves it for one request
+func download(t *testing.T, method string, header http.Header) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "My Show.mp4")
+	if err := os.WriteFile(path, []byte("0123456789"), 0666); err != nil {
+		t.Fatalf("write recording failed: %v", err)
+	}
+	info, _ := os.Stat(path)
+	request := httptest.NewRequest(method, "/livestream/record/uuid-1", nil)
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	recorder := httptest.NewRecorder()
+	if err := ServeDownload(recorder, request, path, "video/mp4"); err != nil {
+		t.Fatalf("ServeDownload failed: %v", err)
+	}
+	return recorder, FileETag(info.Size(), info.ModTime())
+}
+
+func TestServeDownload_Full(t *testing.T) {
+	recorder, etag := download(t, http.MethodGet, nil)
+
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "0123456789" {
+		t.Fatalf("unexpected response: %d %q", recorder.Code, recorder.Body.String())
+	}
+	expected := map[string]string{
+		"Content-Type":        "video/mp4",
+		"Content-Length":      "10",
+		"Accept-Ranges":       "bytes",
+		"ETag":                etag,
+		"Content-Disposition": "attachment; filename*=UTF-8''My%20Show.mp4",
+	}
+	for key, value := range expected {
+		if got := recorder.Header().Get(key); got != value {
+			t.Errorf("%s: expected %q, got %q", key, value, got)
+		}
+	}
+	if recorder.Header().Get("Last-Modified") == "" {
+		t.Error("Last-Modified missing")
+	}
+}
+
+func TestServeDownload_PartialContent(t *testing.T) {
+	tests := []struct {
+		name         string
+		rangeHeader  string
+		contentRange string
+		body         string
+	}{
+		{"Middle", "bytes=2-5", "bytes 2-5/10", "2345"},
+		{"Resume to the end", "bytes=7-", "bytes 7-9/10", "789"},
+		{"Suffix", "bytes=-3", "bytes 7-9/10", "789"},
+		{"Past the end is clipped", "bytes=8-20", "bytes 8-9/10", "89"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder, _ := download(t, http.MethodGet, http.Header{"Range": {tt.rangeHeader}})
+
+			if recorder.Code != http.StatusPartialContent {
+				t.Fatalf("expected 206, got %d", recorder.Code)
+			}
+			if got := recorder.Header().Get("Content-Range"); got != tt.contentRange {
+				t.Fatalf("expected Content-Range %q, got %q", tt.contentRange, got)
+			}
+			if recorder.Body.String() != tt.body {
+				t.Fatalf("expected body %q, got %q", tt.body, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestServeDownload_UnsatisfiableRange(t *testing.T) {
+	recorder, _ := download(t, http.MethodGet, http.Header{"Range": {"bytes=20-30"}})
+
+	if recorder.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("expected 416, got %d", recorder.Code)
+	}
+	if got := recorder.Header().Get("Content-Range"); got != "bytes */10" {
+		t.Fatalf("unexpected Content-Range %q", got)
+	}
+}
+
+func TestServeDownload_IfRange(t *testing.T) {
+	// Resuming the same file gets the rest of it
+	path := filepath.Join(t.TempDir(), "show.mp4")
+	os.WriteFile(path, []byte("0123456789"), 0666)
+	info, _ := os.Stat(path)
+	request := httptest.NewRequest(http.MethodGet, "/livestream/record/uuid-1", nil)
+	request.Header.Set("Range", "bytes=4-")
+	request.Header.Set("If-Range", FileETag(info.Size(), info.ModTime()))
+	recorder := httptest.NewRecorder()
+	ServeDownload(recorder, request, path, "video/mp4")
+	if recorder.Code != http.StatusPartialContent || recorder.Body.String() != "456789" {
+		t.Fatalf("expected the rest of the file, got %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	// A file that changed since is sent whole
+	recorder, _ = download(t, http.MethodGet, http.Header{"Range": {"bytes=4-"}, "If-Range": {`"a-1"`}})
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "0123456789" {
+		t.Fatalf("expected the whole file, got %d %q", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestServeDownload_Head(t *testing.T) {
+	recorder, etag := download(t, http.MethodHead, nil)
+
+	if recorder.Code != http.StatusOK || recorder.Body.Len() != 0 {
+		t.Fatalf("expected 200 without a body, got %d %q", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get("Content-Length") != "10" || recorder.Header().Get("ETag") != etag {
+		t.Fatalf("HEAD is missing the headers of GET: %v", recorder.Header())
+	}
+}
+
+func TestServeDownload_NotModified(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "show.mp4")
+	os.WriteFile(path, []byte("0123456789"), 0666)
+	info, _ := os.Stat(path)
+	request := httptest.NewRequest(http.MethodGet, "/livestream/record/uuid-1", nil)
+	request.Header.Set("If-None-Match", FileETag(info.Size(), info.ModTime()))
+	recorder := httptest.NewRecorder()
+	ServeDownload(recorder, request, path, "video/mp4")
+
+	if recorder.Code != http.StatusNotModified || recorder.Body.Len() != 0 {
+		t.Fatalf("expected 304, got %d", recorder.Code)
+	}
+}
+
+func TestServeDownload_Missing(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/livestream/record/uuid-1", nil)
+	recorder := httptest.NewRecorder()
+	if err := ServeDownload(recorder, request, filepath.Join(t.TempDir(), "none.mp4"), "video/mp4"); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+	if err := ServeDownload(recorder, request, t.TempDir(), "video/mp4"); err == nil {
+		t.Fatal("expected an error for a directory")
+	}
+	if recorder.Body.Len() != 0 {
+		t.Fatal("nothing may be written before the error")
+	}
+}
+
+func TestServeFile_Inline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stream-1.ts")
+	if err := os.WriteFile(path, []byte("0123456789"), 0666); err != nil {
+		t.Fatalf("write segment failed: %v", err)
+	}
+	request := httptest.NewRequest(http.MethodGet, "/vod/session-1/stream-1.ts", nil)
+	request.Header.Set("Range", "bytes=2-4")
+	recorder := httptest.NewRecorder()
+	if err := ServeFile(recorder, request, path, "video/mp2t"); err != nil {
+		t.Fatalf("ServeFile failed: %v", err)
+	}
+
+	if recorder.Code != http.StatusPartialContent || recorder.Body.String() != "234" {
+		t.Fatalf("unexpected response: %d %q", recorder.Code, recorder.Body.String())
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "video/mp2t" {
+		t.Errorf("Content-Type: expected video/mp2t, got %q", got)
+	}
+	if got := recorder.Header().Get("Content-Disposition"); got != "" {
+		t.Errorf("played inline, got Content-Disposition %q", got)
+	}
+}
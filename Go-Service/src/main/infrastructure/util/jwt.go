@@ -43,3 +43,34 @@ func (j *JWTLibrary) ParseAnonymousViewerToken(tokenString, secretKey string) (*
 	}
 	return cl, nil
 }
+
+func (j *JWTLibrary) GeneratePlaybackToken(linkID, livestreamUUID string, expiresAt time.Time, secretKey string) (string, error) {
+	cl := dto.PlaybackClaims{
+		LinkID: linkID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   livestreamUUID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, cl)
+	return token.SignedString([]byte(secretKey))
+}
+
+// ParsePlaybackToken checks the signature and expiry of a playback token
+func (j *JWTLibrary) ParsePlaybackToken(tokenString, secretKey string) (*dto.PlaybackClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &dto.PlaybackClaims{},
+		func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method")
+			}
+			return []byte(secretKey), nil
+		}, jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	cl, ok := token.Claims.(*dto.PlaybackClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return cl, nil
+}
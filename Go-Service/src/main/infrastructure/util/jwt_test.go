@@ -0,0 +1,27 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlaybackToken(t *testing.T) {
+	library := NewJWTLibrary()
+	token, err := library.GeneratePlaybackToken("link-1", "uuid-1", time.Now().Add(time.Hour), "secret")
+	if err != nil {
+		t.Fatalf("GeneratePlaybackToken failed: %v", err)
+	}
+
+	claims, err := library.ParsePlaybackToken(token, "secret")
+	if err != nil || claims.LinkID != "link-1" || claims.Subject != "uuid-1" {
+		t.Fatalf("unexpected claims %+v, %v", claims, err)
+	}
+	if _, err := library.ParsePlaybackToken(token, "other-secret"); err == nil {
+		t.Fatal("token signed with another key was accepted")
+	}
+
+	expired, _ := library.GeneratePlaybackToken("link-1", "uuid-1", time.Now().Add(-time.Minute), "secret")
+	if _, err := library.ParsePlaybackToken(expired, "secret"); err == nil {
+		t.Fatal("expired token was accepted")
+	}
+}
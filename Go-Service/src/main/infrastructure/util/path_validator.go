@@ -1,6 +1,7 @@
 package util
 
 import (
+	"Go-Service/src/main/domain/entity/livestream"
 	"errors"
 	"os"
 	"path/filepath"
@@ -18,7 +19,9 @@ func ValidateUUID(uuidStr string) error {
 	return nil
 }
 
-// ValidateHLSFilename validates filename safety with whitelist approach
+// ValidateHLSFilename validates filename safety with whitelist approach.
+// The only subdirectories allowed are the ABR rendition directories, such as
+// "720p/playlist.m3u8".
 func ValidateHLSFilename(filename string, allowedExts []string) error {
 	// 1. Check for empty filename
 	if filename == "" {
@@ -30,6 +33,15 @@ func ValidateHLSFilename(filename string, allowedExts []string) error {
 		return errors.New("invalid filename: null byte detected")
 	}
 
+	// Strip a known rendition directory; anything else with a separator is
+	// rejected below
+	if dir, name, found := strings.Cut(filename, "/"); found && livestream.Rendition(dir).IsValid() {
+		filename = name
+		if filename == "" {
+			return errors.New("filename cannot be empty")
+		}
+	}
+
 	// 3. Check for path separators
 	if strings.Contains(filename, "..") ||
 		strings.Contains(filename, "/") ||
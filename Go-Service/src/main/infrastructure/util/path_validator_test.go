@@ -61,6 +61,12 @@ func TestValidateHLSFilename(t *testing.T) {
 		{"Absolute path Unix", "/etc/passwd", []string{".txt"}, true},
 		{"Absolute path Windows", "C:\\Windows\\System32\\config.sys", []string{".sys"}, true},
 		{"Contains slash", "subdir/file.m3u8", []string{".m3u8"}, true},
+		{"Rendition playlist", "720p/playlist.m3u8", []string{".m3u8", ".ts"}, false},
+		{"Rendition segment", "audio/segment-12.ts", []string{".m3u8", ".ts"}, false},
+		{"Rendition nested", "720p/480p/playlist.m3u8", []string{".m3u8"}, true},
+		{"Rendition traversal", "720p/../record.m3u8", []string{".m3u8"}, true},
+		{"Rendition without file", "720p/", []string{".m3u8"}, true},
+		{"Unknown rendition", "4k/playlist.m3u8", []string{".m3u8"}, true},
 		{"Contains backslash", "subdir\\file.m3u8", []string{".m3u8"}, true},
 		{"Hidden path traversal", "file..m3u8", []string{".m3u8"}, true},
 		{"Double slash", "//etc/passwd", []string{".txt"}, true},
@@ -0,0 +1,119 @@
+package util
+
+import (
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/interface/recording"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	recordingsDirName = "recordings"
+	// RecordPlaylistName is the VOD playlist of a recording
+	RecordPlaylistName = "record.m3u8"
+)
+
+// RecordingDir returns the session directory a publish of the livestream
+// writing to streamDir is recorded into
+func RecordingDir(streamDir, id string) string {
+	return filepath.Join(streamDir, recordingsDirName, id)
+}
+
+// FileRecordingStore manages the recordings in
+// <hlsRoot>/<uuid>/recordings/<id>, so they are removed together with the
+// HLS output when the livestream is deleted
+type FileRecordingStore struct {
+	hlsRoot string
+}
+
+func NewFileRecordingStore(hlsRoot string) *FileRecordingStore {
+	return &FileRecordingStore{hlsRoot: hlsRoot}
+}
+
+func (s *FileRecordingStore) dir(uuid, id string) string {
+	return RecordingDir(filepath.Join(s.hlsRoot, uuid), id)
+}
+
+// Summarize counts the size of every file in the session directory, MP4
+// exports included
+func (s *FileRecordingStore) Summarize(uuid, id string) (recording.Summary, error) {
+	var summary recording.Summary
+	dir := s.dir(uuid, id)
+	playlist, err := os.ReadFile(filepath.Join(dir, RecordPlaylistName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return summary, errors.ErrNotFound
+		}
+		return summary, err
+	}
+	for _, line := range strings.Split(string(playlist), "\n") {
+		if !strings.HasPrefix(line, "#EXTINF:") {
+			continue
+		}
+		duration, _, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+		if seconds, err := strconv.ParseFloat(duration, 64); err == nil {
+			summary.DurationSeconds += seconds
+		}
+		summary.Segments++
+	}
+	if summary.Segments == 0 {
+		return summary, errors.ErrNotFound
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return summary, err
+	}
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && info.Mode().IsRegular() {
+			summary.SizeBytes += info.Size()
+		}
+	}
+	return summary, nil
+}
+
+// Finalize appends the EXT-X-ENDLIST the ingest writes when a publish ends
+func (s *FileRecordingStore) Finalize(uuid, id string) error {
+	path := filepath.Join(s.dir(uuid, id), RecordPlaylistName)
+	playlist, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.ErrNotFound
+		}
+		return err
+	}
+	if strings.Contains(string(playlist), "#EXT-X-ENDLIST") {
+		return nil
+	}
+	if len(playlist) > 0 && !strings.HasSuffix(string(playlist), "\n") {
+		playlist = append(playlist, '\n')
+	}
+	playlist = append(playlist, "#EXT-X-ENDLIST\n"...)
+	if err := os.WriteFile(path+".tmp", playlist, 0666); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Path checks the file is there, so a recording is never played back from a
+// half deleted directory. The name must have been validated by the caller.
+func (s *FileRecordingStore) Path(uuid, id, name string) (string, error) {
+	path := filepath.Join(s.dir(uuid, id), name)
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", errors.ErrNotFound
+		}
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", errors.ErrNotFound
+	}
+	return path, nil
+}
+
+func (s *FileRecordingStore) Delete(uuid, id string) error {
+	return os.RemoveAll(s.dir(uuid, id))
+}
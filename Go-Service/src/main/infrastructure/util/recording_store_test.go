@@ -0,0 +1,86 @@
+package util
+
+import (
+	"Go-Service/src/main/domain/entity/errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileRecordingStore_Summarize(t *testing.T) {
+	root := t.TempDir()
+	store := NewFileRecordingStore(root)
+	dir := RecordingDir(filepath.Join(root, "uuid-1"), "session-1")
+	os.MkdirAll(dir, 0777)
+
+	if _, err := store.Summarize("uuid-1", "session-1"); err != errors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound without a playlist, got %v", err)
+	}
+
+	playlist := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:3\n#EXT-X-MEDIA-SEQUENCE:0\n\n" +
+		"#EXTINF:2.500,\nstream-1.ts\n#EXT-X-DISCONTINUITY\n#EXTINF:1.250,\nstream-2.ts\n#EXT-X-ENDLIST\n"
+	os.WriteFile(filepath.Join(dir, RecordPlaylistName), []byte(playlist), 0666)
+	os.WriteFile(filepath.Join(dir, "stream-1.ts"), make([]byte, 100), 0666)
+	os.WriteFile(filepath.Join(dir, "stream-2.ts"), make([]byte, 50), 0666)
+
+	summary, err := store.Summarize("uuid-1", "session-1")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if summary.Segments != 2 || summary.DurationSeconds != 3.75 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if expected := int64(150 + len(playlist)); summary.SizeBytes != expected {
+		t.Fatalf("expected %d bytes, got %d", expected, summary.SizeBytes)
+	}
+
+	if err := store.Delete("uuid-1", "session-1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatal("session directory was not deleted")
+	}
+}
+
+func TestFileRecordingStore_Finalize(t *testing.T) {
+	root := t.TempDir()
+	store := NewFileRecordingStore(root)
+	dir := RecordingDir(filepath.Join(root, "uuid-1"), "session-1")
+	os.MkdirAll(dir, 0777)
+
+	if err := store.Finalize("uuid-1", "session-1"); err != errors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound without a playlist, got %v", err)
+	}
+
+	// Cut off by a crash
+	playlist := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-PLAYLIST-TYPE:EVENT\n#EXT-X-TARGETDURATION:3\n#EXT-X-MEDIA-SEQUENCE:0\n\n" +
+		"#EXTINF:2.500,\nstream-1.ts\n"
+	os.WriteFile(filepath.Join(dir, RecordPlaylistName), []byte(playlist), 0666)
+	for i := 0; i < 2; i++ {
+		if err := store.Finalize("uuid-1", "session-1"); err != nil {
+			t.Fatalf("Finalize failed: %v", err)
+		}
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, RecordPlaylistName))
+	if string(data) != playlist+"#EXT-X-ENDLIST\n" {
+		t.Fatalf("unexpected playlist:\n%s", data)
+	}
+}
+
+func TestFileRecordingStore_Path(t *testing.T) {
+	root := t.TempDir()
+	store := NewFileRecordingStore(root)
+	dir := RecordingDir(filepath.Join(root, "uuid-1"), "session-1")
+	os.MkdirAll(filepath.Join(dir, "sub.ts"), 0777)
+	os.WriteFile(filepath.Join(dir, "stream-1.ts"), make([]byte, 10), 0666)
+
+	path, err := store.Path("uuid-1", "session-1", "stream-1.ts")
+	if err != nil || path != filepath.Join(dir, "stream-1.ts") {
+		t.Fatalf("unexpected path %q: %v", path, err)
+	}
+	for _, name := range []string{"stream-2.ts", "sub.ts"} {
+		if _, err := store.Path("uuid-1", "session-1", name); err != errors.ErrNotFound {
+			t.Errorf("%s: expected ErrNotFound, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package util
+
+import (
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	thumbnailDirName       = "thumbnails"
+	thumbnailPosterName    = "poster.jpg"
+	thumbnailExt           = ".jpg"
+	defaultThumbnailNumber = 10
+)
+
+// FileThumbnailStore keeps the snapshots of a livestream in
+// <hlsRoot>/<uuid>/thumbnails, so they are removed together with the HLS
+// output when the livestream is deleted
+type FileThumbnailStore struct {
+	hlsRoot     string
+	historySize int
+}
+
+func NewFileThumbnailStore(hlsRoot string) *FileThumbnailStore {
+	return &FileThumbnailStore{hlsRoot: hlsRoot, historySize: defaultThumbnailNumber}
+}
+
+func (s *FileThumbnailStore) dir(uuid string) string {
+	return filepath.Join(s.hlsRoot, uuid, thumbnailDirName)
+}
+
+// LatestSegment skips LL-HLS parts, which may not start with a keyframe
+func (s *FileThumbnailStore) LatestSegment(uuid string) (string, error) {
+	dir := filepath.Join(s.hlsRoot, uuid)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", errors.ErrNotFound
+		}
+		return "", err
+	}
+	var latest string
+	var latestTime time.Time
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".ts" || isPartialSegment(name) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if latest == "" || info.ModTime().After(latestTime) {
+			latest, latestTime = name, info.ModTime()
+		}
+	}
+	if latest == "" {
+		return "", errors.ErrNotFound
+	}
+	return filepath.Join(dir, latest), nil
+}
+
+// isPartialSegment reports LL-HLS parts, named <stream>-<ms>-p<N>.ts
+func isPartialSegment(name string) bool {
+	base := strings.TrimSuffix(name, ".ts")
+	if i := strings.LastIndex(base, "-"); i >= 0 {
+		return strings.HasPrefix(base[i+1:], "p")
+	}
+	return false
+}
+
+func (s *FileThumbnailStore) Add(uuid string, capturedAt time.Time, capture func(outputPath string) error) (*livestream.Thumbnail, error) {
+	dir := s.dir(uuid)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	id := strconv.FormatInt(capturedAt.UnixMilli(), 10)
+	path := filepath.Join(dir, id+thumbnailExt)
+	// ffmpeg picks the muxer from the extension, so keep .jpg last
+	tmpPath := filepath.Join(dir, id+".tmp"+thumbnailExt)
+	if err := capture(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+
+	thumbnails, err := s.List(uuid)
+	if err != nil {
+		return nil, err
+	}
+	for _, expired := range thumbnails[min(len(thumbnails), s.historySize):] {
+		os.Remove(filepath.Join(dir, expired.ID+thumbnailExt))
+	}
+	return &livestream.Thumbnail{ID: id, CapturedAt: time.UnixMilli(capturedAt.UnixMilli())}, nil
+}
+
+func (s *FileThumbnailStore) List(uuid string) ([]livestream.Thumbnail, error) {
+	entries, err := os.ReadDir(s.dir(uuid))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []livestream.Thumbnail{}, nil
+		}
+		return nil, err
+	}
+	thumbnails := []livestream.Thumbnail{}
+	for _, entry := range entries {
+		id, found := strings.CutSuffix(entry.Name(), thumbnailExt)
+		if !found || !validThumbnailID(id) {
+			continue
+		}
+		ms, _ := strconv.ParseInt(id, 10, 64)
+		thumbnails = append(thumbnails, livestream.Thumbnail{ID: id, CapturedAt: time.UnixMilli(ms)})
+	}
+	sort.Slice(thumbnails, func(i, j int) bool {
+		return thumbnails[i].CapturedAt.After(thumbnails[j].CapturedAt)
+	})
+	return thumbnails, nil
+}
+
+func (s *FileThumbnailStore) Read(uuid, id string) ([]byte, error) {
+	if !validThumbnailID(id) {
+		return nil, errors.ErrInvalidInput
+	}
+	return readThumbnail(filepath.Join(s.dir(uuid), id+thumbnailExt))
+}
+
+func (s *FileThumbnailStore) SetPoster(uuid, id string) error {
+	data, err := s.Read(uuid, id)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir(uuid), thumbnailPosterName)
+	if err := os.WriteFile(path+".tmp", data, 0666); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+func (s *FileThumbnailStore) ReadPoster(uuid string) ([]byte, error) {
+	return readThumbnail(filepath.Join(s.dir(uuid), thumbnailPosterName))
+}
+
+func readThumbnail(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, errors.ErrNotFound
+	}
+	return data, err
+}
+
+// validThumbnailID only accepts the Unix millisecond IDs written by Add
+func validThumbnailID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, c := range id {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
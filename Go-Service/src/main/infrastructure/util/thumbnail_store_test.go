@@ -0,0 +1,130 @@
+package util
+
+import (
+	"Go-Service/src/main/domain/entity/errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeJPEG(data string) func(outputPath string) error {
+	return func(outputPath string) error {
+		return os.WriteFile(outputPath, []byte(data), 0666)
+	}
+}
+
+func TestFileThumbnailStore_LatestSegment(t *testing.T) {
+	root := t.TempDir()
+	store := NewFileThumbnailStore(root)
+	if _, err := store.LatestSegment("uuid-1"); err != errors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound without output, got %v", err)
+	}
+
+	dir := filepath.Join(root, "uuid-1")
+	os.MkdirAll(filepath.Join(dir, "720p"), 0777)
+	base := time.Now().Add(-time.Minute)
+	for i, name := range []string{"stream-1000-1.ts", "stream-2000-2.ts", "stream-3000-p7.ts", "playlist.m3u8"} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte("ts"), 0666)
+		modTime := base.Add(time.Duration(i) * time.Second)
+		os.Chtimes(path, modTime, modTime)
+	}
+
+	segment, err := store.LatestSegment("uuid-1")
+	if err != nil || segment != filepath.Join(dir, "stream-2000-2.ts") {
+		t.Fatalf("expected the newest full segment, got %q, %v", segment, err)
+	}
+}
+
+func TestFileThumbnailStore_KeepsShortHistory(t *testing.T) {
+	store := NewFileThumbnailStore(t.TempDir())
+	store.historySize = 3
+	base := time.UnixMilli(1700000000000)
+	for i := 0; i < 5; i++ {
+		if _, err := store.Add("uuid-1", base.Add(time.Duration(i)*time.Second), writeJPEG("jpeg")); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	thumbnails, err := store.List("uuid-1")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	var ids []string
+	for _, thumbnail := range thumbnails {
+		ids = append(ids, thumbnail.ID)
+	}
+	if strings.Join(ids, ",") != "1700000004000,1700000003000,1700000002000" {
+		t.Fatalf("unexpected history %v", ids)
+	}
+	if _, err := store.Read("uuid-1", "1700000000000"); err != errors.ErrNotFound {
+		t.Fatalf("oldest snapshot should be pruned, got %v", err)
+	}
+}
+
+func TestFileThumbnailStore_FailedCaptureLeavesNothing(t *testing.T) {
+	store := NewFileThumbnailStore(t.TempDir())
+	_, err := store.Add("uuid-1", time.Now(), func(outputPath string) error {
+		os.WriteFile(outputPath, []byte("partial"), 0666)
+		return os.ErrInvalid
+	})
+	if err == nil {
+		t.Fatal("expected the capture error")
+	}
+	if thumbnails, _ := store.List("uuid-1"); len(thumbnails) != 0 {
+		t.Fatalf("failed capture was kept: %v", thumbnails)
+	}
+	entries, _ := os.ReadDir(store.dir("uuid-1"))
+	if len(entries) != 0 {
+		t.Fatalf("temporary file was left behind: %v", entries)
+	}
+}
+
+func TestFileThumbnailStore_Poster(t *testing.T) {
+	store := NewFileThumbnailStore(t.TempDir())
+	if _, err := store.ReadPoster("uuid-1"); err != errors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound without a poster, got %v", err)
+	}
+	thumbnail, _ := store.Add("uuid-1", time.Now(), writeJPEG("frame"))
+
+	if err := store.SetPoster("uuid-1", "../../etc/passwd"); err != errors.ErrInvalidInput {
+		t.Fatalf("expected ErrInvalidInput, got %v", err)
+	}
+	if err := store.SetPoster("uuid-1", "1"); err != errors.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := store.SetPoster("uuid-1", thumbnail.ID); err != nil {
+		t.Fatalf("SetPoster failed: %v", err)
+	}
+	if poster, err := store.ReadPoster("uuid-1"); err != nil || string(poster) != "frame" {
+		t.Fatalf("unexpected poster %q, %v", poster, err)
+	}
+	// The poster is not part of the history
+	if thumbnails, _ := store.List("uuid-1"); len(thumbnails) != 1 {
+		t.Fatalf("unexpected history %v", thumbnails)
+	}
+}
+
+func TestCaptureThumbnail_Args(t *testing.T) {
+	library := fakeFfmpeg(t, `echo "$@" > "$(dirname "$0")/args"; echo jpeg > "$(eval echo \${$#})"`)
+	output := filepath.Join(t.TempDir(), "1.jpg")
+	if err := library.CaptureThumbnail("/hls/uuid-1/stream-1.ts", output); err != nil {
+		t.Fatalf("CaptureThumbnail failed: %v", err)
+	}
+	if data, _ := os.ReadFile(output); string(data) != "jpeg\n" {
+		t.Fatalf("snapshot not written: %q", data)
+	}
+	args, _ := os.ReadFile(filepath.Join(filepath.Dir(library.binary), "args"))
+	for _, want := range []string{"-i /hls/uuid-1/stream-1.ts", "-frames:v 1", "scale=-2:360"} {
+		if !strings.Contains(string(args), want) {
+			t.Fatalf("ffmpeg args missing %q: %s", want, args)
+		}
+	}
+
+	failing := fakeFfmpeg(t, `echo "Invalid data found" >&2; exit 1`)
+	if err := failing.CaptureThumbnail("/missing.ts", output); err == nil || !strings.Contains(err.Error(), "Invalid data found") {
+		t.Fatalf("expected ffmpeg's stderr in the error, got %v", err)
+	}
+}
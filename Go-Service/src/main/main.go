@@ -7,9 +7,20 @@ import (
 	"Go-Service/src/main/infrastructure/router"
 	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+// shutdownTimeout bounds how long open requests, such as FLV playback, may
+// hold up a shutdown
+const shutdownTimeout = 10 * time.Second
+
 func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	// Load config first so LOG_LEVEL is available
 	initializer.InitConfig()
 	// Initialize logger with config loaded
@@ -25,13 +36,25 @@ func main() {
 	logger.Info(context.TODO(), "start InitLiveStreamService")
 	initializer.InitLiveStreamService(logger, initializer.GormDB)
 	logger.Info(context.TODO(), "start InitCronJob")
-	initializer.InitCronJob(logger, initializer.GormDB)
+	initializer.InitCronJob(ctx, logger, initializer.GormDB)
 	logger.Info(context.TODO(), "start router")
 	r := router.NewRouter(initializer.GormDB, initializer.Log, initializer.LiveStreamService, initializer.RedisClient)
 	logger.Info(context.TODO(), "Server starting...")
 
 	serverPort := config.AppConfig.Server.Port
-	if err := r.Run(fmt.Sprintf(":%d", serverPort)); err != nil {
-		logger.Fatal(context.TODO(), err.Error())
+	server := &http.Server{Addr: fmt.Sprintf(":%d", serverPort), Handler: r}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatal(context.TODO(), err.Error())
+		}
+	}()
+
+	<-ctx.Done()
+	logger.Info(context.TODO(), "Server shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error(context.TODO(), "Server shutdown: "+err.Error())
 	}
+	initializer.StopCronJob()
 }
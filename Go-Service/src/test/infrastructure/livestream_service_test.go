@@ -13,7 +13,7 @@ func TestLivestreamService(t *testing.T) {
 	initializer.InitLog()
 	// Setup
 
-	var service stream.ILivestreamService = livestream.NewLivestreamService(initializer.Log)
+	var service stream.ILivestreamService = livestream.NewLivestreamService(initializer.Log, ":1935", nil)
 
 	// Start the service
 	err := service.StartService()
@@ -33,7 +33,7 @@ func TestLivestreamService(t *testing.T) {
 	time.Sleep(time.Second)
 
 	// Open a stream
-	service.OpenStream("test1", "1111111", "test", "test2", true)
+	service.OpenStream("test1", "1111111", "test", stream.StreamOptions{IsRecord: true})
 	// Wait for 30 seconds
 	time.Sleep(30 * time.Second)
 
@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"Go-Service/src/main/application/interface/stream"
+	"Go-Service/src/main/application/usecase"
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/test/usecase/mock_data"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupBroadcastSession() (*usecase.BroadcastSessionUsecase, *mock_data.MockBroadcastSessionRepository) {
+	mockRepo := new(mock_data.MockBroadcastSessionRepository)
+	return usecase.NewBroadcastSessionUsecase(mockRepo, new(mock_data.MockLogger)), mockRepo
+}
+
+func TestHandlePublishEvent_StartCreatesSession(t *testing.T) {
+	useCase, mockRepo := setupBroadcastSession()
+	startedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	mockRepo.On("Create", &livestream.BroadcastSession{
+		ID:             "session-1",
+		LivestreamUUID: "livestream123",
+		StartedAt:      startedAt,
+		PublisherIP:    "203.0.113.7",
+	}).Return(nil)
+
+	useCase.HandlePublishEvent(stream.PublishEvent{
+		Type:       stream.PublishStarted,
+		SessionID:  "session-1",
+		UUID:       "livestream123",
+		RemoteAddr: "203.0.113.7:51234",
+		At:         startedAt,
+	})
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandlePublishEvent_StopEndsSession(t *testing.T) {
+	useCase, mockRepo := setupBroadcastSession()
+	endedAt := time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+
+	mockRepo.On("End", "session-1", endedAt).Return(nil)
+
+	useCase.HandlePublishEvent(stream.PublishEvent{
+		Type:      stream.PublishStopped,
+		SessionID: "session-1",
+		UUID:      "livestream123",
+		At:        endedAt,
+	})
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestRecordViewerCount(t *testing.T) {
+	useCase, mockRepo := setupBroadcastSession()
+
+	mockRepo.On("UpdatePeakViewers", "livestream123", 42).Return(nil)
+
+	err := useCase.RecordViewerCount(context.Background(), "livestream123", 42)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCloseDanglingSessions_Error(t *testing.T) {
+	useCase, mockRepo := setupBroadcastSession()
+
+	mockRepo.On("CloseOpen", mock.AnythingOfType("time.Time")).Return(errors.ErrInternal)
+
+	err := useCase.CloseDanglingSessions(context.Background())
+
+	assert.Equal(t, errors.ErrInternal, err)
+}
@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"Go-Service/src/main/application/usecase"
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/domain/interface/libarary/ffmpeg"
+	"Go-Service/src/test/usecase/mock_data"
+	"context"
+	goErrors "errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type ConversionTestSetup struct {
+	MockJobRepo        *mock_data.MockConversionJobRepository
+	MockRecordingRepo  *mock_data.MockRecordingRepository
+	MockLivestreamRepo *mock_data.MockLivestreamRepository
+	MockFfmpegLibrary  *mock_data.MockFfmpegLibrary
+	UseCase            *usecase.ConversionUsecase
+}
+
+func setupConversion() *ConversionTestSetup {
+	mockJobRepo := new(mock_data.MockConversionJobRepository)
+	mockRecordingRepo := new(mock_data.MockRecordingRepository)
+	mockLivestreamRepo := new(mock_data.MockLivestreamRepository)
+	mockFfmpegLibrary := new(mock_data.MockFfmpegLibrary)
+	return &ConversionTestSetup{
+		MockJobRepo:        mockJobRepo,
+		MockRecordingRepo:  mockRecordingRepo,
+		MockLivestreamRepo: mockLivestreamRepo,
+		MockFfmpegLibrary:  mockFfmpegLibrary,
+		UseCase:            usecase.NewConversionUsecase(mockJobRepo, mockRecordingRepo, mockLivestreamRepo, new(mock_data.MockLogger), mockFfmpegLibrary, "/srv/hls", 2, 3),
+	}
+}
+
+// claimed sets up a claimed job of session-1 on its given attempt
+func (s *ConversionTestSetup) claimed(attempts int) *livestream.ConversionJob {
+	job := &livestream.ConversionJob{
+		ID:             "job-1",
+		RecordingID:    "session-1",
+		LivestreamUUID: "livestream123",
+		Status:         livestream.ConversionStatusRunning,
+		Attempts:       attempts,
+	}
+	s.MockJobRepo.On("Claim", mock.Anything, mock.Anything).Return(job, nil)
+	s.MockRecordingRepo.On("GetByID", "session-1").Return(&livestream.Recording{ID: "session-1", LivestreamUUID: "livestream123", DurationSeconds: 60}, nil)
+	s.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123", Title: "Talk/Show"}, nil)
+	return job
+}
+
+// ================================================================================
+// RunNext (7 tests)
+// ================================================================================
+
+func TestRunNext_ConvertsAndReportsProgress(t *testing.T) {
+	setup := setupConversion()
+	setup.claimed(1)
+	setup.MockFfmpegLibrary.On("ConvertToMp4", mock.Anything, mock.MatchedBy(func(job ffmpeg.ConvertJob) bool {
+		return job.PlaylistPath == "/srv/hls/livestream123/recordings/session-1/record.m3u8" &&
+			job.OutputPath == "/srv/hls/livestream123/recordings/session-1/Talk_Show.mp4" &&
+			job.DurationSeconds == 60
+	})).Run(func(args mock.Arguments) {
+		job := args.Get(1).(ffmpeg.ConvertJob)
+		job.OnProgress(0.25)
+		// Throttled
+		job.OnProgress(0.5)
+	}).Return(nil)
+	setup.MockJobRepo.On("UpdateProgress", "job-1", 0.25).Return(nil)
+	setup.MockJobRepo.On("Update", mock.MatchedBy(func(job *livestream.ConversionJob) bool {
+		return job.Status == livestream.ConversionStatusDone && job.Progress == 1 && job.LastError == ""
+	})).Return(nil)
+
+	assert.True(t, setup.UseCase.RunNext(context.Background()))
+	setup.MockJobRepo.AssertExpectations(t)
+	setup.MockJobRepo.AssertNumberOfCalls(t, "UpdateProgress", 1)
+}
+
+func TestRunNext_FailureIsRetriedWithBackoff(t *testing.T) {
+	setup := setupConversion()
+	setup.claimed(2)
+	setup.MockFfmpegLibrary.On("ConvertToMp4", mock.Anything, mock.Anything).Return(goErrors.New("exit status 1: Invalid data"))
+	before := time.Now()
+	setup.MockJobRepo.On("Update", mock.MatchedBy(func(job *livestream.ConversionJob) bool {
+		// Second attempt failed: 30s doubled
+		return job.Status == livestream.ConversionStatusQueued &&
+			job.LastError == "exit status 1: Invalid data" &&
+			!job.NextAttemptAt.Before(before.Add(time.Minute))
+	})).Return(nil)
+
+	assert.True(t, setup.UseCase.RunNext(context.Background()))
+	setup.MockJobRepo.AssertExpectations(t)
+}
+
+func TestRunNext_LastAttemptFails(t *testing.T) {
+	setup := setupConversion()
+	setup.claimed(3)
+	setup.MockFfmpegLibrary.On("ConvertToMp4", mock.Anything, mock.Anything).Return(goErrors.New("exit status 1"))
+	setup.MockJobRepo.On("Update", mock.MatchedBy(func(job *livestream.ConversionJob) bool {
+		return job.Status == livestream.ConversionStatusFailed && job.LastError == "exit status 1"
+	})).Return(nil)
+
+	assert.True(t, setup.UseCase.RunNext(context.Background()))
+	setup.MockJobRepo.AssertExpectations(t)
+}
+
+func TestRunNext_EmptyQueue(t *testing.T) {
+	setup := setupConversion()
+	setup.MockJobRepo.On("Claim", mock.Anything, mock.Anything).Return(nil, errors.ErrNotFound)
+
+	assert.False(t, setup.UseCase.RunNext(context.Background()))
+	setup.MockFfmpegLibrary.AssertNotCalled(t, "ConvertToMp4", mock.Anything, mock.Anything)
+}
+
+func TestRunNext_ClaimLeasesJob(t *testing.T) {
+	setup := setupConversion()
+	before := time.Now()
+	setup.MockJobRepo.On("Claim", mock.Anything, mock.MatchedBy(func(lockedUntil time.Time) bool {
+		return !lockedUntil.Before(before.Add(time.Minute))
+	})).Return(nil, errors.ErrNotFound)
+
+	assert.False(t, setup.UseCase.RunNext(context.Background()))
+	setup.MockJobRepo.AssertExpectations(t)
+}
+
+func TestRunNext_ShutdownReleasesJob(t *testing.T) {
+	setup := setupConversion()
+	setup.claimed(2)
+	ctx, cancel := context.WithCancel(context.Background())
+	setup.MockFfmpegLibrary.On("ConvertToMp4", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		cancel()
+	}).Return(context.Canceled)
+	setup.MockJobRepo.On("Release", "job-1", 2, mock.Anything).Return(nil)
+
+	assert.False(t, setup.UseCase.RunNext(ctx))
+	setup.MockJobRepo.AssertExpectations(t)
+	setup.MockJobRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestRunNext_LostLease(t *testing.T) {
+	setup := setupConversion()
+	setup.claimed(1)
+	setup.MockFfmpegLibrary.On("ConvertToMp4", mock.Anything, mock.Anything).Return(nil)
+	// Another worker claimed the job after the lease expired
+	setup.MockJobRepo.On("Update", mock.Anything).Return(errors.ErrNotFound)
+
+	assert.True(t, setup.UseCase.RunNext(context.Background()))
+	setup.MockJobRepo.AssertExpectations(t)
+}
+
+// ================================================================================
+// Start (1 test)
+// ================================================================================
+
+func TestStart_WorkersStopWithContext(t *testing.T) {
+	setup := setupConversion()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	setup.UseCase.Start(ctx)
+	setup.UseCase.Wait()
+
+	setup.MockJobRepo.AssertNotCalled(t, "Claim", mock.Anything, mock.Anything)
+}
@@ -1,15 +1,21 @@
 package usecase
 
 import (
+	"Go-Service/src/main/application/dto"
 	"Go-Service/src/main/application/dto/config"
 	livestreamDto "Go-Service/src/main/application/dto/livestream"
+	"Go-Service/src/main/application/interface/stream"
 	"Go-Service/src/main/application/usecase"
 	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/domain/interface/file_cache"
 	"github.com/cool9850311/StreamPlatformLite-Core/pkg/role"
 	"Go-Service/src/test/usecase/mock_data"
+	"Go-Service/src/main/infrastructure/util"
 	"context"
+	"fmt"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"Go-Service/src/main/domain/entity/chat"
 	"Go-Service/src/main/domain/entity/errors"
@@ -26,44 +32,60 @@ import (
 type LivestreamTestSetup struct {
 	MockRepo             *mock_data.MockLivestreamRepository
 	MockLogger           *mock_data.MockLogger
+	MockStreamService    *mock_data.MockLivestreamService
 	MockViewerCountCache *mock_data.MockViewerCountCache
 	MockChatCache        *mock_data.MockChatCache
 	MockFileCache        *mock_data.MockFileCache
-	MockFfmpegLibrary    *mock_data.MockFfmpegLibrary
+	MockShareLinkRepo    *mock_data.MockShareLinkRepository
+	MockJWTGenerator     *mock_data.MockJWTGenerator
+	MockHLSKeyStore      *mock_data.MockHLSKeyStore
+	MockRecordingRepo    *mock_data.MockRecordingRepository
+	MockConversionRepo   *mock_data.MockConversionJobRepository
 	UseCase              *usecase.LivestreamUsecase
 }
 
 func setupLivestream() *LivestreamTestSetup {
+	return setupLivestreamWithConfig(nil)
+}
+
+// setupLivestreamWithConfig lets a test adjust the default config before the
+// usecase is built
+func setupLivestreamWithConfig(configure func(cfg *config.Config)) *LivestreamTestSetup {
 	mockRepo := new(mock_data.MockLivestreamRepository)
 	mockLogger := new(mock_data.MockLogger)
 	mockStreamService := new(mock_data.MockLivestreamService)
 	mockViewerCountCache := new(mock_data.MockViewerCountCache)
 	mockChatCache := new(mock_data.MockChatCache)
 	mockFileCache := new(mock_data.MockFileCache)
-	mockFfmpegLibrary := new(mock_data.MockFfmpegLibrary)
-	cfg := config.Config{
-		Server: struct {
-			Port         int    `mapstructure:"port"`
-			Domain       string `mapstructure:"domain"`
-			HTTPS        bool   `mapstructure:"https" default:"false"`
-			EnableGinLog bool   `mapstructure:"enable_gin_log" default:"true"`
-			LogLevel     string `mapstructure:"log_level" default:"INFO"`
-		}{
-			Domain:   "localhost",
-			Port:     8080,
-			HTTPS:    false,
-			LogLevel: "INFO",
-		},
-	}
-	useCase := usecase.NewLivestreamUsecase(mockRepo, mockLogger, cfg, mockStreamService, mockViewerCountCache, mockChatCache, mockFileCache, mockFfmpegLibrary)
+	mockShareLinkRepo := new(mock_data.MockShareLinkRepository)
+	mockJWTGenerator := new(mock_data.MockJWTGenerator)
+	mockHLSKeyStore := new(mock_data.MockHLSKeyStore)
+	mockRecordingRepo := new(mock_data.MockRecordingRepository)
+	mockConversionRepo := new(mock_data.MockConversionJobRepository)
+	cfg := config.Config{}
+	cfg.Server.Domain = "localhost"
+	cfg.Server.Port = 8080
+	cfg.Server.HTTPS = false
+	cfg.Server.LogLevel = "INFO"
+	cfg.RTMP.AdvertisedHost = "rtmp.example.com"
+	cfg.RTMP.AdvertisedPort = 1937
+	if configure != nil {
+		configure(&cfg)
+	}
+	useCase := usecase.NewLivestreamUsecase(mockRepo, mockLogger, cfg, mockStreamService, mockViewerCountCache, mockChatCache, mockFileCache, mockShareLinkRepo, mockJWTGenerator, mockHLSKeyStore, mockRecordingRepo, mockConversionRepo)
 
 	return &LivestreamTestSetup{
 		MockRepo:             mockRepo,
 		MockLogger:           mockLogger,
+		MockStreamService:    mockStreamService,
 		MockViewerCountCache: mockViewerCountCache,
 		MockChatCache:        mockChatCache,
 		MockFileCache:        mockFileCache,
-		MockFfmpegLibrary:    mockFfmpegLibrary,
+		MockShareLinkRepo:    mockShareLinkRepo,
+		MockJWTGenerator:     mockJWTGenerator,
+		MockHLSKeyStore:      mockHLSKeyStore,
+		MockRecordingRepo:    mockRecordingRepo,
+		MockConversionRepo:   mockConversionRepo,
 		UseCase:              useCase,
 	}
 }
@@ -208,7 +230,7 @@ func TestGetLivestreamByOwnerID_Anonymous_Unauthorized(t *testing.T) {
 }
 
 // ================================================================================
-// API: CreateLivestream (6 tests)
+// API: CreateLivestream (9 tests)
 // ================================================================================
 
 // Role: Admin - Success
@@ -222,7 +244,7 @@ func TestCreateLivestream_Admin_Success(t *testing.T) {
 		Information: "Test Livestream",
 		Visibility:  "public",
 	}
-	setup.MockRepo.On("GetOne").Return(nil, errors.ErrNotFound)
+	setup.MockRepo.On("GetByOwnerID", "user123").Return(nil, errors.ErrNotFound)
 	setup.MockRepo.On("Create", mock.AnythingOfType("*livestream.Livestream")).Return(nil)
 
 	_, err := setup.UseCase.CreateLivestream(ctx, testLivestream, "user123", role.Admin)
@@ -231,6 +253,44 @@ func TestCreateLivestream_Admin_Success(t *testing.T) {
 	setup.MockRepo.AssertExpectations(t)
 }
 
+// Role: Admin - Unknown rendition in the ABR ladder
+func TestCreateLivestream_Admin_InvalidRenditions(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	testLivestream := &livestreamDto.LivestreamCreateDTO{
+		Name:       "Test Livestream",
+		Visibility: "public",
+		Renditions: []livestream.Rendition{livestream.Rendition720p, "4k"},
+	}
+
+	_, err := setup.UseCase.CreateLivestream(ctx, testLivestream, "user123", role.Admin)
+
+	assert.Equal(t, errors.ErrInvalidInput, err)
+	setup.MockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+// Role: Admin - unset muxer settings are stored as the defaults
+func TestCreateLivestream_Admin_DefaultMuxerSettings(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	testLivestream := &livestreamDto.LivestreamCreateDTO{
+		Name:        "Test Livestream",
+		Visibility:  "public",
+		FragmentNum: 10,
+	}
+	setup.MockRepo.On("GetByOwnerID", "user123").Return(nil, errors.ErrNotFound)
+	setup.MockRepo.On("Create", mock.MatchedBy(func(ls *livestream.Livestream) bool {
+		return ls.FragmentDurationMs == 500 && ls.FragmentNum == 10 && ls.CleanupMode == livestream.CleanupModeAsap
+	})).Return(nil)
+
+	_, err := setup.UseCase.CreateLivestream(ctx, testLivestream, "user123", role.Admin)
+
+	assert.NoError(t, err)
+	setup.MockRepo.AssertExpectations(t)
+}
+
 // Role: Admin - Already Exists
 func TestCreateLivestream_Admin_AlreadyExists(t *testing.T) {
 	setup := setupLivestream()
@@ -243,8 +303,8 @@ func TestCreateLivestream_Admin_AlreadyExists(t *testing.T) {
 		Visibility:  "public",
 	}
 
-	// Mock GetOne to return a livestream, indicating it already exists
-	setup.MockRepo.On("GetOne").Return(&livestream.Livestream{}, nil)
+	// Mock GetByOwnerID to return a livestream, indicating the owner already has one
+	setup.MockRepo.On("GetByOwnerID", "user123").Return(&livestream.Livestream{}, nil)
 
 	_, err := setup.UseCase.CreateLivestream(ctx, testLivestream, "user123", role.Admin)
 
@@ -253,6 +313,30 @@ func TestCreateLivestream_Admin_AlreadyExists(t *testing.T) {
 	setup.MockRepo.AssertExpectations(t)
 }
 
+// Role: Admin - Create For Another Owner
+func TestCreateLivestream_Admin_ForOtherOwner(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	testLivestream := &livestreamDto.LivestreamCreateDTO{
+		Name:        "Second Channel",
+		Title:       "Second Channel",
+		Information: "Second Channel",
+		Visibility:  "public",
+		OwnerUserID: "owner456",
+	}
+	setup.MockRepo.On("GetByOwnerID", "owner456").Return(nil, errors.ErrNotFound)
+	setup.MockRepo.On("Create", mock.MatchedBy(func(ls *livestream.Livestream) bool {
+		return ls.OwnerUserId == "owner456"
+	})).Return(nil)
+
+	result, err := setup.UseCase.CreateLivestream(ctx, testLivestream, "admin123", role.Admin)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.UUID)
+	setup.MockRepo.AssertExpectations(t)
+}
+
 // Role: User (Unauthorized)
 func TestCreateLivestream_User_Unauthorized(t *testing.T) {
 	setup := setupLivestream()
@@ -326,8 +410,12 @@ func TestCreateLivestream_Anonymous_Unauthorized(t *testing.T) {
 	assert.Nil(t, result)
 }
 
+// defaultStreamOptions are the ingest options of a livestream saved without
+// muxer settings
+var defaultStreamOptions = stream.StreamOptions{FragmentDurationMs: 500, FragmentNum: 5, CleanupMode: livestream.CleanupModeAsap}
+
 // ================================================================================
-// API: UpdateLivestream (5 tests)
+// API: UpdateLivestream (10 tests)
 // ================================================================================
 
 // Role: Admin
@@ -341,11 +429,115 @@ func TestUpdateLivestream_Admin_Success(t *testing.T) {
 	}
 
 	setup.MockRepo.On("Update", testLivestream).Return(nil)
+	setup.MockStreamService.On("UpdateStreamOptions", "livestream123", defaultStreamOptions).Return(nil)
+
+	err := setup.UseCase.UpdateLivestream(ctx, testLivestream, role.Admin)
+
+	assert.NoError(t, err)
+	setup.MockRepo.AssertExpectations(t)
+	setup.MockStreamService.AssertExpectations(t)
+}
+
+// Role: Admin - switching to low latency reaches the stream service; a
+// stream that was never opened is not an error
+func TestUpdateLivestream_Admin_LowLatency(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	testLivestream := &livestream.Livestream{
+		UUID:       "livestream123",
+		IsRecord:   true,
+		LowLatency: true,
+	}
+
+	setup.MockRepo.On("Update", testLivestream).Return(nil)
+	setup.MockStreamService.On("UpdateStreamOptions", "livestream123", stream.StreamOptions{IsRecord: true, LowLatency: true, FragmentDurationMs: 500, FragmentNum: 5, CleanupMode: livestream.CleanupModeAsap}).Return(errors.ErrNotFound)
+
+	err := setup.UseCase.UpdateLivestream(ctx, testLivestream, role.Admin)
+
+	assert.NoError(t, err)
+	setup.MockRepo.AssertExpectations(t)
+	setup.MockStreamService.AssertExpectations(t)
+}
+
+// Role: Admin - the ABR ladder is stored with the livestream
+func TestUpdateLivestream_Admin_Renditions(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	testLivestream := &livestream.Livestream{
+		UUID:       "livestream123",
+		Renditions: []livestream.Rendition{livestream.Rendition1080p, livestream.Rendition480p, livestream.RenditionAudioOnly},
+	}
+
+	setup.MockRepo.On("Update", testLivestream).Return(nil)
+	setup.MockStreamService.On("UpdateStreamOptions", "livestream123", defaultStreamOptions).Return(nil)
+
+	err := setup.UseCase.UpdateLivestream(ctx, testLivestream, role.Admin)
+
+	assert.NoError(t, err)
+	setup.MockRepo.AssertExpectations(t)
+}
+
+// Role: Admin - a rendition listed twice
+func TestUpdateLivestream_Admin_DuplicateRenditions(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	testLivestream := &livestream.Livestream{
+		UUID:       "livestream123",
+		Renditions: []livestream.Rendition{livestream.Rendition720p, livestream.Rendition720p},
+	}
+
+	err := setup.UseCase.UpdateLivestream(ctx, testLivestream, role.Admin)
+
+	assert.Equal(t, errors.ErrInvalidInput, err)
+	setup.MockRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+// Role: Admin - muxer settings reach the stream service for the next publish
+func TestUpdateLivestream_Admin_MuxerSettings(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	testLivestream := &livestream.Livestream{
+		UUID:               "livestream123",
+		FragmentDurationMs: 2000,
+		FragmentNum:        8,
+		CleanupMode:        livestream.CleanupModeInTheEnd,
+		DVRWindowSeconds:   7200,
+	}
+
+	setup.MockRepo.On("Update", testLivestream).Return(nil)
+	setup.MockStreamService.On("UpdateStreamOptions", "livestream123", stream.StreamOptions{FragmentDurationMs: 2000, FragmentNum: 8, CleanupMode: livestream.CleanupModeInTheEnd, DVRWindowSeconds: 7200}).Return(nil)
 
 	err := setup.UseCase.UpdateLivestream(ctx, testLivestream, role.Admin)
 
 	assert.NoError(t, err)
 	setup.MockRepo.AssertExpectations(t)
+	setup.MockStreamService.AssertExpectations(t)
+}
+
+// Role: Admin - muxer settings out of range
+func TestUpdateLivestream_Admin_InvalidMuxerSettings(t *testing.T) {
+	for _, testLivestream := range []*livestream.Livestream{
+		{UUID: "livestream123", FragmentDurationMs: 100},
+		{UUID: "livestream123", FragmentDurationMs: 20000},
+		{UUID: "livestream123", FragmentNum: 1},
+		{UUID: "livestream123", FragmentNum: 31},
+		{UUID: "livestream123", FragmentDurationMs: -500},
+		{UUID: "livestream123", CleanupMode: "sometimes"},
+		{UUID: "livestream123", DVRWindowSeconds: 30},
+		{UUID: "livestream123", DVRWindowSeconds: 7 * 60 * 60},
+		{UUID: "livestream123", Encrypted: true, LowLatency: true},
+	} {
+		setup := setupLivestream()
+
+		err := setup.UseCase.UpdateLivestream(context.Background(), testLivestream, role.Admin)
+
+		assert.Equal(t, errors.ErrInvalidInput, err)
+		setup.MockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	}
 }
 
 // Role: Editor (Unauthorized)
@@ -434,11 +626,14 @@ func TestDeleteLivestream_Admin_Success(t *testing.T) {
 	ctx := context.Background()
 
 	setup.MockRepo.On("Delete", "livestream123").Return(nil)
+	setup.MockFileCache.On("StopStream", "livestream123").Return()
 
 	err := setup.UseCase.DeleteLivestream(ctx, "livestream123", role.Admin)
 
 	assert.NoError(t, err)
 	setup.MockRepo.AssertExpectations(t)
+	// Cached files and the refresher of the stream go with it
+	setup.MockFileCache.AssertExpectations(t)
 }
 
 // Role: Editor (Unauthorized)
@@ -486,7 +681,165 @@ func TestDeleteLivestream_Anonymous_Unauthorized(t *testing.T) {
 }
 
 // ================================================================================
-// API: GetOne (7 tests)
+// API: RotateStreamKey / RevokeStreamKey (6 tests)
+// ================================================================================
+
+// Role: Admin - Rotate keeps the publisher connected
+func TestRotateStreamKey_Admin_Success(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	testLivestream := &livestream.Livestream{UUID: "livestream123", APIKey: "old-key"}
+	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
+	setup.MockRepo.On("UpdateAPIKey", "livestream123", mock.AnythingOfType("string")).Return(nil)
+	setup.MockStreamService.On("UpdateStreamKey", "livestream123", mock.AnythingOfType("string"), false).Return(nil)
+
+	result, err := setup.UseCase.RotateStreamKey(ctx, "livestream123", false, role.Admin)
+
+	assert.NoError(t, err)
+	newKey := setup.MockRepo.Calls[1].Arguments.String(1)
+	assert.NotEmpty(t, newKey)
+	assert.NotEqual(t, "old-key", newKey)
+	assert.Equal(t, newKey, setup.MockStreamService.Calls[0].Arguments.String(1))
+	// The push URL uses the advertised host and port, not the listen address
+	assert.Equal(t, "rtmp://rtmp.example.com:1937/"+newKey, result.StreamPushURL)
+	setup.MockRepo.AssertExpectations(t)
+	setup.MockStreamService.AssertExpectations(t)
+}
+
+// Role: Admin - RTMPS enabled
+func TestRotateStreamKey_Admin_RTMPS(t *testing.T) {
+	setup := setupLivestreamWithConfig(func(cfg *config.Config) {
+		cfg.RTMP.TLSEnabled = true
+		cfg.RTMP.TLSAdvertisedPort = 443
+	})
+	ctx := context.Background()
+
+	setup.MockRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123"}, nil)
+	setup.MockRepo.On("UpdateAPIKey", "livestream123", mock.AnythingOfType("string")).Return(nil)
+	setup.MockStreamService.On("UpdateStreamKey", "livestream123", mock.AnythingOfType("string"), false).Return(nil)
+
+	result, err := setup.UseCase.RotateStreamKey(ctx, "livestream123", false, role.Admin)
+
+	assert.NoError(t, err)
+	newKey := setup.MockRepo.Calls[1].Arguments.String(1)
+	assert.Equal(t, "rtmps://rtmp.example.com:443/"+newKey, result.StreamPushURL)
+}
+
+// Role: Admin - Rotate and drop the current publisher
+func TestRotateStreamKey_Admin_DropPublisher(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	setup.MockRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123"}, nil)
+	setup.MockRepo.On("UpdateAPIKey", "livestream123", mock.AnythingOfType("string")).Return(nil)
+	setup.MockStreamService.On("UpdateStreamKey", "livestream123", mock.AnythingOfType("string"), true).Return(nil)
+
+	_, err := setup.UseCase.RotateStreamKey(ctx, "livestream123", true, role.Admin)
+
+	assert.NoError(t, err)
+	setup.MockStreamService.AssertExpectations(t)
+}
+
+// Role: Admin - Unknown livestream
+func TestRotateStreamKey_Admin_NotFound(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	setup.MockRepo.On("GetByID", "missing").Return(nil, errors.ErrNotFound)
+
+	result, err := setup.UseCase.RotateStreamKey(ctx, "missing", false, role.Admin)
+
+	assert.Equal(t, errors.ErrNotFound, err)
+	assert.Nil(t, result)
+	setup.MockRepo.AssertNotCalled(t, "UpdateAPIKey")
+	setup.MockStreamService.AssertNotCalled(t, "UpdateStreamKey")
+}
+
+// Role: Editor (Unauthorized)
+func TestRotateStreamKey_Editor_Unauthorized(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	result, err := setup.UseCase.RotateStreamKey(ctx, "livestream123", false, role.Editor)
+
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	assert.Nil(t, result)
+	setup.MockRepo.AssertNotCalled(t, "UpdateAPIKey")
+}
+
+// Role: Admin - Revoke clears the key and always drops the publisher
+func TestRevokeStreamKey_Admin_Success(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	setup.MockRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123"}, nil)
+	setup.MockRepo.On("UpdateAPIKey", "livestream123", "").Return(nil)
+	setup.MockStreamService.On("UpdateStreamKey", "livestream123", "", true).Return(nil)
+
+	err := setup.UseCase.RevokeStreamKey(ctx, "livestream123", role.Admin)
+
+	assert.NoError(t, err)
+	setup.MockRepo.AssertExpectations(t)
+	setup.MockStreamService.AssertExpectations(t)
+}
+
+// ================================================================================
+// API: KickPublisher (4 tests)
+// ================================================================================
+
+// Role: Admin - Kick without a block
+func TestKickPublisher_Admin_Success(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	setup.MockStreamService.On("KickPublisher", "livestream123", time.Duration(0)).Return(nil)
+
+	err := setup.UseCase.KickPublisher(ctx, "livestream123", 0, role.Admin)
+
+	assert.NoError(t, err)
+	setup.MockStreamService.AssertExpectations(t)
+}
+
+// Role: Admin - Kick and block new publishes
+func TestKickPublisher_Admin_WithBlock(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	setup.MockStreamService.On("KickPublisher", "livestream123", 10*time.Minute).Return(nil)
+
+	err := setup.UseCase.KickPublisher(ctx, "livestream123", 600, role.Admin)
+
+	assert.NoError(t, err)
+	setup.MockStreamService.AssertExpectations(t)
+}
+
+// Role: Admin - Block duration out of range
+func TestKickPublisher_Admin_InvalidBlock(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	err := setup.UseCase.KickPublisher(ctx, "livestream123", -1, role.Admin)
+	assert.Equal(t, errors.ErrInvalidInput, err)
+
+	err = setup.UseCase.KickPublisher(ctx, "livestream123", 24*60*60+1, role.Admin)
+	assert.Equal(t, errors.ErrInvalidInput, err)
+	setup.MockStreamService.AssertNotCalled(t, "KickPublisher", mock.Anything, mock.Anything)
+}
+
+// Role: Editor (Unauthorized)
+func TestKickPublisher_Editor_Unauthorized(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	err := setup.UseCase.KickPublisher(ctx, "livestream123", 0, role.Editor)
+
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	setup.MockStreamService.AssertNotCalled(t, "KickPublisher", mock.Anything, mock.Anything)
+}
+
+// ================================================================================
+// API: GetOne (11 tests)
 // Grouped by: Visibility -> Role
 // ================================================================================
 
@@ -503,9 +856,9 @@ func TestGetOne_Public_User_Success(t *testing.T) {
 		Visibility:  livestream.Public,
 	}
 
-	setup.MockRepo.On("GetOne").Return(testLivestream, nil)
+	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
 
-	result, err := setup.UseCase.GetOne(ctx, role.User)
+	result, err := setup.UseCase.GetOne(ctx, "livestream123", "", role.User)
 
 	expectedURL := "http://localhost:8080/livestream/livestream123/playlist.m3u8"
 	assert.NoError(t, err)
@@ -514,9 +867,82 @@ func TestGetOne_Public_User_Success(t *testing.T) {
 	assert.Equal(t, testLivestream.Title, result.Title)
 	assert.Equal(t, testLivestream.Information, result.Information)
 	assert.Equal(t, expectedURL, result.StreamURL)
+	assert.Equal(t, "http://localhost:8080/livestream/livestream123/thumbnail.jpg", result.ThumbnailURL)
 	setup.MockRepo.AssertExpectations(t)
 }
 
+// Visibility: Public - Role: User - Publisher connected
+func TestGetOne_Public_User_IsLive(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	startedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	setup.MockStreamService.LiveStatuses = map[string]livestream.LiveStatus{
+		"livestream123": {State: livestream.LiveStatePublishing, StartedAt: startedAt},
+	}
+	setup.MockRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123", Visibility: livestream.Public}, nil)
+
+	result, err := setup.UseCase.GetOne(ctx, "livestream123", "", role.User)
+
+	assert.NoError(t, err)
+	assert.True(t, result.IsLive)
+	assert.Equal(t, startedAt, *result.LiveSince)
+}
+
+// Visibility: Public - Role: User - Publisher gone
+func TestGetOne_Public_User_Offline(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	setup.MockStreamService.LiveStatuses = map[string]livestream.LiveStatus{
+		"livestream123": {State: livestream.LiveStateDisconnected, StartedAt: time.Now()},
+	}
+	setup.MockRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123", Visibility: livestream.Public}, nil)
+
+	result, err := setup.UseCase.GetOne(ctx, "livestream123", "", role.User)
+
+	assert.NoError(t, err)
+	assert.False(t, result.IsLive)
+	assert.Nil(t, result.LiveSince)
+}
+
+// Visibility: Public - Role: User - ABR ladder configured
+func TestGetOne_Public_User_MasterURL(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	setup.MockRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{
+		UUID:       "livestream123",
+		Visibility: livestream.Public,
+		Renditions: []livestream.Rendition{livestream.Rendition720p},
+	}, nil)
+
+	result, err := setup.UseCase.GetOne(ctx, "livestream123", "", role.User)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/livestream/livestream123/playlist.m3u8", result.StreamURL)
+	assert.Equal(t, "http://localhost:8080/livestream/livestream123/master.m3u8", result.MasterURL)
+	assert.Empty(t, result.DVRURL)
+}
+
+// Visibility: Public - Role: User - DVR enabled
+func TestGetOne_Public_User_DVRURL(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	setup.MockRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{
+		UUID:             "livestream123",
+		Visibility:       livestream.Public,
+		DVRWindowSeconds: 7200,
+	}, nil)
+
+	result, err := setup.UseCase.GetOne(ctx, "livestream123", "", role.User)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/livestream/livestream123/dvr.m3u8", result.DVRURL)
+	assert.Empty(t, result.MasterURL)
+}
+
 // Visibility: Public - Role: Guest
 func TestGetOne_Public_Guest_Success(t *testing.T) {
 	setup := setupLivestream()
@@ -530,9 +956,9 @@ func TestGetOne_Public_Guest_Success(t *testing.T) {
 		Visibility:  livestream.Public,
 	}
 
-	setup.MockRepo.On("GetOne").Return(testLivestream, nil)
+	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
 
-	result, err := setup.UseCase.GetOne(ctx, role.Guest)
+	result, err := setup.UseCase.GetOne(ctx, "livestream123", "", role.Guest)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
@@ -553,9 +979,9 @@ func TestGetOne_Public_Anonymous_Success(t *testing.T) {
 		Visibility:  livestream.Public,
 	}
 
-	setup.MockRepo.On("GetOne").Return(testLivestream, nil)
+	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
 
-	result, err := setup.UseCase.GetOne(ctx, role.Anonymous)
+	result, err := setup.UseCase.GetOne(ctx, "livestream123", "", role.Anonymous)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
@@ -577,9 +1003,9 @@ func TestGetOne_MemberOnly_User_Success(t *testing.T) {
 		Visibility:  livestream.MemberOnly,
 	}
 
-	setup.MockRepo.On("GetOne").Return(testLivestream, nil)
+	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
 
-	result, err := setup.UseCase.GetOne(ctx, role.User)
+	result, err := setup.UseCase.GetOne(ctx, "livestream123", "", role.User)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
@@ -597,9 +1023,9 @@ func TestGetOne_MemberOnly_Guest_Unauthorized(t *testing.T) {
 		Visibility: livestream.MemberOnly,
 	}
 
-	setup.MockRepo.On("GetOne").Return(testLivestream, nil)
+	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
 
-	result, err := setup.UseCase.GetOne(ctx, role.Guest)
+	result, err := setup.UseCase.GetOne(ctx, "livestream123", "", role.Guest)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -617,9 +1043,9 @@ func TestGetOne_MemberOnly_Anonymous_Unauthorized(t *testing.T) {
 		Visibility: livestream.MemberOnly,
 	}
 
-	setup.MockRepo.On("GetOne").Return(testLivestream, nil)
+	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
 
-	result, err := setup.UseCase.GetOne(ctx, role.Anonymous)
+	result, err := setup.UseCase.GetOne(ctx, "livestream123", "", role.Anonymous)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -628,34 +1054,133 @@ func TestGetOne_MemberOnly_Anonymous_Unauthorized(t *testing.T) {
 }
 
 // ================================================================================
-// API: PingViewerCount (9 tests)
-// Grouped by: Visibility -> Role
+// API: ListLivestreams (3 tests)
+// Grouped by: Role
 // ================================================================================
 
-// Visibility: Public - Role: Admin
-func TestPingViewerCount_Public_Admin_Success(t *testing.T) {
+func listTestLivestreams() []*livestream.Livestream {
+	return []*livestream.Livestream{
+		{UUID: "public-stream", Visibility: livestream.Public},
+		{UUID: "member-stream", Visibility: livestream.MemberOnly},
+		{UUID: "private-stream", Visibility: livestream.Private},
+	}
+}
+
+// Role: Admin - Sees every livestream
+func TestListLivestreams_Admin_All(t *testing.T) {
 	setup := setupLivestream()
 	ctx := context.Background()
 
-	testLivestream := &livestream.Livestream{
-		UUID:       "livestream123",
-		Visibility: livestream.Public,
-	}
-
-	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
-	setup.MockViewerCountCache.On("AddViewerCount", "livestream123", "user123").Return(nil)
-	setup.MockViewerCountCache.On("GetViewerCount", "livestream123").Return(10, nil)
+	setup.MockRepo.On("List").Return(listTestLivestreams(), nil)
 
-	viewerCount, err := setup.UseCase.PingViewerCount(ctx, role.Admin, "livestream123", "user123", "")
+	result, err := setup.UseCase.ListLivestreams(ctx, role.Admin)
 
 	assert.NoError(t, err)
-	assert.Equal(t, 10, viewerCount)
+	assert.Len(t, result, 3)
 	setup.MockRepo.AssertExpectations(t)
-	setup.MockViewerCountCache.AssertExpectations(t)
 }
 
-// Visibility: Public - Role: User (Ignores AnonymousID)
-func TestPingViewerCount_Public_User_IgnoresAnonymousID(t *testing.T) {
+// Role: User - Private livestreams are filtered out
+func TestListLivestreams_User_FiltersPrivate(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	setup.MockRepo.On("List").Return(listTestLivestreams(), nil)
+
+	result, err := setup.UseCase.ListLivestreams(ctx, role.User)
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "public-stream", result[0].UUID)
+	assert.Equal(t, "member-stream", result[1].UUID)
+	setup.MockRepo.AssertExpectations(t)
+}
+
+// Role: Anonymous - Only public livestreams
+func TestListLivestreams_Anonymous_PublicOnly(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	setup.MockRepo.On("List").Return(listTestLivestreams(), nil)
+
+	result, err := setup.UseCase.ListLivestreams(ctx, role.Anonymous)
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, "public-stream", result[0].UUID)
+	assert.Equal(t, "http://localhost:8080/livestream/public-stream/playlist.m3u8", result[0].StreamURL)
+	setup.MockRepo.AssertExpectations(t)
+}
+
+// ================================================================================
+// API: GetFirst (3 tests)
+// Deprecated GET /livestream/one
+// ================================================================================
+
+// The first livestream the user may watch
+func TestGetFirst_SkipsLivestreamsTheUserMayNotWatch(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	livestreams := listTestLivestreams()
+	setup.MockRepo.On("List").Return([]*livestream.Livestream{livestreams[2], livestreams[1], livestreams[0]}, nil)
+
+	result, err := setup.UseCase.GetFirst(ctx, role.User)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "member-stream", result.UUID)
+}
+
+func TestGetFirst_NoneWatchable_Unauthorized(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.MockRepo.On("List").Return([]*livestream.Livestream{{UUID: "private-stream", Visibility: livestream.Private}}, nil)
+
+	result, err := setup.UseCase.GetFirst(ctx, role.Anonymous)
+
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrUnauthorized, err)
+}
+
+func TestGetFirst_NoLivestream_NotFound(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.MockRepo.On("List").Return([]*livestream.Livestream{}, nil)
+
+	result, err := setup.UseCase.GetFirst(ctx, role.Admin)
+
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrNotFound, err)
+}
+
+// ================================================================================
+// API: PingViewerCount (9 tests)
+// Grouped by: Visibility -> Role
+// ================================================================================
+
+// Visibility: Public - Role: Admin
+func TestPingViewerCount_Public_Admin_Success(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	testLivestream := &livestream.Livestream{
+		UUID:       "livestream123",
+		Visibility: livestream.Public,
+	}
+
+	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
+	setup.MockViewerCountCache.On("AddViewerCount", "livestream123", "user123").Return(nil)
+	setup.MockViewerCountCache.On("GetViewerCount", "livestream123").Return(10, nil)
+
+	viewerCount, err := setup.UseCase.PingViewerCount(ctx, role.Admin, "livestream123", "user123", "", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10, viewerCount)
+	setup.MockRepo.AssertExpectations(t)
+	setup.MockViewerCountCache.AssertExpectations(t)
+}
+
+// Visibility: Public - Role: User (Ignores AnonymousID)
+func TestPingViewerCount_Public_User_IgnoresAnonymousID(t *testing.T) {
 	setup := setupLivestream()
 	defer setup.MockRepo.AssertExpectations(t)
 	defer setup.MockViewerCountCache.AssertExpectations(t)
@@ -681,6 +1206,7 @@ func TestPingViewerCount_Public_User_IgnoresAnonymousID(t *testing.T) {
 		livestreamUUID,
 		userID,
 		anonymousID, // Should be ignored
+		"",
 	)
 
 	// Assertions
@@ -716,6 +1242,7 @@ func TestPingViewerCount_Public_Anonymous_WithValidID(t *testing.T) {
 		livestreamUUID,
 		"", // userID empty for anonymous
 		anonymousID,
+		"",
 	)
 
 	// Assertions
@@ -746,6 +1273,7 @@ func TestPingViewerCount_Public_Anonymous_WithoutID(t *testing.T) {
 		livestreamUUID,
 		"", // userID empty
 		"", // anonymousID empty - should fail
+		"",
 	)
 
 	// Assertions
@@ -775,6 +1303,7 @@ func TestPingViewerCount_Public_Anonymous_WithEmptyStringID(t *testing.T) {
 		livestreamUUID,
 		"",
 		"   ", // Whitespace-only should also fail
+		"",
 	)
 
 	// Assertions
@@ -808,6 +1337,7 @@ func TestPingViewerCount_Public_Anonymous_SameIDUpdatesTimestamp(t *testing.T) {
 		livestreamUUID,
 		"",
 		anonymousID,
+		"",
 	)
 
 	// Second ping with same ID
@@ -821,6 +1351,7 @@ func TestPingViewerCount_Public_Anonymous_SameIDUpdatesTimestamp(t *testing.T) {
 		livestreamUUID,
 		"",
 		anonymousID,
+		"",
 	)
 
 	// Assertions
@@ -844,7 +1375,7 @@ func TestPingViewerCount_MemberOnly_Guest_Unauthorized(t *testing.T) {
 
 	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
 
-	viewerCount, err := setup.UseCase.PingViewerCount(ctx, role.Guest, "livestream123", "user123", "")
+	viewerCount, err := setup.UseCase.PingViewerCount(ctx, role.Guest, "livestream123", "user123", "", "")
 
 	assert.Error(t, err)
 	assert.Equal(t, 0, viewerCount)
@@ -875,6 +1406,7 @@ func TestPingViewerCount_MemberOnly_Anonymous_Unauthorized(t *testing.T) {
 		livestreamUUID,
 		"",
 		anonymousID,
+		"",
 	)
 
 	// Assertions
@@ -906,7 +1438,7 @@ func TestGetChat_Public_Admin_Success(t *testing.T) {
 	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
 	setup.MockChatCache.On("GetChat", "livestream123", "0", 10).Return(testChats, nil)
 
-	chats, err := setup.UseCase.GetChat(ctx, role.Admin, "livestream123", "0")
+	chats, err := setup.UseCase.GetChat(ctx, role.Admin, "livestream123", "0", "")
 
 	assert.NoError(t, err)
 	assert.Equal(t, testChats, chats)
@@ -931,7 +1463,7 @@ func TestGetChat_Public_Anonymous_Success(t *testing.T) {
 	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
 	setup.MockChatCache.On("GetChat", "livestream123", "0", 10).Return(testChats, nil)
 
-	chats, err := setup.UseCase.GetChat(ctx, role.Anonymous, "livestream123", "0")
+	chats, err := setup.UseCase.GetChat(ctx, role.Anonymous, "livestream123", "0", "")
 
 	assert.NoError(t, err)
 	assert.NotNil(t, chats)
@@ -952,7 +1484,7 @@ func TestGetChat_MemberOnly_Guest_Unauthorized(t *testing.T) {
 
 	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
 
-	chats, err := setup.UseCase.GetChat(ctx, role.Guest, "livestream123", "0")
+	chats, err := setup.UseCase.GetChat(ctx, role.Guest, "livestream123", "0", "")
 
 	assert.Error(t, err)
 	assert.Nil(t, chats)
@@ -979,7 +1511,7 @@ func TestAddChat_Public_Admin_Success(t *testing.T) {
 	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
 	setup.MockChatCache.On("AddChat", "livestream123", testChat).Return(nil)
 
-	err := setup.UseCase.AddChat(ctx, "identityProvider", role.Admin, "livestream123", testChat)
+	err := setup.UseCase.AddChat(ctx, "identityProvider", role.Admin, "livestream123", testChat, "")
 
 	assert.NoError(t, err)
 	setup.MockRepo.AssertExpectations(t)
@@ -1001,7 +1533,7 @@ func TestAddChat_Public_Guest_Success(t *testing.T) {
 	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
 	setup.MockChatCache.On("AddChat", "livestream123", testChat).Return(nil)
 
-	err := setup.UseCase.AddChat(ctx, "discord", role.Guest, "livestream123", testChat)
+	err := setup.UseCase.AddChat(ctx, "discord", role.Guest, "livestream123", testChat, "")
 
 	assert.NoError(t, err)
 	setup.MockRepo.AssertExpectations(t)
@@ -1022,7 +1554,7 @@ func TestAddChat_Public_Anonymous_Unauthorized(t *testing.T) {
 
 	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
 
-	err := setup.UseCase.AddChat(ctx, "test", role.Anonymous, "livestream123", testChat)
+	err := setup.UseCase.AddChat(ctx, "test", role.Anonymous, "livestream123", testChat, "")
 
 	assert.Error(t, err)
 	assert.Equal(t, errors.ErrUnauthorized, err)
@@ -1043,7 +1575,7 @@ func TestAddChat_MemberOnly_Guest_Unauthorized(t *testing.T) {
 
 	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
 
-	err := setup.UseCase.AddChat(ctx, "discord", role.Guest, "livestream123", testChat)
+	err := setup.UseCase.AddChat(ctx, "discord", role.Guest, "livestream123", testChat, "")
 
 	assert.Error(t, err)
 	assert.Equal(t, errors.ErrUnauthorized, err)
@@ -1069,7 +1601,7 @@ func TestDeleteChat_Admin_CanDeleteAny(t *testing.T) {
 	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
 	setup.MockChatCache.On("DeleteChat", "livestream123", "chat123").Return(nil)
 
-	err := setup.UseCase.DeleteChat(ctx, role.Admin, "user456", "livestream123", "chat123")
+	err := setup.UseCase.DeleteChat(ctx, role.Admin, "user456", "livestream123", "chat123", "")
 
 	assert.NoError(t, err)
 	setup.MockChatCache.AssertExpectations(t)
@@ -1099,7 +1631,7 @@ func TestDeleteChat_Editor_CanDeleteUserMessage(t *testing.T) {
 	setup.MockChatCache.On("GetChatByID", "livestream123", "chat123").Return(&testChat, nil)
 	setup.MockChatCache.On("DeleteChat", "livestream123", "chat123").Return(nil)
 
-	err := setup.UseCase.DeleteChat(ctx, role.Editor, "user456", "livestream123", "chat123")
+	err := setup.UseCase.DeleteChat(ctx, role.Editor, "user456", "livestream123", "chat123", "")
 
 	assert.NoError(t, err)
 	setup.MockRepo.AssertExpectations(t)
@@ -1135,6 +1667,7 @@ func TestDeleteChat_Editor_CannotDeleteAdminMessage(t *testing.T) {
 		"editor-001",
 		"livestream123",
 		"admin-chat-001",
+		"",
 	)
 
 	// Verify it returns Unauthorized
@@ -1173,6 +1706,7 @@ func TestDeleteChat_Editor_CannotDeleteOtherEditorMessage(t *testing.T) {
 		"editor-001",
 		"livestream123",
 		"editor-chat-001",
+		"",
 	)
 
 	// Verify it returns Unauthorized
@@ -1212,6 +1746,7 @@ func TestDeleteChat_Editor_CanDeleteOwnMessage(t *testing.T) {
 		"editor-001",
 		"livestream123",
 		"chat123",
+		"",
 	)
 
 	// Verify it succeeds
@@ -1244,7 +1779,7 @@ func TestDeleteChat_User_CanDeleteOwnMessage(t *testing.T) {
 	setup.MockChatCache.On("DeleteChat", "livestream123", "chat123").Return(nil)
 
 	// User deletes their own message - should succeed
-	err := setup.UseCase.DeleteChat(ctx, role.User, "user123", "livestream123", "chat123")
+	err := setup.UseCase.DeleteChat(ctx, role.User, "user123", "livestream123", "chat123", "")
 
 	assert.NoError(t, err)
 	setup.MockRepo.AssertExpectations(t)
@@ -1274,7 +1809,7 @@ func TestDeleteChat_User_CannotDeleteOthersMessage(t *testing.T) {
 	setup.MockChatCache.On("GetChatByID", "livestream123", "chat123").Return(&testChat, nil)
 
 	// User tries to delete someone else's message - should fail
-	err := setup.UseCase.DeleteChat(ctx, role.User, "user123", "livestream123", "chat123")
+	err := setup.UseCase.DeleteChat(ctx, role.User, "user123", "livestream123", "chat123", "")
 
 	assert.Error(t, err)
 	assert.Equal(t, errors.ErrUnauthorized, err)
@@ -1303,7 +1838,7 @@ func TestDeleteChat_Guest_CanDeleteOwnMessage(t *testing.T) {
 	setup.MockChatCache.On("GetChatByID", "livestream123", "chat123").Return(&testChat, nil)
 	setup.MockChatCache.On("DeleteChat", "livestream123", "chat123").Return(nil)
 
-	err := setup.UseCase.DeleteChat(ctx, role.Guest, "guest123", "livestream123", "chat123")
+	err := setup.UseCase.DeleteChat(ctx, role.Guest, "guest123", "livestream123", "chat123", "")
 
 	assert.NoError(t, err)
 	setup.MockRepo.AssertExpectations(t)
@@ -1330,7 +1865,7 @@ func TestDeleteChat_Guest_CannotDeleteOthersMessage(t *testing.T) {
 	setup.MockRepo.On("GetByID", "livestream123").Return(testLivestream, nil)
 	setup.MockChatCache.On("GetChatByID", "livestream123", "chat123").Return(&testChat, nil)
 
-	err := setup.UseCase.DeleteChat(ctx, role.Guest, "guest123", "livestream123", "chat123")
+	err := setup.UseCase.DeleteChat(ctx, role.Guest, "guest123", "livestream123", "chat123", "")
 
 	assert.Error(t, err)
 	assert.Equal(t, errors.ErrUnauthorized, err)
@@ -1537,7 +2072,7 @@ func TestMuteUser_User_Unauthorized(t *testing.T) {
 }
 
 // ================================================================================
-// API: GetFile (5 tests)
+// API: GetFile (10 tests)
 // Grouped by: Visibility -> Role
 // ================================================================================
 
@@ -1553,7 +2088,7 @@ func TestGetFile_Public_Anonymous_Success(t *testing.T) {
 
 	testFileData := []byte("test file content")
 
-	setup.MockRepo.On("GetOne").Return(testLivestream, nil)
+	setup.MockRepo.On("GetByID", "83636040-7f54-49f2-ae40-9a1213614729").Return(testLivestream, nil)
 	setup.MockFileCache.On("LoadCache", "/test/root/hls/83636040-7f54-49f2-ae40-9a1213614729/playlist.m3u8").Return(testFileData, true)
 
 	file, err := setup.UseCase.GetFile(
@@ -1561,15 +2096,49 @@ func TestGetFile_Public_Anonymous_Success(t *testing.T) {
 		"/test/root",                           // rootPath (trusted)
 		"83636040-7f54-49f2-ae40-9a1213614729", // uuid (external)
 		"playlist.m3u8",                        // filename (external)
+		livestreamDto.LivestreamPlaylistReloadDTO{},
+		"",
 		role.Anonymous,
 	)
 
 	assert.NoError(t, err)
-	assert.Equal(t, testFileData, file)
+	assert.Equal(t, testFileData, file.Data)
 	setup.MockRepo.AssertExpectations(t)
 	setup.MockFileCache.AssertExpectations(t)
 }
 
+// Only files of public livestreams may be kept by shared caches
+func TestGetFile_CacheValidators(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility livestream.Visibility
+		public     bool
+	}{
+		{"public", livestream.Public, true},
+		{"member only", livestream.MemberOnly, false},
+		{"private", livestream.Private, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupLivestream()
+			filePath := "/test/root/hls/83636040-7f54-49f2-ae40-9a1213614729/stream-3.ts"
+			modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			setup.MockFileCache.ModTimes = map[string]time.Time{filePath: modTime}
+			setup.MockRepo.On("GetByID", "83636040-7f54-49f2-ae40-9a1213614729").Return(&livestream.Livestream{
+				UUID:       "83636040-7f54-49f2-ae40-9a1213614729",
+				Visibility: tt.visibility,
+			}, nil)
+			setup.MockFileCache.On("LoadCache", filePath).Return([]byte("ts"), true)
+
+			file, err := setup.UseCase.GetFile(context.Background(), "/test/root", "83636040-7f54-49f2-ae40-9a1213614729", "stream-3.ts", livestreamDto.LivestreamPlaylistReloadDTO{}, "", role.Admin)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.public, file.Public)
+			assert.Equal(t, modTime, file.ModTime)
+		})
+	}
+}
+
 // Visibility: MemberOnly - Role: Guest (Unauthorized)
 func TestGetFile_MemberOnly_Guest_Unauthorized(t *testing.T) {
 	setup := setupLivestream()
@@ -1580,13 +2149,15 @@ func TestGetFile_MemberOnly_Guest_Unauthorized(t *testing.T) {
 		Visibility: livestream.MemberOnly,
 	}
 
-	setup.MockRepo.On("GetOne").Return(testLivestream, nil)
+	setup.MockRepo.On("GetByID", "83636040-7f54-49f2-ae40-9a1213614729").Return(testLivestream, nil)
 
 	file, err := setup.UseCase.GetFile(
 		ctx,
 		"/test/root",
 		"83636040-7f54-49f2-ae40-9a1213614729",
 		"playlist.m3u8",
+		livestreamDto.LivestreamPlaylistReloadDTO{},
+		"",
 		role.Guest,
 	)
 
@@ -1601,7 +2172,7 @@ func TestGetRecord_Editor_Unauthorized(t *testing.T) {
 	setup := setupLivestream()
 	ctx := context.Background()
 
-	result, err := setup.UseCase.GetRecord(ctx, "/test/root", "livestream123", role.Editor)
+	result, err := setup.UseCase.GetRecord(ctx, "/test/root", "livestream123", "", role.Editor)
 
 	assert.Error(t, err)
 	assert.Equal(t, errors.ErrUnauthorized, err)
@@ -1613,7 +2184,7 @@ func TestGetRecord_User_Unauthorized(t *testing.T) {
 	setup := setupLivestream()
 	ctx := context.Background()
 
-	result, err := setup.UseCase.GetRecord(ctx, "/test/root", "livestream123", role.User)
+	result, err := setup.UseCase.GetRecord(ctx, "/test/root", "livestream123", "", role.User)
 
 	assert.Error(t, err)
 	assert.Equal(t, errors.ErrUnauthorized, err)
@@ -1632,6 +2203,8 @@ func TestGetFile_NotFound(t *testing.T) {
 		"/test/root",
 		"83636040-7f54-49f2-ae40-9a1213614729",
 		"record.m3u8",
+		livestreamDto.LivestreamPlaylistReloadDTO{},
+		"",
 		role.User,
 	)
 	assert.Nil(t, file)
@@ -1643,163 +2216,979 @@ func TestGetFile_NotFound(t *testing.T) {
 		"/test/root",
 		"83636040-7f54-49f2-ae40-9a1213614729",
 		"output.mp4",
+		livestreamDto.LivestreamPlaylistReloadDTO{},
+		"",
 		role.User,
 	)
 	assert.Nil(t, file)
 	assert.Equal(t, errors.ErrInvalidInput, err)
 }
 
-// ================================================================================
-// API: GetRecord (6 tests)
-// Grouped by: Role
-// ================================================================================
-
-// Role: Admin - Success
-func TestGetRecord_Admin_Success(t *testing.T) {
+// Blocking playlist reload - the playlist is served once the part exists
+func TestGetFile_BlockingReload_Success(t *testing.T) {
 	setup := setupLivestream()
 	ctx := context.Background()
 
-	validUUID := "83636040-7f54-49f2-ae40-9a1213614729"
-	rootPath := "/test/root"
-	filePath := "/test/root/hls/" + validUUID + "/*.mp4"
+	testLivestream := &livestream.Livestream{
+		UUID:       "83636040-7f54-49f2-ae40-9a1213614729",
+		Visibility: livestream.Public,
+	}
+	testFileData := []byte("#EXTM3U")
+	msn, part := 12, 2
 
-	setup.MockFileCache.On("GetSingleFileName", filePath).Return("output.mp4", nil)
+	setup.MockRepo.On("GetByID", "83636040-7f54-49f2-ae40-9a1213614729").Return(testLivestream, nil)
+	setup.MockStreamService.On("WaitLowLatencyPart", "83636040-7f54-49f2-ae40-9a1213614729", 12, 2).Return(nil)
+	setup.MockFileCache.On("LoadCache", "/test/root/hls/83636040-7f54-49f2-ae40-9a1213614729/playlist.m3u8").Return(testFileData, true)
 
-	result, err := setup.UseCase.GetRecord(ctx, rootPath, validUUID, role.Admin)
+	file, err := setup.UseCase.GetFile(
+		ctx,
+		"/test/root",
+		"83636040-7f54-49f2-ae40-9a1213614729",
+		"playlist.m3u8",
+		livestreamDto.LivestreamPlaylistReloadDTO{MSN: &msn, Part: &part},
+		"",
+		role.Anonymous,
+	)
 
 	assert.NoError(t, err)
-	assert.Equal(t, "output.mp4", result)
+	assert.Equal(t, testFileData, file.Data)
+	setup.MockStreamService.AssertExpectations(t)
 	setup.MockFileCache.AssertExpectations(t)
 }
 
-// Role: Admin - Not Mp4 (this test is no longer relevant as GetRecord always uses *.mp4)
-// Removed - GetRecord now always constructs path with *.mp4
+// Blocking playlist reload - the part never shows up
+func TestGetFile_BlockingReload_Timeout(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
 
-// Role: Guest (Unauthorized)
-func TestGetRecord_Guest_Unauthorized(t *testing.T) {
+	testLivestream := &livestream.Livestream{
+		UUID:       "83636040-7f54-49f2-ae40-9a1213614729",
+		Visibility: livestream.Public,
+	}
+	msn := 12
+
+	setup.MockRepo.On("GetByID", "83636040-7f54-49f2-ae40-9a1213614729").Return(testLivestream, nil)
+	setup.MockStreamService.On("WaitLowLatencyPart", "83636040-7f54-49f2-ae40-9a1213614729", 12, -1).Return(errors.ErrTimeout)
+
+	file, err := setup.UseCase.GetFile(
+		ctx,
+		"/test/root",
+		"83636040-7f54-49f2-ae40-9a1213614729",
+		"playlist.m3u8",
+		livestreamDto.LivestreamPlaylistReloadDTO{MSN: &msn},
+		"",
+		role.Anonymous,
+	)
+
+	assert.Nil(t, file)
+	assert.Equal(t, errors.ErrTimeout, err)
+	setup.MockStreamService.AssertExpectations(t)
+	setup.MockFileCache.AssertNotCalled(t, "LoadCache", mock.Anything)
+}
+
+// Blocking playlist reload - _HLS_part without _HLS_msn is rejected
+func TestGetFile_BlockingReload_PartWithoutMSN(t *testing.T) {
 	setup := setupLivestream()
 	ctx := context.Background()
-	result, err := setup.UseCase.GetRecord(ctx, "/test/root", "livestream123", role.Guest)
+	part := 1
 
-	assert.Error(t, err)
-	assert.Equal(t, errors.ErrUnauthorized, err)
-	assert.Empty(t, result)
+	file, err := setup.UseCase.GetFile(
+		ctx,
+		"/test/root",
+		"83636040-7f54-49f2-ae40-9a1213614729",
+		"playlist.m3u8",
+		livestreamDto.LivestreamPlaylistReloadDTO{Part: &part},
+		"",
+		role.Anonymous,
+	)
+
+	assert.Nil(t, file)
+	assert.Equal(t, errors.ErrInvalidInput, err)
+	setup.MockRepo.AssertNotCalled(t, "GetByID", mock.Anything)
 }
 
-// Role: Anonymous (Unauthorized)
-func TestGetRecord_Anonymous_Unauthorized(t *testing.T) {
+// Preload hint - a part that is still being written is read once complete
+func TestGetFile_PreloadHint_WaitsForPart(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	testLivestream := &livestream.Livestream{
+		UUID:       "83636040-7f54-49f2-ae40-9a1213614729",
+		Visibility: livestream.Public,
+	}
+	filePath := "/test/root/hls/83636040-7f54-49f2-ae40-9a1213614729/stream-1700000000000-p7.ts"
+	testFileData := []byte("part data")
+
+	setup.MockRepo.On("GetByID", "83636040-7f54-49f2-ae40-9a1213614729").Return(testLivestream, nil)
+	setup.MockFileCache.On("LoadCache", filePath).Return([]byte(nil), false)
+	setup.MockFileCache.On("ReadFile", filePath).Return([]byte(nil), errors.ErrNotFound).Once()
+	setup.MockStreamService.On("WaitPreloadHint", "83636040-7f54-49f2-ae40-9a1213614729", "stream-1700000000000-p7.ts").Return(nil)
+	setup.MockFileCache.On("ReadFile", filePath).Return(testFileData, nil).Once()
+	setup.MockFileCache.On("StoreCache", filePath, testFileData).Return()
+	setup.MockFileCache.On("WatchStream", "83636040-7f54-49f2-ae40-9a1213614729", "/test/root/hls/83636040-7f54-49f2-ae40-9a1213614729").Return()
+
+	file, err := setup.UseCase.GetFile(
+		ctx,
+		"/test/root",
+		"83636040-7f54-49f2-ae40-9a1213614729",
+		"stream-1700000000000-p7.ts",
+		livestreamDto.LivestreamPlaylistReloadDTO{},
+		"",
+		role.Anonymous,
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testFileData, file.Data)
+	setup.MockStreamService.AssertExpectations(t)
+	setup.MockFileCache.AssertExpectations(t)
+}
+
+// Rendition playlists are rewritten by ffmpeg without update events; they
+// are cached and kept fresh by the stream's watcher
+func TestGetFile_RenditionPlaylist_WatchesStream(t *testing.T) {
 	setup := setupLivestream()
 	ctx := context.Background()
 
-	result, err := setup.UseCase.GetRecord(ctx, "/test/root", "livestream123", role.Anonymous)
+	testLivestream := &livestream.Livestream{
+		UUID:       "83636040-7f54-49f2-ae40-9a1213614729",
+		Visibility: livestream.Public,
+	}
+	filePath := "/test/root/hls/83636040-7f54-49f2-ae40-9a1213614729/720p/playlist.m3u8"
+	testFileData := []byte("#EXTM3U")
+
+	setup.MockRepo.On("GetByID", "83636040-7f54-49f2-ae40-9a1213614729").Return(testLivestream, nil)
+	setup.MockFileCache.On("LoadCache", filePath).Return([]byte(nil), false)
+	setup.MockFileCache.On("ReadFile", filePath).Return(testFileData, nil)
+	setup.MockFileCache.On("StoreCache", filePath, testFileData).Return()
+	setup.MockFileCache.On("WatchStream", "83636040-7f54-49f2-ae40-9a1213614729", "/test/root/hls/83636040-7f54-49f2-ae40-9a1213614729").Return()
 
-	assert.Error(t, err)
-	assert.Equal(t, errors.ErrUnauthorized, err)
-	assert.Empty(t, result)
+	msn := 3
+	file, err := setup.UseCase.GetFile(
+		ctx,
+		"/test/root",
+		"83636040-7f54-49f2-ae40-9a1213614729",
+		"720p/playlist.m3u8",
+		livestreamDto.LivestreamPlaylistReloadDTO{MSN: &msn},
+		"",
+		role.Anonymous,
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testFileData, file.Data)
+	setup.MockFileCache.AssertExpectations(t)
+	setup.MockStreamService.AssertNotCalled(t, "WaitLowLatencyPart", mock.Anything, mock.Anything, mock.Anything)
 }
 
 // ================================================================================
-// API: GetDeleteChatIDs (4 tests)
+// API: SubscribeLiveFLV (4 tests)
 // Grouped by: Visibility -> Role
 // ================================================================================
 
 // Visibility: Public - Role: Anonymous
-func TestGetDeleteChatIDs_Public_Anonymous_Success(t *testing.T) {
+func TestSubscribeLiveFLV_Public_Anonymous_Success(t *testing.T) {
 	setup := setupLivestream()
 	ctx := context.Background()
 
 	testLivestream := &livestream.Livestream{
-		UUID:       "test-uuid",
+		UUID:       "83636040-7f54-49f2-ae40-9a1213614729",
 		Visibility: livestream.Public,
 	}
+	subscription := new(mock_data.MockFlvSubscription)
 
-	deletedIDs := []string{"chat1", "chat2"}
-
-	setup.MockRepo.On("GetByID", "test-uuid").Return(testLivestream, nil)
-	setup.MockChatCache.On("GetDeleteChatIDs", "test-uuid").Return(deletedIDs, nil)
+	setup.MockRepo.On("GetByID", "83636040-7f54-49f2-ae40-9a1213614729").Return(testLivestream, nil)
+	setup.MockStreamService.On("SubscribeFLV", "83636040-7f54-49f2-ae40-9a1213614729").Return(subscription, nil)
 
-	result, err := setup.UseCase.GetDeleteChatIDs(ctx, role.Anonymous, "test-uuid")
+	result, err := setup.UseCase.SubscribeLiveFLV(ctx, "83636040-7f54-49f2-ae40-9a1213614729", "", role.Anonymous)
 
 	assert.NoError(t, err)
-	assert.NotNil(t, result)
-	assert.Equal(t, deletedIDs, result)
+	assert.Equal(t, subscription, result)
 	setup.MockRepo.AssertExpectations(t)
-	setup.MockChatCache.AssertExpectations(t)
+	setup.MockStreamService.AssertExpectations(t)
 }
 
-// Visibility: MemberOnly - Role: Anonymous (Unauthorized)
-func TestGetDeleteChatIDs_MemberOnly_Anonymous_Unauthorized(t *testing.T) {
+// Visibility: Private - Role: Editor (Unauthorized)
+func TestSubscribeLiveFLV_Private_Editor_Unauthorized(t *testing.T) {
 	setup := setupLivestream()
 	ctx := context.Background()
 
 	testLivestream := &livestream.Livestream{
-		UUID:       "test-uuid",
-		Visibility: livestream.MemberOnly,
+		UUID:       "83636040-7f54-49f2-ae40-9a1213614729",
+		Visibility: livestream.Private,
 	}
 
-	setup.MockRepo.On("GetByID", "test-uuid").Return(testLivestream, nil)
+	setup.MockRepo.On("GetByID", "83636040-7f54-49f2-ae40-9a1213614729").Return(testLivestream, nil)
 
-	result, err := setup.UseCase.GetDeleteChatIDs(ctx, role.Anonymous, "test-uuid")
+	result, err := setup.UseCase.SubscribeLiveFLV(ctx, "83636040-7f54-49f2-ae40-9a1213614729", "", role.Editor)
 
-	assert.Error(t, err)
 	assert.Nil(t, result)
 	assert.Equal(t, errors.ErrUnauthorized, err)
-	setup.MockRepo.AssertExpectations(t)
+	setup.MockStreamService.AssertNotCalled(t, "SubscribeFLV", mock.Anything)
 }
 
-// Visibility: Public - Role: Guest
-func TestGetDeleteChatIDs_Public_Guest_Success(t *testing.T) {
+// Visibility: MemberOnly - Role: User (not live)
+func TestSubscribeLiveFLV_NotLive(t *testing.T) {
 	setup := setupLivestream()
 	ctx := context.Background()
 
 	testLivestream := &livestream.Livestream{
-		UUID:       "test-uuid",
-		Visibility: livestream.Public,
+		UUID:       "83636040-7f54-49f2-ae40-9a1213614729",
+		Visibility: livestream.MemberOnly,
 	}
 
-	deletedIDs := []string{"chat1", "chat2"}
-
-	setup.MockRepo.On("GetByID", "test-uuid").Return(testLivestream, nil)
-	setup.MockChatCache.On("GetDeleteChatIDs", "test-uuid").Return(deletedIDs, nil)
+	setup.MockRepo.On("GetByID", "83636040-7f54-49f2-ae40-9a1213614729").Return(testLivestream, nil)
+	setup.MockStreamService.On("SubscribeFLV", "83636040-7f54-49f2-ae40-9a1213614729").Return(nil, errors.ErrNotFound)
 
-	result, err := setup.UseCase.GetDeleteChatIDs(ctx, role.Guest, "test-uuid")
+	result, err := setup.UseCase.SubscribeLiveFLV(ctx, "83636040-7f54-49f2-ae40-9a1213614729", "", role.User)
 
-	assert.NoError(t, err)
-	assert.NotNil(t, result)
-	assert.Equal(t, deletedIDs, result)
-	setup.MockRepo.AssertExpectations(t)
-	setup.MockChatCache.AssertExpectations(t)
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrNotFound, err)
 }
 
-// Visibility: MemberOnly - Role: Guest (Unauthorized)
-func TestGetDeleteChatIDs_MemberOnly_Guest_Unauthorized(t *testing.T) {
+func TestSubscribeLiveFLV_InvalidUUID(t *testing.T) {
 	setup := setupLivestream()
 	ctx := context.Background()
 
-	testLivestream := &livestream.Livestream{
-		UUID:       "test-uuid",
-		Visibility: livestream.MemberOnly,
-	}
-
-	setup.MockRepo.On("GetByID", "test-uuid").Return(testLivestream, nil)
-
-	result, err := setup.UseCase.GetDeleteChatIDs(ctx, role.Guest, "test-uuid")
+	result, err := setup.UseCase.SubscribeLiveFLV(ctx, "../../etc", "", role.Admin)
 
-	assert.Error(t, err)
 	assert.Nil(t, result)
-	assert.Equal(t, errors.ErrUnauthorized, err)
-	setup.MockRepo.AssertExpectations(t)
+	assert.Equal(t, errors.ErrInvalidInput, err)
+	setup.MockRepo.AssertNotCalled(t, "GetByID", mock.Anything)
 }
 
 // ================================================================================
-// Path Validation Tests for GetFile (Table-Driven)
+// API: GetRecord (11 tests)
+// Grouped by: Role
 // ================================================================================
 
-func TestGetFile_PathValidation(t *testing.T) {
+const recordingID = "0b7c8a52-4a8e-4c43-9d1c-5b3f1e2a9c10"
+
+// Role: Admin - Success, the latest recording that has ended
+func TestGetRecord_Admin_Success(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
 	validUUID := "83636040-7f54-49f2-ae40-9a1213614729"
 	rootPath := "/test/root"
+	filePath := "/test/root/hls/" + validUUID + "/recordings/" + recordingID + "/*.mp4"
 
-	tests := []struct {
+	setup.MockRecordingRepo.On("ListByLivestream", validUUID).Return([]*livestream.Recording{
+		{ID: "still-recording", LivestreamUUID: validUUID, Status: livestream.RecordingStatusRecording},
+		{ID: "failed", LivestreamUUID: validUUID, Status: livestream.RecordingStatusFailed},
+		{ID: recordingID, LivestreamUUID: validUUID, Status: livestream.RecordingStatusFinished},
+	}, nil)
+	setup.MockFileCache.On("GetSingleFileName", filePath).Return("output.mp4", nil)
+
+	result, err := setup.UseCase.GetRecord(ctx, rootPath, validUUID, "", role.Admin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "output.mp4", result)
+	setup.MockFileCache.AssertExpectations(t)
+}
+
+// Role: Admin - A recording named by its ID
+func TestGetRecord_Admin_ByRecordingID(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	validUUID := "83636040-7f54-49f2-ae40-9a1213614729"
+	filePath := "/test/root/hls/" + validUUID + "/recordings/" + recordingID + "/*.mp4"
+
+	setup.MockRecordingRepo.On("GetByID", recordingID).Return(&livestream.Recording{ID: recordingID, LivestreamUUID: validUUID, Status: livestream.RecordingStatusInterrupted}, nil)
+	setup.MockFileCache.On("GetSingleFileName", filePath).Return("output.mp4", nil)
+
+	result, err := setup.UseCase.GetRecord(ctx, "/test/root", validUUID, recordingID, role.Admin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "output.mp4", result)
+	setup.MockRecordingRepo.AssertNotCalled(t, "ListByLivestream", mock.Anything)
+}
+
+// Role: Admin - A recording of another livestream
+func TestGetRecord_Admin_OtherLivestreamRecording_NotFound(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	setup.MockRecordingRepo.On("GetByID", recordingID).Return(&livestream.Recording{ID: recordingID, LivestreamUUID: "other", Status: livestream.RecordingStatusFinished}, nil)
+
+	result, err := setup.UseCase.GetRecord(ctx, "/test/root", "83636040-7f54-49f2-ae40-9a1213614729", recordingID, role.Admin)
+
+	assert.Equal(t, errors.ErrNotFound, err)
+	assert.Empty(t, result)
+	setup.MockFileCache.AssertNotCalled(t, "GetSingleFileName", mock.Anything)
+}
+
+// Role: Admin - Nothing has been recorded yet
+func TestGetRecord_Admin_NoRecording_NotFound(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	validUUID := "83636040-7f54-49f2-ae40-9a1213614729"
+	setup.MockRecordingRepo.On("ListByLivestream", validUUID).Return([]*livestream.Recording{
+		{ID: recordingID, LivestreamUUID: validUUID, Status: livestream.RecordingStatusRecording},
+	}, nil)
+
+	result, err := setup.UseCase.GetRecord(ctx, "/test/root", validUUID, "", role.Admin)
+
+	assert.Equal(t, errors.ErrNotFound, err)
+	assert.Empty(t, result)
+	setup.MockFileCache.AssertNotCalled(t, "GetSingleFileName", mock.Anything)
+}
+
+// Role: Admin - Not converted yet, a conversion is queued
+func TestGetRecord_Admin_Unconverted_QueuesConversion(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	validUUID := "83636040-7f54-49f2-ae40-9a1213614729"
+	filePath := "/test/root/hls/" + validUUID + "/recordings/" + recordingID + "/*.mp4"
+
+	setup.MockRecordingRepo.On("GetByID", recordingID).Return(&livestream.Recording{ID: recordingID, LivestreamUUID: validUUID, Status: livestream.RecordingStatusFinished}, nil)
+	setup.MockFileCache.On("GetSingleFileName", filePath).Return("", errors.ErrNotFound)
+	setup.MockConversionRepo.On("Enqueue", mock.MatchedBy(func(job *livestream.ConversionJob) bool {
+		return job.ID != "" && job.RecordingID == recordingID && job.LivestreamUUID == validUUID &&
+			job.Status == livestream.ConversionStatusQueued && !job.NextAttemptAt.IsZero()
+	})).Return(nil)
+
+	result, err := setup.UseCase.GetRecord(ctx, "/test/root", validUUID, recordingID, role.Admin)
+
+	assert.Equal(t, errors.ErrNotFound, err)
+	assert.Empty(t, result)
+	setup.MockConversionRepo.AssertExpectations(t)
+}
+
+// Role: Admin - A recording still being written cannot be converted
+func TestGetRecord_Admin_StillRecording_NotFound(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	validUUID := "83636040-7f54-49f2-ae40-9a1213614729"
+	setup.MockRecordingRepo.On("GetByID", recordingID).Return(&livestream.Recording{ID: recordingID, LivestreamUUID: validUUID, Status: livestream.RecordingStatusRecording}, nil)
+
+	result, err := setup.UseCase.GetRecord(ctx, "/test/root", validUUID, recordingID, role.Admin)
+
+	assert.Equal(t, errors.ErrNotFound, err)
+	assert.Empty(t, result)
+	setup.MockConversionRepo.AssertNotCalled(t, "Enqueue", mock.Anything)
+}
+
+// Role: Admin - Not Mp4 (this test is no longer relevant as GetRecord always uses *.mp4)
+// Removed - GetRecord now always constructs path with *.mp4
+
+// Role: Guest (Unauthorized)
+func TestGetRecord_Guest_Unauthorized(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	result, err := setup.UseCase.GetRecord(ctx, "/test/root", "livestream123", "", role.Guest)
+
+	assert.Error(t, err)
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	assert.Empty(t, result)
+}
+
+// Role: Anonymous (Unauthorized)
+func TestGetRecord_Anonymous_Unauthorized(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	result, err := setup.UseCase.GetRecord(ctx, "/test/root", "livestream123", "", role.Anonymous)
+
+	assert.Error(t, err)
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	assert.Empty(t, result)
+}
+
+// ================================================================================
+// API: GetRecordStatus (4 tests)
+// Grouped by: Role
+// ================================================================================
+
+// Role: Admin - The conversion of the latest recording
+func TestGetRecordStatus_Admin_Success(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	validUUID := "83636040-7f54-49f2-ae40-9a1213614729"
+	job := &livestream.ConversionJob{ID: "job-1", RecordingID: recordingID, Status: livestream.ConversionStatusRunning, Progress: 0.4, Attempts: 1}
+	setup.MockRecordingRepo.On("ListByLivestream", validUUID).Return([]*livestream.Recording{
+		{ID: recordingID, LivestreamUUID: validUUID, Status: livestream.RecordingStatusFinished},
+	}, nil)
+	setup.MockConversionRepo.On("GetByRecordingID", recordingID).Return(job, nil)
+
+	result, err := setup.UseCase.GetRecordStatus(ctx, validUUID, "", role.Admin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, job, result)
+}
+
+// Role: Admin - GetRecord has not queued a conversion yet
+func TestGetRecordStatus_Admin_NotQueued(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	validUUID := "83636040-7f54-49f2-ae40-9a1213614729"
+	setup.MockRecordingRepo.On("GetByID", recordingID).Return(&livestream.Recording{ID: recordingID, LivestreamUUID: validUUID, Status: livestream.RecordingStatusFinished}, nil)
+	setup.MockConversionRepo.On("GetByRecordingID", recordingID).Return(nil, errors.ErrNotFound)
+
+	result, err := setup.UseCase.GetRecordStatus(ctx, validUUID, recordingID, role.Admin)
+
+	assert.Equal(t, errors.ErrNotFound, err)
+	assert.Nil(t, result)
+}
+
+// Role: Admin - Invalid recording ID
+func TestGetRecordStatus_Admin_InvalidRecordingID(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	result, err := setup.UseCase.GetRecordStatus(ctx, "83636040-7f54-49f2-ae40-9a1213614729", "../x", role.Admin)
+
+	assert.Equal(t, errors.ErrInvalidInput, err)
+	assert.Nil(t, result)
+	setup.MockRecordingRepo.AssertNotCalled(t, "GetByID", mock.Anything)
+}
+
+// Role: Editor (Unauthorized)
+func TestGetRecordStatus_Editor_Unauthorized(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	result, err := setup.UseCase.GetRecordStatus(ctx, "83636040-7f54-49f2-ae40-9a1213614729", "", role.Editor)
+
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	assert.Nil(t, result)
+}
+
+// ================================================================================
+// Playlist updates (1 test)
+// ================================================================================
+
+func TestHandlePlaylistUpdate_EvictsCachedPlaylist(t *testing.T) {
+	setup := setupLivestream()
+	setup.MockFileCache.On("DeleteFile", "/test/root/hls/livestream123/playlist.m3u8").Return()
+
+	setup.UseCase.HandlePlaylistUpdate("livestream123", "/test/root/hls/livestream123/playlist.m3u8")
+
+	setup.MockFileCache.AssertExpectations(t)
+}
+
+// ================================================================================
+// API: GetCacheStats (2 tests)
+// ================================================================================
+
+// Role: Admin
+func TestGetCacheStats_Admin_Success(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	stats := file_cache.CacheStats{Hits: 8, Misses: 2, Evictions: 1, Entries: 3, Bytes: 1024, MaxBytes: 4096}
+	setup.MockFileCache.On("Stats").Return(stats)
+
+	result, err := setup.UseCase.GetCacheStats(ctx, role.Admin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &stats, result)
+}
+
+// Role: Editor (Unauthorized)
+func TestGetCacheStats_Editor_Unauthorized(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	result, err := setup.UseCase.GetCacheStats(ctx, role.Editor)
+
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	setup.MockFileCache.AssertNotCalled(t, "Stats")
+}
+
+// ================================================================================
+// API: Share link playback (13 tests)
+// Visibility: Link
+// ================================================================================
+
+const shareLinkStreamUUID = "83636040-7f54-49f2-ae40-9a1213614729"
+
+// withShareLink makes token a valid share link of the Link livestream
+func (s *LivestreamTestSetup) withShareLink(token string, link *livestream.ShareLink) {
+	s.MockRepo.On("GetByID", shareLinkStreamUUID).Return(&livestream.Livestream{
+		UUID:       shareLinkStreamUUID,
+		Visibility: livestream.Link,
+	}, nil)
+	claims := &dto.PlaybackClaims{LinkID: link.ID}
+	claims.Subject = shareLinkStreamUUID
+	s.MockJWTGenerator.On("ParsePlaybackToken", token, mock.Anything).Return(claims, nil)
+	s.MockShareLinkRepo.On("GetByID", link.ID).Return(link, nil)
+}
+
+func TestGetFile_Link_ValidToken_SignsPlaylist(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.withShareLink("signed", &livestream.ShareLink{ID: "link-1", LivestreamUUID: shareLinkStreamUUID, ExpiresAt: time.Now().Add(time.Hour)})
+
+	filePath := "/test/root/hls/" + shareLinkStreamUUID + "/playlist.m3u8"
+	raw := []byte("#EXTM3U\n#EXTINF:2.000,\nstream-1.ts\n")
+	setup.MockFileCache.On("LoadCache", filePath).Return(raw, true)
+
+	file, err := setup.UseCase.GetFile(ctx, "/test/root", shareLinkStreamUUID, "playlist.m3u8", livestreamDto.LivestreamPlaylistReloadDTO{}, "signed", role.Anonymous)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "#EXTM3U\n#EXTINF:2.000,\nstream-1.ts?token=signed\n", string(file.Data))
+	// Signed playlists must not be shared by caches
+	assert.False(t, file.Public)
+	// The cache keeps the playlist as written
+	assert.Equal(t, "#EXTM3U\n#EXTINF:2.000,\nstream-1.ts\n", string(raw))
+}
+
+func TestGetFile_Link_ValidToken_Segment(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.withShareLink("signed", &livestream.ShareLink{ID: "link-1", LivestreamUUID: shareLinkStreamUUID, ExpiresAt: time.Now().Add(time.Hour)})
+
+	filePath := "/test/root/hls/" + shareLinkStreamUUID + "/stream-1.ts"
+	setup.MockFileCache.On("LoadCache", filePath).Return([]byte("ts"), true)
+
+	file, err := setup.UseCase.GetFile(ctx, "/test/root", shareLinkStreamUUID, "stream-1.ts", livestreamDto.LivestreamPlaylistReloadDTO{}, "signed", role.Anonymous)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ts", string(file.Data))
+}
+
+func TestGetFile_Link_NoToken_Unauthorized(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.MockRepo.On("GetByID", shareLinkStreamUUID).Return(&livestream.Livestream{
+		UUID:       shareLinkStreamUUID,
+		Visibility: livestream.Link,
+	}, nil)
+
+	file, err := setup.UseCase.GetFile(ctx, "/test/root", shareLinkStreamUUID, "playlist.m3u8", livestreamDto.LivestreamPlaylistReloadDTO{}, "", role.User)
+
+	assert.Nil(t, file)
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	setup.MockFileCache.AssertNotCalled(t, "LoadCache", mock.Anything)
+}
+
+func TestGetFile_Link_InvalidToken_Unauthorized(t *testing.T) {
+	tests := []struct {
+		name string
+		link *livestream.ShareLink
+		err  error
+	}{
+		{"expired", &livestream.ShareLink{ID: "link-1", LivestreamUUID: shareLinkStreamUUID, ExpiresAt: time.Now().Add(-time.Minute)}, nil},
+		{"other livestream", &livestream.ShareLink{ID: "link-1", LivestreamUUID: "other", ExpiresAt: time.Now().Add(time.Hour)}, nil},
+		{"revoked", nil, errors.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupLivestream()
+			ctx := context.Background()
+			setup.MockRepo.On("GetByID", shareLinkStreamUUID).Return(&livestream.Livestream{
+				UUID:       shareLinkStreamUUID,
+				Visibility: livestream.Link,
+			}, nil)
+			claims := &dto.PlaybackClaims{LinkID: "link-1"}
+			claims.Subject = shareLinkStreamUUID
+			setup.MockJWTGenerator.On("ParsePlaybackToken", "signed", mock.Anything).Return(claims, nil)
+			setup.MockShareLinkRepo.On("GetByID", "link-1").Return(tt.link, tt.err)
+
+			file, err := setup.UseCase.GetFile(ctx, "/test/root", shareLinkStreamUUID, "playlist.m3u8", livestreamDto.LivestreamPlaylistReloadDTO{}, "signed", role.Anonymous)
+
+			assert.Nil(t, file)
+			assert.Equal(t, errors.ErrUnauthorized, err)
+		})
+	}
+}
+
+func TestGetFile_Link_TokenOfOtherLivestream_Unauthorized(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.MockRepo.On("GetByID", shareLinkStreamUUID).Return(&livestream.Livestream{
+		UUID:       shareLinkStreamUUID,
+		Visibility: livestream.Link,
+	}, nil)
+	claims := &dto.PlaybackClaims{LinkID: "link-1"}
+	claims.Subject = "other"
+	setup.MockJWTGenerator.On("ParsePlaybackToken", "signed", mock.Anything).Return(claims, nil)
+
+	file, err := setup.UseCase.GetFile(ctx, "/test/root", shareLinkStreamUUID, "playlist.m3u8", livestreamDto.LivestreamPlaylistReloadDTO{}, "signed", role.Anonymous)
+
+	assert.Nil(t, file)
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	setup.MockShareLinkRepo.AssertNotCalled(t, "GetByID", mock.Anything)
+}
+
+// Role: Admin watches without a link
+func TestGetFile_Link_Admin_Success(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.MockRepo.On("GetByID", shareLinkStreamUUID).Return(&livestream.Livestream{
+		UUID:       shareLinkStreamUUID,
+		Visibility: livestream.Link,
+	}, nil)
+	filePath := "/test/root/hls/" + shareLinkStreamUUID + "/playlist.m3u8"
+	setup.MockFileCache.On("LoadCache", filePath).Return([]byte("#EXTM3U\nstream-1.ts\n"), true)
+
+	file, err := setup.UseCase.GetFile(ctx, "/test/root", shareLinkStreamUUID, "playlist.m3u8", livestreamDto.LivestreamPlaylistReloadDTO{}, "", role.Admin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "#EXTM3U\nstream-1.ts\n", string(file.Data))
+}
+
+func TestGetOne_Link_ValidToken_SignsURLs(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.withShareLink("signed", &livestream.ShareLink{ID: "link-1", LivestreamUUID: shareLinkStreamUUID, ExpiresAt: time.Now().Add(time.Hour)})
+
+	result, err := setup.UseCase.GetOne(ctx, shareLinkStreamUUID, "signed", role.Anonymous)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/livestream/"+shareLinkStreamUUID+"/playlist.m3u8?token=signed", result.StreamURL)
+	assert.Equal(t, "http://localhost:8080/livestream/"+shareLinkStreamUUID+"/thumbnail.jpg?token=signed", result.ThumbnailURL)
+}
+
+func TestGetFile_Link_CachesShareLinkLookup(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.withShareLink("signed", &livestream.ShareLink{ID: "link-1", LivestreamUUID: shareLinkStreamUUID, ExpiresAt: time.Now().Add(time.Hour)})
+	setup.MockFileCache.On("LoadCache", mock.Anything).Return([]byte("ts"), true)
+
+	for _, filename := range []string{"stream-1.ts", "stream-2.ts"} {
+		_, err := setup.UseCase.GetFile(ctx, "/test/root", shareLinkStreamUUID, filename, livestreamDto.LivestreamPlaylistReloadDTO{}, "signed", role.Anonymous)
+		assert.NoError(t, err)
+	}
+
+	setup.MockShareLinkRepo.AssertNumberOfCalls(t, "GetByID", 1)
+}
+
+func TestPingViewerCount_Link_ValidToken_Success(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.withShareLink("signed", &livestream.ShareLink{ID: "link-1", LivestreamUUID: shareLinkStreamUUID, ExpiresAt: time.Now().Add(time.Hour)})
+	setup.MockViewerCountCache.On("AddViewerCount", shareLinkStreamUUID, "anon-1").Return(nil)
+	setup.MockViewerCountCache.On("GetViewerCount", shareLinkStreamUUID).Return(1, nil)
+
+	count, err := setup.UseCase.PingViewerCount(ctx, role.Anonymous, shareLinkStreamUUID, "", "anon-1", "signed")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestGetChat_Link_ValidToken_Success(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.withShareLink("signed", &livestream.ShareLink{ID: "link-1", LivestreamUUID: shareLinkStreamUUID, ExpiresAt: time.Now().Add(time.Hour)})
+	setup.MockChatCache.On("GetChat", shareLinkStreamUUID, "0", 10).Return([]chat.Chat{{ID: "chat-1"}}, nil)
+
+	chats, err := setup.UseCase.GetChat(ctx, role.Anonymous, shareLinkStreamUUID, "0", "signed")
+
+	assert.NoError(t, err)
+	assert.Len(t, chats, 1)
+}
+
+func TestGetChat_Link_NoToken_Unauthorized(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.MockRepo.On("GetByID", shareLinkStreamUUID).Return(&livestream.Livestream{
+		UUID:       shareLinkStreamUUID,
+		Visibility: livestream.Link,
+	}, nil)
+
+	chats, err := setup.UseCase.GetChat(ctx, role.User, shareLinkStreamUUID, "0", "")
+
+	assert.Nil(t, chats)
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	setup.MockChatCache.AssertNotCalled(t, "GetChat", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// A share link lets a logged in viewer chat, but never an anonymous one
+func TestAddChat_Link_ValidToken(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.withShareLink("signed", &livestream.ShareLink{ID: "link-1", LivestreamUUID: shareLinkStreamUUID, ExpiresAt: time.Now().Add(time.Hour)})
+	testChat := chat.Chat{UserID: "guest123", Message: "Hello", Role: role.Guest}
+	setup.MockChatCache.On("AddChat", shareLinkStreamUUID, testChat).Return(nil)
+
+	assert.NoError(t, setup.UseCase.AddChat(ctx, "discord", role.Guest, shareLinkStreamUUID, testChat, "signed"))
+	assert.Equal(t, errors.ErrUnauthorized, setup.UseCase.AddChat(ctx, "test", role.Anonymous, shareLinkStreamUUID, testChat, "signed"))
+	setup.MockChatCache.AssertNumberOfCalls(t, "AddChat", 1)
+}
+
+func TestSubscribeLiveFLV_Link_ValidToken_Success(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.withShareLink("signed", &livestream.ShareLink{ID: "link-1", LivestreamUUID: shareLinkStreamUUID, ExpiresAt: time.Now().Add(time.Hour)})
+	subscription := new(mock_data.MockFlvSubscription)
+	setup.MockStreamService.On("SubscribeFLV", shareLinkStreamUUID).Return(subscription, nil)
+
+	result, err := setup.UseCase.SubscribeLiveFLV(ctx, shareLinkStreamUUID, "signed", role.Anonymous)
+
+	assert.NoError(t, err)
+	assert.Equal(t, subscription, result)
+}
+
+// ================================================================================
+// API: HLS encryption (8 tests)
+// ================================================================================
+
+var testHLSKey = []byte("0123456789abcdef")
+
+func TestGetFile_Encrypted_EncryptsSegment(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.MockRepo.On("GetByID", shareLinkStreamUUID).Return(&livestream.Livestream{
+		UUID:       shareLinkStreamUUID,
+		Visibility: livestream.Public,
+		Encrypted:  true,
+	}, nil)
+	segment := []byte("plain segment")
+	filePath := "/test/root/hls/" + shareLinkStreamUUID + "/720p/segment-1.ts"
+	expected, _ := util.EncryptSegment(segment, testHLSKey, util.SegmentIV("720p/segment-1.ts"))
+	setup.MockFileCache.On("LoadCache", filePath+"#7").Return([]byte(nil), false)
+	setup.MockFileCache.On("ReadFile", filePath).Return(segment, nil)
+	setup.MockFileCache.On("WatchStream", shareLinkStreamUUID, mock.Anything).Return()
+	setup.MockFileCache.On("StoreCache", filePath+"#7", expected).Return()
+	setup.MockHLSKeyStore.On("KeyID", shareLinkStreamUUID, "720p/segment-1.ts").Return("7", nil)
+	setup.MockHLSKeyStore.On("Key", shareLinkStreamUUID, "7").Return(testHLSKey, nil)
+
+	file, err := setup.UseCase.GetFile(ctx, "/test/root", shareLinkStreamUUID, "720p/segment-1.ts", livestreamDto.LivestreamPlaylistReloadDTO{}, "", role.Anonymous)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, file.Data)
+	// Only the segment as served is cached
+	setup.MockFileCache.AssertExpectations(t)
+	setup.MockFileCache.AssertNotCalled(t, "LoadCache", filePath)
+	setup.MockFileCache.AssertNotCalled(t, "StoreCache", filePath, mock.Anything)
+	assert.Equal(t, "plain segment", string(segment))
+}
+
+func TestGetFile_Encrypted_ServesCachedCiphertext(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.MockRepo.On("GetByID", shareLinkStreamUUID).Return(&livestream.Livestream{
+		UUID:       shareLinkStreamUUID,
+		Visibility: livestream.Public,
+		Encrypted:  true,
+	}, nil)
+	filePath := "/test/root/hls/" + shareLinkStreamUUID + "/segment-1.ts"
+	setup.MockFileCache.On("LoadCache", filePath+"#7").Return([]byte("ciphertext"), true)
+	setup.MockHLSKeyStore.On("KeyID", shareLinkStreamUUID, "segment-1.ts").Return("7", nil)
+
+	file, err := setup.UseCase.GetFile(ctx, "/test/root", shareLinkStreamUUID, "segment-1.ts", livestreamDto.LivestreamPlaylistReloadDTO{}, "", role.Anonymous)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ciphertext", string(file.Data))
+	setup.MockHLSKeyStore.AssertNotCalled(t, "Key", mock.Anything, mock.Anything)
+	setup.MockFileCache.AssertNotCalled(t, "ReadFile", mock.Anything)
+}
+
+func TestGetFile_Encrypted_PlaylistPointsAtKeys(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.MockRepo.On("GetByID", shareLinkStreamUUID).Return(&livestream.Livestream{
+		UUID:       shareLinkStreamUUID,
+		Visibility: livestream.Link,
+		Encrypted:  true,
+	}, nil)
+	claims := &dto.PlaybackClaims{LinkID: "link-1"}
+	claims.Subject = shareLinkStreamUUID
+	setup.MockJWTGenerator.On("ParsePlaybackToken", "signed", mock.Anything).Return(claims, nil)
+	setup.MockShareLinkRepo.On("GetByID", "link-1").Return(&livestream.ShareLink{ID: "link-1", LivestreamUUID: shareLinkStreamUUID, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+	setup.MockFileCache.On("LoadCache", "/test/root/hls/"+shareLinkStreamUUID+"/playlist.m3u8").Return([]byte("#EXTM3U\n#EXTINF:2.000,\nstream-1.ts\n"), true)
+	setup.MockHLSKeyStore.On("KeyID", shareLinkStreamUUID, "stream-1.ts").Return("7", nil)
+
+	file, err := setup.UseCase.GetFile(ctx, "/test/root", shareLinkStreamUUID, "playlist.m3u8", livestreamDto.LivestreamPlaylistReloadDTO{}, "signed", role.Anonymous)
+
+	assert.NoError(t, err)
+	expected := "#EXTM3U\n" +
+		fmt.Sprintf("#EXT-X-KEY:METHOD=AES-128,URI=\"http://localhost:8080/livestream/%s/key/7?token=signed\",IV=0x%x\n", shareLinkStreamUUID, util.SegmentIV("stream-1.ts")) +
+		"#EXTINF:2.000,\n" +
+		"stream-1.ts?token=signed\n"
+	assert.Equal(t, expected, string(file.Data))
+}
+
+func TestGetKey_Public_Anonymous_Success(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.MockRepo.On("GetByID", shareLinkStreamUUID).Return(&livestream.Livestream{
+		UUID:       shareLinkStreamUUID,
+		Visibility: livestream.Public,
+		Encrypted:  true,
+	}, nil)
+	setup.MockHLSKeyStore.On("Key", shareLinkStreamUUID, "7").Return(testHLSKey, nil)
+
+	key, err := setup.UseCase.GetKey(ctx, shareLinkStreamUUID, "7", "", "", "", role.Anonymous)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testHLSKey, key)
+}
+
+func TestGetKey_MemberOnly_Guest_Unauthorized(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.MockRepo.On("GetByID", shareLinkStreamUUID).Return(&livestream.Livestream{
+		UUID:       shareLinkStreamUUID,
+		Visibility: livestream.MemberOnly,
+		Encrypted:  true,
+	}, nil)
+
+	key, err := setup.UseCase.GetKey(ctx, shareLinkStreamUUID, "7", "", "discord", "user-1", role.Guest)
+
+	assert.Nil(t, key)
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	setup.MockHLSKeyStore.AssertNotCalled(t, "Key", mock.Anything, mock.Anything)
+}
+
+func TestGetKey_BannedUser_Unauthorized(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.MockRepo.On("GetByID", shareLinkStreamUUID).Return(&livestream.Livestream{
+		UUID:       shareLinkStreamUUID,
+		Visibility: livestream.MemberOnly,
+		Encrypted:  true,
+		BanList:    []string{"discord-user-1"},
+	}, nil)
+
+	key, err := setup.UseCase.GetKey(ctx, shareLinkStreamUUID, "7", "", "discord", "user-1", role.User)
+
+	assert.Nil(t, key)
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	setup.MockHLSKeyStore.AssertNotCalled(t, "Key", mock.Anything, mock.Anything)
+}
+
+func TestGetKey_NotEncrypted_NotFound(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.MockRepo.On("GetByID", shareLinkStreamUUID).Return(&livestream.Livestream{
+		UUID:       shareLinkStreamUUID,
+		Visibility: livestream.Public,
+	}, nil)
+
+	key, err := setup.UseCase.GetKey(ctx, shareLinkStreamUUID, "7", "", "", "", role.Admin)
+
+	assert.Nil(t, key)
+	assert.Equal(t, errors.ErrNotFound, err)
+}
+
+// Keys are kept as long as the segments stay in the playlists
+func TestPruneHLSKeys_BySegmentLifetime(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+	setup.MockRepo.On("List").Return([]*livestream.Livestream{
+		{UUID: "live", Encrypted: true, FragmentDurationMs: 2000, FragmentNum: 6},
+		{UUID: "dvr", Encrypted: true, FragmentDurationMs: 2000, FragmentNum: 6, DVRWindowSeconds: 3600},
+		{UUID: "plain"},
+	}, nil)
+	setup.MockHLSKeyStore.On("Prune", "live", 12*time.Second).Return(nil)
+	setup.MockHLSKeyStore.On("Prune", "dvr", time.Hour).Return(nil)
+	setup.MockHLSKeyStore.On("Prune", "plain", time.Duration(livestream.DefaultFragmentDurationMs*livestream.DefaultFragmentNum)*time.Millisecond).Return(nil)
+
+	setup.UseCase.PruneHLSKeys(ctx)
+
+	setup.MockHLSKeyStore.AssertExpectations(t)
+}
+
+// ================================================================================
+// API: GetDeleteChatIDs (4 tests)
+// Grouped by: Visibility -> Role
+// ================================================================================
+
+// Visibility: Public - Role: Anonymous
+func TestGetDeleteChatIDs_Public_Anonymous_Success(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	testLivestream := &livestream.Livestream{
+		UUID:       "test-uuid",
+		Visibility: livestream.Public,
+	}
+
+	deletedIDs := []string{"chat1", "chat2"}
+
+	setup.MockRepo.On("GetByID", "test-uuid").Return(testLivestream, nil)
+	setup.MockChatCache.On("GetDeleteChatIDs", "test-uuid").Return(deletedIDs, nil)
+
+	result, err := setup.UseCase.GetDeleteChatIDs(ctx, role.Anonymous, "test-uuid", "")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.Equal(t, deletedIDs, result)
+	setup.MockRepo.AssertExpectations(t)
+	setup.MockChatCache.AssertExpectations(t)
+}
+
+// Visibility: MemberOnly - Role: Anonymous (Unauthorized)
+func TestGetDeleteChatIDs_MemberOnly_Anonymous_Unauthorized(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	testLivestream := &livestream.Livestream{
+		UUID:       "test-uuid",
+		Visibility: livestream.MemberOnly,
+	}
+
+	setup.MockRepo.On("GetByID", "test-uuid").Return(testLivestream, nil)
+
+	result, err := setup.UseCase.GetDeleteChatIDs(ctx, role.Anonymous, "test-uuid", "")
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	setup.MockRepo.AssertExpectations(t)
+}
+
+// Visibility: Public - Role: Guest
+func TestGetDeleteChatIDs_Public_Guest_Success(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	testLivestream := &livestream.Livestream{
+		UUID:       "test-uuid",
+		Visibility: livestream.Public,
+	}
+
+	deletedIDs := []string{"chat1", "chat2"}
+
+	setup.MockRepo.On("GetByID", "test-uuid").Return(testLivestream, nil)
+	setup.MockChatCache.On("GetDeleteChatIDs", "test-uuid").Return(deletedIDs, nil)
+
+	result, err := setup.UseCase.GetDeleteChatIDs(ctx, role.Guest, "test-uuid", "")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.Equal(t, deletedIDs, result)
+	setup.MockRepo.AssertExpectations(t)
+	setup.MockChatCache.AssertExpectations(t)
+}
+
+// Visibility: MemberOnly - Role: Guest (Unauthorized)
+func TestGetDeleteChatIDs_MemberOnly_Guest_Unauthorized(t *testing.T) {
+	setup := setupLivestream()
+	ctx := context.Background()
+
+	testLivestream := &livestream.Livestream{
+		UUID:       "test-uuid",
+		Visibility: livestream.MemberOnly,
+	}
+
+	setup.MockRepo.On("GetByID", "test-uuid").Return(testLivestream, nil)
+
+	result, err := setup.UseCase.GetDeleteChatIDs(ctx, role.Guest, "test-uuid", "")
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	setup.MockRepo.AssertExpectations(t)
+}
+
+// ================================================================================
+// Path Validation Tests for GetFile (Table-Driven)
+// ================================================================================
+
+func TestGetFile_PathValidation(t *testing.T) {
+	validUUID := "83636040-7f54-49f2-ae40-9a1213614729"
+	rootPath := "/test/root"
+
+	tests := []struct {
 		name           string
 		uuid           string
 		filename       string
@@ -1895,7 +3284,7 @@ func TestGetFile_PathValidation(t *testing.T) {
 					UUID:       validUUID,
 					Visibility: livestream.Public,
 				}
-				setup.MockRepo.On("GetOne").Return(testLivestream, nil)
+				setup.MockRepo.On("GetByID", validUUID).Return(testLivestream, nil)
 			}
 
 			if tt.needMock {
@@ -1904,7 +3293,7 @@ func TestGetFile_PathValidation(t *testing.T) {
 				setup.MockFileCache.On("LoadCache", filePath).Return(testFileData, true)
 			}
 
-			file, err := setup.UseCase.GetFile(ctx, rootPath, tt.uuid, tt.filename, role.User)
+			file, err := setup.UseCase.GetFile(ctx, rootPath, tt.uuid, tt.filename, livestreamDto.LivestreamPlaylistReloadDTO{}, "", role.User)
 
 			if tt.expectError != nil {
 				assert.Error(t, err)
@@ -1934,6 +3323,7 @@ func TestGetRecord_PathValidation(t *testing.T) {
 	tests := []struct {
 		name        string
 		uuid        string
+		recordingID string
 		expectError error
 		needMock    bool
 	}{
@@ -1953,6 +3343,12 @@ func TestGetRecord_PathValidation(t *testing.T) {
 			uuid:        "",
 			expectError: errors.ErrInvalidInput,
 		},
+		{
+			name:        "Invalid recording ID",
+			uuid:        validUUID,
+			recordingID: "../../other",
+			expectError: errors.ErrInvalidInput,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1961,11 +3357,14 @@ func TestGetRecord_PathValidation(t *testing.T) {
 			ctx := context.Background()
 
 			if tt.needMock {
-				filePath := filepath.Join(rootPath, "hls", tt.uuid, "*.mp4")
+				setup.MockRecordingRepo.On("ListByLivestream", tt.uuid).Return([]*livestream.Recording{
+					{ID: recordingID, LivestreamUUID: tt.uuid, Status: livestream.RecordingStatusFinished},
+				}, nil)
+				filePath := filepath.Join(rootPath, "hls", tt.uuid, "recordings", recordingID, "*.mp4")
 				setup.MockFileCache.On("GetSingleFileName", filePath).Return("output.mp4", nil)
 			}
 
-			result, err := setup.UseCase.GetRecord(ctx, rootPath, tt.uuid, role.Admin)
+			result, err := setup.UseCase.GetRecord(ctx, rootPath, tt.uuid, tt.recordingID, role.Admin)
 
 			if tt.expectError != nil {
 				assert.Error(t, err)
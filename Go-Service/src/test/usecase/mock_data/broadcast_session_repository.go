@@ -0,0 +1,32 @@
+package mock_data
+
+import (
+	"Go-Service/src/main/domain/entity/livestream"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockBroadcastSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockBroadcastSessionRepository) Create(session *livestream.BroadcastSession) error {
+	args := m.Called(session)
+	return args.Error(0)
+}
+
+func (m *MockBroadcastSessionRepository) End(id string, endedAt time.Time) error {
+	args := m.Called(id, endedAt)
+	return args.Error(0)
+}
+
+func (m *MockBroadcastSessionRepository) UpdatePeakViewers(livestreamUUID string, viewers int) error {
+	args := m.Called(livestreamUUID, viewers)
+	return args.Error(0)
+}
+
+func (m *MockBroadcastSessionRepository) CloseOpen(endedAt time.Time) error {
+	args := m.Called(endedAt)
+	return args.Error(0)
+}
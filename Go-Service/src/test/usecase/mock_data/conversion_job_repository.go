@@ -0,0 +1,53 @@
+package mock_data
+
+import (
+	"Go-Service/src/main/domain/entity/livestream"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockConversionJobRepository struct {
+	mock.Mock
+}
+
+func (m *MockConversionJobRepository) GetByRecordingID(recordingID string) (*livestream.ConversionJob, error) {
+	args := m.Called(recordingID)
+	if job, ok := args.Get(0).(*livestream.ConversionJob); ok {
+		return job, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockConversionJobRepository) Enqueue(job *livestream.ConversionJob) error {
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+func (m *MockConversionJobRepository) Claim(now, lockedUntil time.Time) (*livestream.ConversionJob, error) {
+	args := m.Called(now, lockedUntil)
+	if job, ok := args.Get(0).(*livestream.ConversionJob); ok {
+		return job, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockConversionJobRepository) RenewLease(id string, attempt int, lockedUntil time.Time) error {
+	args := m.Called(id, attempt, lockedUntil)
+	return args.Error(0)
+}
+
+func (m *MockConversionJobRepository) UpdateProgress(id string, progress float64) error {
+	args := m.Called(id, progress)
+	return args.Error(0)
+}
+
+func (m *MockConversionJobRepository) Update(job *livestream.ConversionJob) error {
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+func (m *MockConversionJobRepository) Release(id string, attempt int, now time.Time) error {
+	args := m.Called(id, attempt, now)
+	return args.Error(0)
+}
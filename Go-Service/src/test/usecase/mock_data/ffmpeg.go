@@ -1,11 +1,38 @@
 package mock_data
 
-import "Go-Service/src/main/domain/interface/libarary/ffmpeg"
+import (
+	"Go-Service/src/main/domain/interface/libarary/ffmpeg"
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
 
 type MockFfmpegLibrary struct {
-	ffmpeg.FfmpegLibrary
+	mock.Mock
+}
+
+func (m *MockFfmpegLibrary) ConvertToMp4(ctx context.Context, job ffmpeg.ConvertJob) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
+
+func (m *MockFfmpegLibrary) StartTranscode(job ffmpeg.TranscodeJob) (ffmpeg.Transcoder, error) {
+	args := m.Called(job)
+	if transcoder, ok := args.Get(0).(ffmpeg.Transcoder); ok {
+		return transcoder, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+type MockTranscoder struct {
+	mock.Mock
+}
+
+func (m *MockTranscoder) Stop() {
+	m.Called()
 }
 
-func (m *MockFfmpegLibrary) ConvertStreamToMp4(filePath string, fileName string) error {
-	return nil
+func (m *MockFfmpegLibrary) CaptureThumbnail(segmentPath string, outputPath string) error {
+	args := m.Called(segmentPath, outputPath)
+	return args.Error(0)
 }
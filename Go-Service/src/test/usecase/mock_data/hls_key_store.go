@@ -0,0 +1,29 @@
+package mock_data
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockHLSKeyStore struct {
+	mock.Mock
+}
+
+func (m *MockHLSKeyStore) KeyID(uuid, segment string) (string, error) {
+	args := m.Called(uuid, segment)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockHLSKeyStore) Key(uuid, id string) ([]byte, error) {
+	args := m.Called(uuid, id)
+	if key, ok := args.Get(0).([]byte); ok {
+		return key, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockHLSKeyStore) Prune(uuid string, maxAge time.Duration) error {
+	args := m.Called(uuid, maxAge)
+	return args.Error(0)
+}
@@ -2,6 +2,7 @@ package mock_data
 
 import (
 	"Go-Service/src/main/application/dto"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -22,3 +23,16 @@ func (m *MockJWTGenerator) ParseAnonymousViewerToken(tokenString, secretKey stri
 	}
 	return nil, args.Error(1)
 }
+
+func (m *MockJWTGenerator) GeneratePlaybackToken(linkID, livestreamUUID string, expiresAt time.Time, secretKey string) (string, error) {
+	args := m.Called(linkID, livestreamUUID, expiresAt, secretKey)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockJWTGenerator) ParsePlaybackToken(tokenString, secretKey string) (*dto.PlaybackClaims, error) {
+	args := m.Called(tokenString, secretKey)
+	if args.Get(0) != nil {
+		return args.Get(0).(*dto.PlaybackClaims), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
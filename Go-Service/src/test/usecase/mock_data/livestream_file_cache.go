@@ -1,11 +1,16 @@
 package mock_data
 
 import (
+	"Go-Service/src/main/domain/interface/file_cache"
+	"time"
+
 	"github.com/stretchr/testify/mock"
 )
 
 type MockFileCache struct {
 	mock.Mock
+	// ModTimes are the modification times ModTime reports, zero when absent
+	ModTimes map[string]time.Time
 }
 
 func (m *MockFileCache) GetSingleFileName(filePath string) (string, error) {
@@ -18,6 +23,10 @@ func (m *MockFileCache) ReadFile(filePath string) ([]byte, error) {
 	return args.Get(0).([]byte), args.Error(1)
 }
 
+func (m *MockFileCache) ModTime(filePath string) (time.Time, error) {
+	return m.ModTimes[filePath], nil
+}
+
 func (m *MockFileCache) StoreCache(filePath string, data []byte) {
 	m.Called(filePath, data)
 }
@@ -31,6 +40,19 @@ func (m *MockFileCache) DeleteFile(filePath string) {
 	m.Called(filePath)
 }
 
-func (m *MockFileCache) Range(f func(key, value interface{}) bool) {
-	m.Called(f)
+func (m *MockFileCache) Stats() file_cache.CacheStats {
+	args := m.Called()
+	return args.Get(0).(file_cache.CacheStats)
+}
+
+func (m *MockFileCache) LockFile(filePath string) func() {
+	return func() {}
+}
+
+func (m *MockFileCache) WatchStream(uuid, dir string) {
+	m.Called(uuid, dir)
+}
+
+func (m *MockFileCache) StopStream(uuid string) {
+	m.Called(uuid)
 }
@@ -25,10 +25,10 @@ func (m *MockLivestreamRepository) GetByOwnerID(ownerID string) (*livestream.Liv
 	return nil, args.Error(1)
 }
 
-func (m *MockLivestreamRepository) GetOne() (*livestream.Livestream, error) {
+func (m *MockLivestreamRepository) List() ([]*livestream.Livestream, error) {
 	args := m.Called()
 	if args.Get(0) != nil {
-		return args.Get(0).(*livestream.Livestream), args.Error(1)
+		return args.Get(0).([]*livestream.Livestream), args.Error(1)
 	}
 	return nil, args.Error(1)
 }
@@ -47,6 +47,10 @@ func (m *MockLivestreamRepository) Delete(id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
+func (m *MockLivestreamRepository) UpdateAPIKey(id string, apiKey string) error {
+	args := m.Called(id, apiKey)
+	return args.Error(0)
+}
 func (m *MockLivestreamRepository) MuteUser(identityProvider string, livestreamUUID string, userID string) error {
 	args := m.Called(identityProvider, livestreamUUID, userID)
 	return args.Error(0)
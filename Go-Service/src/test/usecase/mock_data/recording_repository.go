@@ -0,0 +1,55 @@
+package mock_data
+
+import (
+	"Go-Service/src/main/domain/entity/livestream"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRecordingRepository struct {
+	mock.Mock
+}
+
+func (m *MockRecordingRepository) GetByID(id string) (*livestream.Recording, error) {
+	args := m.Called(id)
+	if recording, ok := args.Get(0).(*livestream.Recording); ok {
+		return recording, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRecordingRepository) ListByLivestream(livestreamUUID string) ([]*livestream.Recording, error) {
+	args := m.Called(livestreamUUID)
+	if recordings, ok := args.Get(0).([]*livestream.Recording); ok {
+		return recordings, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRecordingRepository) ListByStatus(status livestream.RecordingStatus) ([]*livestream.Recording, error) {
+	args := m.Called(status)
+	if recordings, ok := args.Get(0).([]*livestream.Recording); ok {
+		return recordings, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRecordingRepository) Create(recording *livestream.Recording) error {
+	args := m.Called(recording)
+	return args.Error(0)
+}
+
+func (m *MockRecordingRepository) Update(recording *livestream.Recording) error {
+	args := m.Called(recording)
+	return args.Error(0)
+}
+
+func (m *MockRecordingRepository) UpdateVisibility(id string, visibility livestream.Visibility) error {
+	args := m.Called(id, visibility)
+	return args.Error(0)
+}
+
+func (m *MockRecordingRepository) Delete(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
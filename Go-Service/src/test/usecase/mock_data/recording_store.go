@@ -0,0 +1,31 @@
+package mock_data
+
+import (
+	"Go-Service/src/main/domain/interface/recording"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRecordingStore struct {
+	mock.Mock
+}
+
+func (m *MockRecordingStore) Summarize(uuid, id string) (recording.Summary, error) {
+	args := m.Called(uuid, id)
+	return args.Get(0).(recording.Summary), args.Error(1)
+}
+
+func (m *MockRecordingStore) Finalize(uuid, id string) error {
+	args := m.Called(uuid, id)
+	return args.Error(0)
+}
+
+func (m *MockRecordingStore) Path(uuid, id, name string) (string, error) {
+	args := m.Called(uuid, id, name)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockRecordingStore) Delete(uuid, id string) error {
+	args := m.Called(uuid, id)
+	return args.Error(0)
+}
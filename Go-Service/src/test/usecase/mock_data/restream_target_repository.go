@@ -0,0 +1,42 @@
+package mock_data
+
+import (
+	"Go-Service/src/main/domain/entity/livestream"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRestreamTargetRepository struct {
+	mock.Mock
+}
+
+func (m *MockRestreamTargetRepository) GetByID(id string) (*livestream.RestreamTarget, error) {
+	args := m.Called(id)
+	if target, ok := args.Get(0).(*livestream.RestreamTarget); ok {
+		return target, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRestreamTargetRepository) ListByLivestream(livestreamUUID string) ([]*livestream.RestreamTarget, error) {
+	args := m.Called(livestreamUUID)
+	if targets, ok := args.Get(0).([]*livestream.RestreamTarget); ok {
+		return targets, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRestreamTargetRepository) Create(target *livestream.RestreamTarget) error {
+	args := m.Called(target)
+	return args.Error(0)
+}
+
+func (m *MockRestreamTargetRepository) Update(target *livestream.RestreamTarget) error {
+	args := m.Called(target)
+	return args.Error(0)
+}
+
+func (m *MockRestreamTargetRepository) Delete(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
@@ -0,0 +1,37 @@
+package mock_data
+
+import (
+	"Go-Service/src/main/domain/entity/livestream"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockShareLinkRepository struct {
+	mock.Mock
+}
+
+func (m *MockShareLinkRepository) GetByID(id string) (*livestream.ShareLink, error) {
+	args := m.Called(id)
+	if link, ok := args.Get(0).(*livestream.ShareLink); ok {
+		return link, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockShareLinkRepository) ListByLivestream(livestreamUUID string) ([]*livestream.ShareLink, error) {
+	args := m.Called(livestreamUUID)
+	if links, ok := args.Get(0).([]*livestream.ShareLink); ok {
+		return links, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockShareLinkRepository) Create(link *livestream.ShareLink) error {
+	args := m.Called(link)
+	return args.Error(0)
+}
+
+func (m *MockShareLinkRepository) Delete(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
@@ -1,21 +1,80 @@
 package mock_data
 
 import (
+	"Go-Service/src/main/application/interface/stream"
+	"Go-Service/src/main/domain/entity/livestream"
+	"context"
+	"time"
+
 	"github.com/stretchr/testify/mock"
 )
 
 type MockLivestreamService struct {
 	mock.Mock
+	// LiveStatuses overrides GetLiveStatus per uuid; unknown streams are idle
+	LiveStatuses map[string]livestream.LiveStatus
 }
 
-func (m *MockLivestreamService) OpenStream(name, uuid, apiKey string, isRecord bool) error {
+func (m *MockLivestreamService) OpenStream(name, uuid, apiKey string, options stream.StreamOptions) error {
 	return nil
 }
 
+func (m *MockLivestreamService) UpdateStreamOptions(uuid string, options stream.StreamOptions) error {
+	args := m.Called(uuid, options)
+	return args.Error(0)
+}
+
 func (m *MockLivestreamService) CloseStream(uuid string) error {
 	return nil
 }
 
+func (m *MockLivestreamService) UpdateStreamKey(uuid, apiKey string, dropPublisher bool) error {
+	args := m.Called(uuid, apiKey, dropPublisher)
+	return args.Error(0)
+}
+
+func (m *MockLivestreamService) KickPublisher(uuid string, blockFor time.Duration) error {
+	args := m.Called(uuid, blockFor)
+	return args.Error(0)
+}
+
+func (m *MockLivestreamService) OnPublishEvent(handler stream.PublishEventHandler) {
+}
+
+func (m *MockLivestreamService) OnPlaylistUpdate(handler stream.PlaylistUpdateHandler) {
+}
+
+func (m *MockLivestreamService) WaitLowLatencyPart(ctx context.Context, uuid string, msn, part int) error {
+	args := m.Called(uuid, msn, part)
+	return args.Error(0)
+}
+
+func (m *MockLivestreamService) WaitPreloadHint(ctx context.Context, uuid, filename string) error {
+	args := m.Called(uuid, filename)
+	return args.Error(0)
+}
+
+func (m *MockLivestreamService) SetRestreamTargetSource(source stream.RestreamTargetSource) {
+}
+
+func (m *MockLivestreamService) RefreshRestreamTargets(uuid string) error {
+	args := m.Called(uuid)
+	return args.Error(0)
+}
+
+func (m *MockLivestreamService) GetRestreamStatus(uuid string) map[string]livestream.RestreamStatus {
+	args := m.Called(uuid)
+	return args.Get(0).(map[string]livestream.RestreamStatus)
+}
+
+func (m *MockLivestreamService) SubscribeFLV(uuid string) (stream.FlvSubscription, error) {
+	args := m.Called(uuid)
+	if subscription, ok := args.Get(0).(stream.FlvSubscription); ok {
+		return subscription, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func (m *MockLivestreamService) StartService() error {
 	return nil
 }
@@ -27,3 +86,23 @@ func (m *MockLivestreamService) RunLoop() error {
 func (m *MockLivestreamService) IsLiveStreamExist(uuid string) bool {
 	return true
 }
+
+func (m *MockLivestreamService) GetLiveStatus(uuid string) (livestream.LiveStatus, bool) {
+	if status, ok := m.LiveStatuses[uuid]; ok {
+		return status, true
+	}
+	return livestream.LiveStatus{State: livestream.LiveStateIdle}, true
+}
+
+type MockFlvSubscription struct {
+	mock.Mock
+}
+
+func (m *MockFlvSubscription) Data() <-chan []byte {
+	args := m.Called()
+	return args.Get(0).(<-chan []byte)
+}
+
+func (m *MockFlvSubscription) Close() {
+	m.Called()
+}
@@ -0,0 +1,58 @@
+package mock_data
+
+import (
+	"Go-Service/src/main/domain/entity/livestream"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockThumbnailStore struct {
+	mock.Mock
+}
+
+func (m *MockThumbnailStore) LatestSegment(uuid string) (string, error) {
+	args := m.Called(uuid)
+	return args.String(0), args.Error(1)
+}
+
+// Add runs capture with the output path given as the second return value
+func (m *MockThumbnailStore) Add(uuid string, capturedAt time.Time, capture func(outputPath string) error) (*livestream.Thumbnail, error) {
+	args := m.Called(uuid)
+	if err := capture(args.String(1)); err != nil {
+		return nil, err
+	}
+	if thumbnail, ok := args.Get(0).(*livestream.Thumbnail); ok {
+		return thumbnail, args.Error(2)
+	}
+	return nil, args.Error(2)
+}
+
+func (m *MockThumbnailStore) List(uuid string) ([]livestream.Thumbnail, error) {
+	args := m.Called(uuid)
+	if thumbnails, ok := args.Get(0).([]livestream.Thumbnail); ok {
+		return thumbnails, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockThumbnailStore) Read(uuid, id string) ([]byte, error) {
+	args := m.Called(uuid, id)
+	if data, ok := args.Get(0).([]byte); ok {
+		return data, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockThumbnailStore) SetPoster(uuid, id string) error {
+	args := m.Called(uuid, id)
+	return args.Error(0)
+}
+
+func (m *MockThumbnailStore) ReadPoster(uuid string) ([]byte, error) {
+	args := m.Called(uuid)
+	if data, ok := args.Get(0).([]byte); ok {
+		return data, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
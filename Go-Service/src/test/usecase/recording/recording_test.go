@@ -0,0 +1,455 @@
+package usecase
+
+import (
+	"Go-Service/src/main/application/interface/stream"
+	"Go-Service/src/main/application/usecase"
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/domain/interface/recording"
+	"Go-Service/src/test/usecase/mock_data"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cool9850311/StreamPlatformLite-Core/pkg/role"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type RecordingTestSetup struct {
+	MockRecordingRepo  *mock_data.MockRecordingRepository
+	MockLivestreamRepo *mock_data.MockLivestreamRepository
+	MockRecordingStore *mock_data.MockRecordingStore
+	MockConversionRepo *mock_data.MockConversionJobRepository
+	UseCase            *usecase.RecordingUsecase
+}
+
+func setupRecording() *RecordingTestSetup {
+	mockRecordingRepo := new(mock_data.MockRecordingRepository)
+	mockLivestreamRepo := new(mock_data.MockLivestreamRepository)
+	mockRecordingStore := new(mock_data.MockRecordingStore)
+	mockConversionRepo := new(mock_data.MockConversionJobRepository)
+	return &RecordingTestSetup{
+		MockRecordingRepo:  mockRecordingRepo,
+		MockLivestreamRepo: mockLivestreamRepo,
+		MockRecordingStore: mockRecordingStore,
+		MockConversionRepo: mockConversionRepo,
+		UseCase:            usecase.NewRecordingUsecase(mockRecordingRepo, mockLivestreamRepo, mockConversionRepo, new(mock_data.MockLogger), mockRecordingStore),
+	}
+}
+
+func openRecording() *livestream.Recording {
+	return &livestream.Recording{
+		ID:             "session-1",
+		LivestreamUUID: "livestream123",
+		StartedAt:      time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Status:         livestream.RecordingStatusRecording,
+		Visibility:     livestream.Private,
+	}
+}
+
+// ================================================================================
+// Publish events (6 tests)
+// ================================================================================
+
+func TestHandlePublishEvent_RecordedStartCreatesRecording(t *testing.T) {
+	setup := setupRecording()
+	expected := openRecording()
+	setup.MockRecordingRepo.On("Create", expected).Return(nil)
+
+	setup.UseCase.HandlePublishEvent(stream.PublishEvent{
+		Type:      stream.PublishStarted,
+		SessionID: "session-1",
+		UUID:      "livestream123",
+		At:        expected.StartedAt,
+		Recorded:  true,
+	})
+
+	setup.MockRecordingRepo.AssertExpectations(t)
+}
+
+func TestHandlePublishEvent_NotRecordedIgnored(t *testing.T) {
+	setup := setupRecording()
+
+	setup.UseCase.HandlePublishEvent(stream.PublishEvent{Type: stream.PublishStarted, SessionID: "session-1", UUID: "livestream123", At: time.Now()})
+	setup.UseCase.HandlePublishEvent(stream.PublishEvent{Type: stream.PublishStopped, SessionID: "session-1", UUID: "livestream123", At: time.Now()})
+
+	setup.MockRecordingRepo.AssertNotCalled(t, "Create", mock.Anything)
+	setup.MockRecordingRepo.AssertNotCalled(t, "GetByID", mock.Anything)
+}
+
+func TestHandlePublishEvent_StopFinishesWithTotals(t *testing.T) {
+	setup := setupRecording()
+	endedAt := time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+	setup.MockRecordingRepo.On("GetByID", "session-1").Return(openRecording(), nil)
+	setup.MockRecordingStore.On("Summarize", "livestream123", "session-1").Return(recording.Summary{DurationSeconds: 3600, SizeBytes: 1 << 30, Segments: 900}, nil)
+	setup.MockRecordingRepo.On("Update", mock.MatchedBy(func(rec *livestream.Recording) bool {
+		return rec.Status == livestream.RecordingStatusFinished && rec.EndedAt.Equal(endedAt) &&
+			rec.DurationSeconds == 3600 && rec.SizeBytes == 1<<30
+	})).Return(nil)
+	setup.MockConversionRepo.On("Enqueue", mock.MatchedBy(func(job *livestream.ConversionJob) bool {
+		return job.RecordingID == "session-1" && job.LivestreamUUID == "livestream123" && job.Status == livestream.ConversionStatusQueued
+	})).Return(nil)
+
+	setup.UseCase.HandlePublishEvent(stream.PublishEvent{Type: stream.PublishStopped, SessionID: "session-1", UUID: "livestream123", At: endedAt, Recorded: true})
+
+	setup.MockRecordingRepo.AssertExpectations(t)
+	setup.MockConversionRepo.AssertExpectations(t)
+	// The ingest ended the playlist itself
+	setup.MockRecordingStore.AssertNotCalled(t, "Finalize", mock.Anything, mock.Anything)
+}
+
+func TestHandlePublishEvent_StopWithoutSegmentsFails(t *testing.T) {
+	setup := setupRecording()
+	setup.MockRecordingRepo.On("GetByID", "session-1").Return(openRecording(), nil)
+	setup.MockRecordingStore.On("Summarize", "livestream123", "session-1").Return(recording.Summary{}, errors.ErrNotFound)
+	setup.MockRecordingRepo.On("Update", mock.MatchedBy(func(rec *livestream.Recording) bool {
+		return rec.Status == livestream.RecordingStatusFailed && rec.EndedAt != nil
+	})).Return(nil)
+
+	setup.UseCase.HandlePublishEvent(stream.PublishEvent{Type: stream.PublishStopped, SessionID: "session-1", UUID: "livestream123", At: time.Now(), Recorded: true})
+
+	setup.MockRecordingRepo.AssertExpectations(t)
+	setup.MockConversionRepo.AssertNotCalled(t, "Enqueue", mock.Anything)
+}
+
+func TestHandlePublishEvent_StopKeepsRecordingWhenQueueFails(t *testing.T) {
+	setup := setupRecording()
+	setup.MockRecordingRepo.On("GetByID", "session-1").Return(openRecording(), nil)
+	setup.MockRecordingStore.On("Summarize", "livestream123", "session-1").Return(recording.Summary{DurationSeconds: 10, SizeBytes: 100, Segments: 5}, nil)
+	setup.MockRecordingRepo.On("Update", mock.Anything).Return(nil)
+	setup.MockConversionRepo.On("Enqueue", mock.Anything).Return(errors.ErrInternal)
+
+	setup.UseCase.HandlePublishEvent(stream.PublishEvent{Type: stream.PublishStopped, SessionID: "session-1", UUID: "livestream123", At: time.Now(), Recorded: true})
+
+	setup.MockRecordingRepo.AssertExpectations(t)
+	setup.MockConversionRepo.AssertExpectations(t)
+}
+
+func TestCloseDanglingRecordings_MarksInterrupted(t *testing.T) {
+	setup := setupRecording()
+	setup.MockRecordingRepo.On("ListByStatus", livestream.RecordingStatusRecording).Return([]*livestream.Recording{openRecording()}, nil)
+	setup.MockRecordingStore.On("Finalize", "livestream123", "session-1").Return(nil)
+	setup.MockConversionRepo.On("Enqueue", mock.Anything).Return(nil)
+	setup.MockRecordingStore.On("Summarize", "livestream123", "session-1").Return(recording.Summary{DurationSeconds: 60, SizeBytes: 1000, Segments: 15}, nil)
+	setup.MockRecordingRepo.On("Update", mock.MatchedBy(func(rec *livestream.Recording) bool {
+		return rec.Status == livestream.RecordingStatusInterrupted && rec.DurationSeconds == 60
+	})).Return(nil)
+
+	err := setup.UseCase.CloseDanglingRecordings(context.Background())
+
+	assert.NoError(t, err)
+	setup.MockRecordingRepo.AssertExpectations(t)
+	setup.MockRecordingStore.AssertExpectations(t)
+	setup.MockConversionRepo.AssertExpectations(t)
+}
+
+// ================================================================================
+// API: ListRecordings / GetRecording (5 tests)
+// ================================================================================
+
+func TestListRecordings_NonAdmin_Unauthorized(t *testing.T) {
+	setup := setupRecording()
+
+	for _, r := range []role.Role{role.Editor, role.User, role.Guest, role.Anonymous} {
+		result, err := setup.UseCase.ListRecordings(context.Background(), "livestream123", r)
+		assert.Equal(t, errors.ErrUnauthorized, err)
+		assert.Nil(t, result)
+	}
+	setup.MockRecordingRepo.AssertNotCalled(t, "ListByLivestream", mock.Anything)
+}
+
+func TestListRecordings_LiveTotalsForOpenRecording(t *testing.T) {
+	setup := setupRecording()
+	endedAt := time.Date(2024, 4, 30, 13, 0, 0, 0, time.UTC)
+	finished := &livestream.Recording{ID: "session-0", LivestreamUUID: "livestream123", EndedAt: &endedAt, DurationSeconds: 10, SizeBytes: 100, Status: livestream.RecordingStatusFinished}
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123"}, nil)
+	setup.MockRecordingRepo.On("ListByLivestream", "livestream123").Return([]*livestream.Recording{openRecording(), finished}, nil)
+	setup.MockRecordingStore.On("Summarize", "livestream123", "session-1").Return(recording.Summary{DurationSeconds: 42, SizeBytes: 4200, Segments: 21}, nil)
+
+	result, err := setup.UseCase.ListRecordings(context.Background(), "livestream123", role.Admin)
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, 42.0, result[0].DurationSeconds)
+	assert.Equal(t, int64(4200), result[0].SizeBytes)
+	assert.Equal(t, 10.0, result[1].DurationSeconds)
+	// Finished recordings keep their stored totals
+	setup.MockRecordingStore.AssertNotCalled(t, "Summarize", "livestream123", "session-0")
+}
+
+// Admins still manage the recordings of encrypted livestreams, which are
+// only kept out of VOD
+func TestListRecordings_EncryptedLivestream_Listed(t *testing.T) {
+	setup := setupRecording()
+	finished := vodRecording(livestream.Private)
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123", Encrypted: true}, nil)
+	setup.MockRecordingRepo.On("ListByLivestream", "livestream123").Return([]*livestream.Recording{finished}, nil)
+
+	result, err := setup.UseCase.ListRecordings(context.Background(), "livestream123", role.Admin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*livestream.Recording{finished}, result)
+}
+
+func TestListRecordings_UnknownLivestream_NotFound(t *testing.T) {
+	setup := setupRecording()
+	setup.MockLivestreamRepo.On("GetByID", "missing").Return(nil, errors.ErrNotFound)
+
+	result, err := setup.UseCase.ListRecordings(context.Background(), "missing", role.Admin)
+
+	assert.Equal(t, errors.ErrNotFound, err)
+	assert.Nil(t, result)
+}
+
+func TestGetRecording_OtherLivestream_NotFound(t *testing.T) {
+	setup := setupRecording()
+	setup.MockRecordingRepo.On("GetByID", "session-1").Return(openRecording(), nil)
+
+	result, err := setup.UseCase.GetRecording(context.Background(), "other-livestream", "session-1", role.Admin)
+
+	assert.Equal(t, errors.ErrNotFound, err)
+	assert.Nil(t, result)
+}
+
+// ================================================================================
+// API: DeleteRecording (3 tests)
+// ================================================================================
+
+func TestDeleteRecording_Success(t *testing.T) {
+	setup := setupRecording()
+	rec := openRecording()
+	rec.Status = livestream.RecordingStatusFinished
+	setup.MockRecordingRepo.On("GetByID", "session-1").Return(rec, nil)
+	setup.MockRecordingStore.On("Delete", "livestream123", "session-1").Return(nil)
+	setup.MockRecordingRepo.On("Delete", "session-1").Return(nil)
+
+	err := setup.UseCase.DeleteRecording(context.Background(), "livestream123", "session-1", role.Admin)
+
+	assert.NoError(t, err)
+	setup.MockRecordingStore.AssertExpectations(t)
+	setup.MockRecordingRepo.AssertExpectations(t)
+}
+
+func TestDeleteRecording_StillRecording_InvalidInput(t *testing.T) {
+	setup := setupRecording()
+	setup.MockRecordingRepo.On("GetByID", "session-1").Return(openRecording(), nil)
+
+	err := setup.UseCase.DeleteRecording(context.Background(), "livestream123", "session-1", role.Admin)
+
+	assert.Equal(t, errors.ErrInvalidInput, err)
+	setup.MockRecordingStore.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestDeleteRecording_FilesNotDeleted_KeepsRow(t *testing.T) {
+	setup := setupRecording()
+	rec := openRecording()
+	rec.Status = livestream.RecordingStatusInterrupted
+	setup.MockRecordingRepo.On("GetByID", "session-1").Return(rec, nil)
+	setup.MockRecordingStore.On("Delete", "livestream123", "session-1").Return(errors.ErrInternal)
+
+	err := setup.UseCase.DeleteRecording(context.Background(), "livestream123", "session-1", role.Admin)
+
+	assert.Equal(t, errors.ErrInternal, err)
+	setup.MockRecordingRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+// ================================================================================
+// VOD: UpdateRecordingVisibility / ListVODs / GetVODFile (11 tests)
+// ================================================================================
+
+const vodRecordingID = "0b7c8a52-4a8e-4c43-9d1c-5b3f1e2a9c10"
+
+// vodRecording returns an ended recording with the given visibility
+func vodRecording(visibility livestream.Visibility) *livestream.Recording {
+	endedAt := time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+	return &livestream.Recording{
+		ID:             vodRecordingID,
+		LivestreamUUID: "livestream123",
+		StartedAt:      time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		EndedAt:        &endedAt,
+		Status:         livestream.RecordingStatusFinished,
+		Visibility:     visibility,
+	}
+}
+
+func TestUpdateRecordingVisibility_Success(t *testing.T) {
+	setup := setupRecording()
+	setup.MockRecordingRepo.On("GetByID", vodRecordingID).Return(vodRecording(livestream.Private), nil)
+	setup.MockRecordingRepo.On("UpdateVisibility", vodRecordingID, livestream.MemberOnly).Return(nil)
+
+	result, err := setup.UseCase.UpdateRecordingVisibility(context.Background(), "livestream123", vodRecordingID, livestream.MemberOnly, role.Admin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, livestream.MemberOnly, result.Visibility)
+	setup.MockRecordingRepo.AssertExpectations(t)
+}
+
+func TestUpdateRecordingVisibility_NonAdmin_Unauthorized(t *testing.T) {
+	setup := setupRecording()
+
+	result, err := setup.UseCase.UpdateRecordingVisibility(context.Background(), "livestream123", vodRecordingID, livestream.Public, role.User)
+
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	assert.Nil(t, result)
+	setup.MockRecordingRepo.AssertNotCalled(t, "UpdateVisibility", mock.Anything, mock.Anything)
+}
+
+func TestUpdateRecordingVisibility_LinkOrUnknown_InvalidInput(t *testing.T) {
+	setup := setupRecording()
+
+	for _, visibility := range []livestream.Visibility{livestream.Link, "everyone", ""} {
+		result, err := setup.UseCase.UpdateRecordingVisibility(context.Background(), "livestream123", vodRecordingID, visibility, role.Admin)
+		assert.Equal(t, errors.ErrInvalidInput, err)
+		assert.Nil(t, result)
+	}
+	setup.MockRecordingRepo.AssertNotCalled(t, "UpdateVisibility", mock.Anything, mock.Anything)
+}
+
+func TestListVODs_OnlyEndedRecordingsTheUserMayWatch(t *testing.T) {
+	setup := setupRecording()
+	failed := vodRecording(livestream.Public)
+	failed.ID = "session-failed"
+	failed.Status = livestream.RecordingStatusFailed
+	private := vodRecording(livestream.Private)
+	private.ID = "session-private"
+	interrupted := vodRecording(livestream.MemberOnly)
+	interrupted.Status = livestream.RecordingStatusInterrupted
+	live := openRecording()
+	live.Visibility = livestream.Public
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123"}, nil)
+	setup.MockRecordingRepo.On("ListByLivestream", "livestream123").Return([]*livestream.Recording{live, interrupted, failed, private}, nil)
+
+	result, err := setup.UseCase.ListVODs(context.Background(), "livestream123", role.User)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*livestream.Recording{interrupted}, result)
+
+	result, err = setup.UseCase.ListVODs(context.Background(), "livestream123", role.Anonymous)
+
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func TestListVODs_EncryptedLivestream_None(t *testing.T) {
+	setup := setupRecording()
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123", Encrypted: true}, nil)
+
+	result, err := setup.UseCase.ListVODs(context.Background(), "livestream123", role.Admin)
+
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+	setup.MockRecordingRepo.AssertNotCalled(t, "ListByLivestream", mock.Anything)
+}
+
+func TestGetVODFile_AccessByVisibility(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility livestream.Visibility
+		userRole   role.Role
+		allowed    bool
+	}{
+		{"Public_Anonymous", livestream.Public, role.Anonymous, true},
+		{"MemberOnly_User", livestream.MemberOnly, role.User, true},
+		{"MemberOnly_Guest", livestream.MemberOnly, role.Guest, false},
+		{"MemberOnly_Anonymous", livestream.MemberOnly, role.Anonymous, false},
+		{"Private_Admin", livestream.Private, role.Admin, true},
+		{"Private_Editor", livestream.Private, role.Editor, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupRecording()
+			setup.MockRecordingRepo.On("GetByID", vodRecordingID).Return(vodRecording(tt.visibility), nil)
+			setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123"}, nil)
+			setup.MockRecordingStore.On("Path", "livestream123", vodRecordingID, "record.m3u8").Return("/srv/hls/livestream123/recordings/"+vodRecordingID+"/record.m3u8", nil)
+
+			result, err := setup.UseCase.GetVODFile(context.Background(), vodRecordingID, "record.m3u8", tt.userRole)
+
+			if !tt.allowed {
+				assert.Equal(t, errors.ErrUnauthorized, err)
+				setup.MockRecordingStore.AssertNotCalled(t, "Path", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "/srv/hls/livestream123/recordings/"+vodRecordingID+"/record.m3u8", result.Path)
+			// Only public recordings may be kept by a CDN
+			assert.Equal(t, tt.visibility == livestream.Public, result.Public)
+		})
+	}
+}
+
+func TestGetVODFile_Segment(t *testing.T) {
+	setup := setupRecording()
+	setup.MockRecordingRepo.On("GetByID", vodRecordingID).Return(vodRecording(livestream.Public), nil)
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123"}, nil)
+	setup.MockRecordingStore.On("Path", "livestream123", vodRecordingID, "stream-1.ts").Return("/srv/hls/livestream123/recordings/"+vodRecordingID+"/stream-1.ts", nil)
+
+	result, err := setup.UseCase.GetVODFile(context.Background(), vodRecordingID, "stream-1.ts", role.Anonymous)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/srv/hls/livestream123/recordings/"+vodRecordingID+"/stream-1.ts", result.Path)
+}
+
+func TestGetVODFile_InvalidInput(t *testing.T) {
+	tests := []struct {
+		name        string
+		recordingID string
+		filename    string
+	}{
+		{"Invalid recording ID", "../livestream123", "record.m3u8"},
+		{"Path traversal", vodRecordingID, "../index.m3u8"},
+		{"Other playlist", vodRecordingID, "index.m3u8"},
+		{"MP4 export", vodRecordingID, "Talk.mp4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupRecording()
+
+			result, err := setup.UseCase.GetVODFile(context.Background(), tt.recordingID, tt.filename, role.Admin)
+
+			assert.Equal(t, errors.ErrInvalidInput, err)
+			assert.Nil(t, result)
+			setup.MockRecordingRepo.AssertNotCalled(t, "GetByID", mock.Anything)
+		})
+	}
+}
+
+func TestGetVODFile_NotEnded_NotFound(t *testing.T) {
+	for _, status := range []livestream.RecordingStatus{livestream.RecordingStatusRecording, livestream.RecordingStatusFailed} {
+		setup := setupRecording()
+		rec := vodRecording(livestream.Public)
+		rec.Status = status
+		setup.MockRecordingRepo.On("GetByID", vodRecordingID).Return(rec, nil)
+
+		result, err := setup.UseCase.GetVODFile(context.Background(), vodRecordingID, "record.m3u8", role.Admin)
+
+		assert.Equal(t, errors.ErrNotFound, err)
+		assert.Nil(t, result)
+		setup.MockRecordingStore.AssertNotCalled(t, "Path", mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+func TestGetVODFile_MissingFile_NotFound(t *testing.T) {
+	setup := setupRecording()
+	setup.MockRecordingRepo.On("GetByID", vodRecordingID).Return(vodRecording(livestream.Public), nil)
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123"}, nil)
+	setup.MockRecordingStore.On("Path", "livestream123", vodRecordingID, "stream-9.ts").Return("", errors.ErrNotFound)
+
+	result, err := setup.UseCase.GetVODFile(context.Background(), vodRecordingID, "stream-9.ts", role.Anonymous)
+
+	assert.Equal(t, errors.ErrNotFound, err)
+	assert.Nil(t, result)
+}
+
+// The recording holds the plaintext segments of an encrypted livestream
+func TestGetVODFile_EncryptedLivestream_NotFound(t *testing.T) {
+	setup := setupRecording()
+	setup.MockRecordingRepo.On("GetByID", vodRecordingID).Return(vodRecording(livestream.Public), nil)
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123", Encrypted: true}, nil)
+
+	result, err := setup.UseCase.GetVODFile(context.Background(), vodRecordingID, "stream-1.ts", role.Anonymous)
+
+	assert.Equal(t, errors.ErrNotFound, err)
+	assert.Nil(t, result)
+	setup.MockRecordingStore.AssertNotCalled(t, "Path", mock.Anything, mock.Anything, mock.Anything)
+}
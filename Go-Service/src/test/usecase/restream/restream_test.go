@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	livestreamDto "Go-Service/src/main/application/dto/livestream"
+	"Go-Service/src/main/application/usecase"
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/test/usecase/mock_data"
+	"context"
+	"testing"
+
+	"github.com/cool9850311/StreamPlatformLite-Core/pkg/role"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type RestreamTestSetup struct {
+	MockTargetRepo     *mock_data.MockRestreamTargetRepository
+	MockLivestreamRepo *mock_data.MockLivestreamRepository
+	MockStreamService  *mock_data.MockLivestreamService
+	UseCase            *usecase.RestreamUsecase
+}
+
+func setupRestream() *RestreamTestSetup {
+	mockTargetRepo := new(mock_data.MockRestreamTargetRepository)
+	mockLivestreamRepo := new(mock_data.MockLivestreamRepository)
+	mockStreamService := new(mock_data.MockLivestreamService)
+	return &RestreamTestSetup{
+		MockTargetRepo:     mockTargetRepo,
+		MockLivestreamRepo: mockLivestreamRepo,
+		MockStreamService:  mockStreamService,
+		UseCase:            usecase.NewRestreamUsecase(mockTargetRepo, mockLivestreamRepo, new(mock_data.MockLogger), mockStreamService),
+	}
+}
+
+func TestListRestreamTargets_MergesStatus(t *testing.T) {
+	setup := setupRestream()
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123"}, nil)
+	setup.MockTargetRepo.On("ListByLivestream", "livestream123").Return([]*livestream.RestreamTarget{
+		{ID: "target-1", LivestreamUUID: "livestream123", Name: "YouTube", URL: "rtmp://a.rtmp.youtube.com/live2/key", Enabled: true},
+		{ID: "target-2", LivestreamUUID: "livestream123", Name: "Twitch", URL: "rtmp://live.twitch.tv/app/key", Enabled: false},
+	}, nil)
+	setup.MockStreamService.On("GetRestreamStatus", "livestream123").Return(map[string]livestream.RestreamStatus{
+		"target-1": {State: livestream.RestreamStateLive},
+	})
+
+	result, err := setup.UseCase.ListTargets(context.Background(), "livestream123", role.Admin)
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, livestream.RestreamStateLive, result[0].Status.State)
+	assert.Equal(t, livestream.RestreamStateIdle, result[1].Status.State)
+}
+
+func TestListRestreamTargets_Unauthorized(t *testing.T) {
+	setup := setupRestream()
+
+	result, err := setup.UseCase.ListTargets(context.Background(), "livestream123", role.Editor)
+
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	setup.MockTargetRepo.AssertNotCalled(t, "ListByLivestream", mock.Anything)
+}
+
+func TestCreateRestreamTarget_DefaultsEnabledAndRefreshes(t *testing.T) {
+	setup := setupRestream()
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123"}, nil)
+	setup.MockTargetRepo.On("ListByLivestream", "livestream123").Return([]*livestream.RestreamTarget{}, nil)
+	setup.MockTargetRepo.On("Create", mock.MatchedBy(func(target *livestream.RestreamTarget) bool {
+		return target.LivestreamUUID == "livestream123" && target.Name == "YouTube" && target.Enabled && target.ID != ""
+	})).Return(nil)
+	setup.MockStreamService.On("RefreshRestreamTargets", "livestream123").Return(nil)
+
+	result, err := setup.UseCase.CreateTarget(context.Background(), "livestream123", &livestreamDto.RestreamTargetRequestDTO{
+		Name: " YouTube ",
+		URL:  "rtmps://a.rtmps.youtube.com:443/live2/key",
+	}, role.Admin)
+
+	assert.NoError(t, err)
+	assert.True(t, result.Enabled)
+	assert.Equal(t, livestream.RestreamStateIdle, result.Status.State)
+	setup.MockTargetRepo.AssertExpectations(t)
+	setup.MockStreamService.AssertExpectations(t)
+}
+
+func TestCreateRestreamTarget_InvalidURL(t *testing.T) {
+	for _, rawURL := range []string{
+		"",
+		"http://example.com/live/key",
+		"rtmp:///live/key",
+		"rtmp://example.com/live",
+		"srt://example.com:9000",
+	} {
+		setup := setupRestream()
+
+		_, err := setup.UseCase.CreateTarget(context.Background(), "livestream123", &livestreamDto.RestreamTargetRequestDTO{
+			Name: "target",
+			URL:  rawURL,
+		}, role.Admin)
+
+		assert.Equal(t, errors.ErrInvalidInput, err, rawURL)
+		setup.MockTargetRepo.AssertNotCalled(t, "Create", mock.Anything)
+	}
+}
+
+func TestCreateRestreamTarget_TooMany(t *testing.T) {
+	setup := setupRestream()
+	existing := make([]*livestream.RestreamTarget, 10)
+	for i := range existing {
+		existing[i] = &livestream.RestreamTarget{LivestreamUUID: "livestream123"}
+	}
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123"}, nil)
+	setup.MockTargetRepo.On("ListByLivestream", "livestream123").Return(existing, nil)
+
+	_, err := setup.UseCase.CreateTarget(context.Background(), "livestream123", &livestreamDto.RestreamTargetRequestDTO{
+		Name: "one too many",
+		URL:  "rtmp://example.com/live/key",
+	}, role.Admin)
+
+	assert.Equal(t, errors.ErrInvalidInput, err)
+	setup.MockTargetRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestUpdateRestreamTarget_OtherLivestream(t *testing.T) {
+	setup := setupRestream()
+	setup.MockTargetRepo.On("GetByID", "target-1").Return(&livestream.RestreamTarget{ID: "target-1", LivestreamUUID: "other"}, nil)
+
+	err := setup.UseCase.UpdateTarget(context.Background(), "livestream123", "target-1", &livestreamDto.RestreamTargetRequestDTO{
+		Name: "target",
+		URL:  "rtmp://example.com/live/key",
+	}, role.Admin)
+
+	assert.Equal(t, errors.ErrNotFound, err)
+	setup.MockTargetRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestDeleteRestreamTarget_Success(t *testing.T) {
+	setup := setupRestream()
+	setup.MockTargetRepo.On("GetByID", "target-1").Return(&livestream.RestreamTarget{ID: "target-1", LivestreamUUID: "livestream123"}, nil)
+	setup.MockTargetRepo.On("Delete", "target-1").Return(nil)
+	setup.MockStreamService.On("RefreshRestreamTargets", "livestream123").Return(nil)
+
+	err := setup.UseCase.DeleteTarget(context.Background(), "livestream123", "target-1", role.Admin)
+
+	assert.NoError(t, err)
+	setup.MockTargetRepo.AssertExpectations(t)
+	setup.MockStreamService.AssertExpectations(t)
+}
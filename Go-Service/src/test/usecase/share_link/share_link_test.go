@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"Go-Service/src/main/application/dto/config"
+	livestreamDto "Go-Service/src/main/application/dto/livestream"
+	"Go-Service/src/main/application/usecase"
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/test/usecase/mock_data"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cool9850311/StreamPlatformLite-Core/pkg/role"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type ShareLinkTestSetup struct {
+	MockShareLinkRepo  *mock_data.MockShareLinkRepository
+	MockLivestreamRepo *mock_data.MockLivestreamRepository
+	MockJWTGenerator   *mock_data.MockJWTGenerator
+	UseCase            *usecase.ShareLinkUsecase
+}
+
+func setupShareLink() *ShareLinkTestSetup {
+	mockShareLinkRepo := new(mock_data.MockShareLinkRepository)
+	mockLivestreamRepo := new(mock_data.MockLivestreamRepository)
+	mockJWTGenerator := new(mock_data.MockJWTGenerator)
+	cfg := config.Config{}
+	cfg.Server.Domain = "localhost"
+	cfg.Server.Port = 8080
+	cfg.JWT.SecretKey = "secret"
+	return &ShareLinkTestSetup{
+		MockShareLinkRepo:  mockShareLinkRepo,
+		MockLivestreamRepo: mockLivestreamRepo,
+		MockJWTGenerator:   mockJWTGenerator,
+		UseCase:            usecase.NewShareLinkUsecase(mockShareLinkRepo, mockLivestreamRepo, new(mock_data.MockLogger), cfg, mockJWTGenerator),
+	}
+}
+
+func TestCreateShareLink_Success(t *testing.T) {
+	setup := setupShareLink()
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123", Visibility: livestream.Link}, nil)
+	setup.MockShareLinkRepo.On("Create", mock.AnythingOfType("*livestream.ShareLink")).Return(nil)
+	setup.MockJWTGenerator.On("GeneratePlaybackToken", mock.Anything, "livestream123", mock.Anything, "secret").Return("signed", nil)
+
+	before := time.Now()
+	result, err := setup.UseCase.CreateShareLink(context.Background(), "livestream123", &livestreamDto.ShareLinkCreateDTO{Name: "Press", ExpiresInSeconds: 3600}, role.Admin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Press", result.Name)
+	assert.Equal(t, "signed", result.Token)
+	assert.Equal(t, "http://localhost:8080/livestream/livestream123/playlist.m3u8?token=signed", result.PlaybackURL)
+	assert.WithinDuration(t, before.Add(time.Hour), result.ExpiresAt, 5*time.Second)
+	link := setup.MockShareLinkRepo.Calls[0].Arguments.Get(0).(*livestream.ShareLink)
+	assert.Equal(t, result.ID, link.ID)
+	assert.Equal(t, "livestream123", link.LivestreamUUID)
+}
+
+func TestCreateShareLink_DefaultTTL(t *testing.T) {
+	setup := setupShareLink()
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123", Visibility: livestream.Link}, nil)
+	setup.MockShareLinkRepo.On("Create", mock.AnythingOfType("*livestream.ShareLink")).Return(nil)
+	setup.MockJWTGenerator.On("GeneratePlaybackToken", mock.Anything, "livestream123", mock.Anything, "secret").Return("signed", nil)
+
+	before := time.Now()
+	result, err := setup.UseCase.CreateShareLink(context.Background(), "livestream123", &livestreamDto.ShareLinkCreateDTO{}, role.Admin)
+
+	assert.NoError(t, err)
+	assert.WithinDuration(t, before.Add(livestream.DefaultShareLinkTTLSeconds*time.Second), result.ExpiresAt, 5*time.Second)
+}
+
+func TestCreateShareLink_InvalidInput(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility livestream.Visibility
+		request    livestreamDto.ShareLinkCreateDTO
+	}{
+		{"not a Link livestream", livestream.Public, livestreamDto.ShareLinkCreateDTO{}},
+		{"expiry too short", livestream.Link, livestreamDto.ShareLinkCreateDTO{ExpiresInSeconds: 10}},
+		{"expiry too long", livestream.Link, livestreamDto.ShareLinkCreateDTO{ExpiresInSeconds: livestream.MaxShareLinkTTLSeconds + 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupShareLink()
+			setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123", Visibility: tt.visibility}, nil)
+
+			result, err := setup.UseCase.CreateShareLink(context.Background(), "livestream123", &tt.request, role.Admin)
+
+			assert.Nil(t, result)
+			assert.Equal(t, errors.ErrInvalidInput, err)
+			setup.MockShareLinkRepo.AssertNotCalled(t, "Create", mock.Anything)
+		})
+	}
+}
+
+func TestCreateShareLink_Unauthorized(t *testing.T) {
+	setup := setupShareLink()
+
+	result, err := setup.UseCase.CreateShareLink(context.Background(), "livestream123", &livestreamDto.ShareLinkCreateDTO{}, role.Editor)
+
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	setup.MockLivestreamRepo.AssertNotCalled(t, "GetByID", mock.Anything)
+}
+
+func TestListShareLinks_SkipsExpired(t *testing.T) {
+	setup := setupShareLink()
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123", Visibility: livestream.Link}, nil)
+	setup.MockShareLinkRepo.On("ListByLivestream", "livestream123").Return([]*livestream.ShareLink{
+		{ID: "link-1", LivestreamUUID: "livestream123", ExpiresAt: time.Now().Add(time.Hour)},
+		{ID: "link-2", LivestreamUUID: "livestream123", ExpiresAt: time.Now().Add(-time.Hour)},
+	}, nil)
+	setup.MockJWTGenerator.On("GeneratePlaybackToken", "link-1", "livestream123", mock.Anything, "secret").Return("signed", nil)
+
+	result, err := setup.UseCase.ListShareLinks(context.Background(), "livestream123", role.Admin)
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, "link-1", result[0].ID)
+}
+
+func TestRevokeShareLink_Success(t *testing.T) {
+	setup := setupShareLink()
+	setup.MockShareLinkRepo.On("GetByID", "link-1").Return(&livestream.ShareLink{ID: "link-1", LivestreamUUID: "livestream123"}, nil)
+	setup.MockShareLinkRepo.On("Delete", "link-1").Return(nil)
+
+	err := setup.UseCase.RevokeShareLink(context.Background(), "livestream123", "link-1", role.Admin)
+
+	assert.NoError(t, err)
+	setup.MockShareLinkRepo.AssertExpectations(t)
+}
+
+func TestRevokeShareLink_OtherLivestream(t *testing.T) {
+	setup := setupShareLink()
+	setup.MockShareLinkRepo.On("GetByID", "link-1").Return(&livestream.ShareLink{ID: "link-1", LivestreamUUID: "other"}, nil)
+
+	err := setup.UseCase.RevokeShareLink(context.Background(), "livestream123", "link-1", role.Admin)
+
+	assert.Equal(t, errors.ErrNotFound, err)
+	setup.MockShareLinkRepo.AssertNotCalled(t, "Delete", "link-1")
+}
@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"Go-Service/src/main/application/dto"
+	"Go-Service/src/main/application/dto/config"
+	livestreamDto "Go-Service/src/main/application/dto/livestream"
+	"Go-Service/src/main/application/usecase"
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/test/usecase/mock_data"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cool9850311/StreamPlatformLite-Core/pkg/role"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const thumbnailStreamUUID = "123e4567-e89b-12d3-a456-426614174000"
+
+type ThumbnailTestSetup struct {
+	MockLivestreamRepo *mock_data.MockLivestreamRepository
+	MockStreamService  *mock_data.MockLivestreamService
+	MockFfmpegLibrary  *mock_data.MockFfmpegLibrary
+	MockStore          *mock_data.MockThumbnailStore
+	MockShareLinkRepo  *mock_data.MockShareLinkRepository
+	MockJWTGenerator   *mock_data.MockJWTGenerator
+	UseCase            *usecase.ThumbnailUsecase
+}
+
+func setupThumbnail() *ThumbnailTestSetup {
+	mockLivestreamRepo := new(mock_data.MockLivestreamRepository)
+	mockStreamService := new(mock_data.MockLivestreamService)
+	mockFfmpegLibrary := new(mock_data.MockFfmpegLibrary)
+	mockStore := new(mock_data.MockThumbnailStore)
+	mockShareLinkRepo := new(mock_data.MockShareLinkRepository)
+	mockJWTGenerator := new(mock_data.MockJWTGenerator)
+	return &ThumbnailTestSetup{
+		MockLivestreamRepo: mockLivestreamRepo,
+		MockStreamService:  mockStreamService,
+		MockFfmpegLibrary:  mockFfmpegLibrary,
+		MockStore:          mockStore,
+		MockShareLinkRepo:  mockShareLinkRepo,
+		MockJWTGenerator:   mockJWTGenerator,
+		UseCase:            usecase.NewThumbnailUsecase(mockLivestreamRepo, new(mock_data.MockLogger), config.Config{}, mockStreamService, mockFfmpegLibrary, mockStore, mockShareLinkRepo, mockJWTGenerator),
+	}
+}
+
+func (s *ThumbnailTestSetup) setLive(uuid string) {
+	s.MockStreamService.LiveStatuses = map[string]livestream.LiveStatus{
+		uuid: {State: livestream.LiveStatePublishing},
+	}
+}
+
+func TestCaptureAll_OnlyLiveStreams(t *testing.T) {
+	setup := setupThumbnail()
+	setup.MockLivestreamRepo.On("List").Return([]*livestream.Livestream{
+		{UUID: "live-1"}, {UUID: "offline-1"},
+	}, nil)
+	setup.setLive("live-1")
+	setup.MockStore.On("LatestSegment", "live-1").Return("/hls/live-1/stream-1.ts", nil)
+	setup.MockStore.On("Add", "live-1").Return(&livestream.Thumbnail{ID: "1"}, "/hls/live-1/thumbnails/1.jpg", nil)
+	setup.MockFfmpegLibrary.On("CaptureThumbnail", "/hls/live-1/stream-1.ts", "/hls/live-1/thumbnails/1.jpg").Return(nil)
+
+	setup.UseCase.CaptureAll(context.Background())
+
+	setup.MockFfmpegLibrary.AssertExpectations(t)
+	setup.MockStore.AssertNotCalled(t, "LatestSegment", "offline-1")
+}
+
+func TestCaptureAll_NoSegmentYet(t *testing.T) {
+	setup := setupThumbnail()
+	setup.MockLivestreamRepo.On("List").Return([]*livestream.Livestream{{UUID: "live-1"}}, nil)
+	setup.setLive("live-1")
+	setup.MockStore.On("LatestSegment", "live-1").Return("", errors.ErrNotFound)
+
+	setup.UseCase.CaptureAll(context.Background())
+
+	setup.MockStore.AssertNotCalled(t, "Add", "live-1")
+}
+
+func TestGetThumbnail_LiveServesLatestSnapshot(t *testing.T) {
+	setup := setupThumbnail()
+	setup.MockLivestreamRepo.On("GetByID", thumbnailStreamUUID).Return(&livestream.Livestream{UUID: thumbnailStreamUUID, Visibility: livestream.Public}, nil)
+	setup.setLive(thumbnailStreamUUID)
+	setup.MockStore.On("List", thumbnailStreamUUID).Return([]livestream.Thumbnail{{ID: "2"}, {ID: "1"}}, nil)
+	setup.MockStore.On("Read", thumbnailStreamUUID, "2").Return([]byte("latest"), nil)
+
+	data, err := setup.UseCase.GetThumbnail(context.Background(), thumbnailStreamUUID, "", role.Anonymous)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "latest", string(data))
+	setup.MockStore.AssertNotCalled(t, "ReadPoster", thumbnailStreamUUID)
+}
+
+func TestGetThumbnail_OfflineServesPoster(t *testing.T) {
+	setup := setupThumbnail()
+	setup.MockLivestreamRepo.On("GetByID", thumbnailStreamUUID).Return(&livestream.Livestream{UUID: thumbnailStreamUUID, Visibility: livestream.Public}, nil)
+	setup.MockStore.On("ReadPoster", thumbnailStreamUUID).Return([]byte("poster"), nil)
+
+	data, err := setup.UseCase.GetThumbnail(context.Background(), thumbnailStreamUUID, "", role.Anonymous)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "poster", string(data))
+}
+
+func TestGetThumbnail_OfflineWithoutPosterFallsBack(t *testing.T) {
+	setup := setupThumbnail()
+	setup.MockLivestreamRepo.On("GetByID", thumbnailStreamUUID).Return(&livestream.Livestream{UUID: thumbnailStreamUUID, Visibility: livestream.Public}, nil)
+	setup.MockStore.On("ReadPoster", thumbnailStreamUUID).Return(nil, errors.ErrNotFound)
+	setup.MockStore.On("List", thumbnailStreamUUID).Return([]livestream.Thumbnail{}, nil)
+
+	data, err := setup.UseCase.GetThumbnail(context.Background(), thumbnailStreamUUID, "", role.Anonymous)
+
+	assert.Nil(t, data)
+	assert.Equal(t, errors.ErrNotFound, err)
+}
+
+func TestGetThumbnail_Visibility(t *testing.T) {
+	setup := setupThumbnail()
+	setup.MockLivestreamRepo.On("GetByID", thumbnailStreamUUID).Return(&livestream.Livestream{UUID: thumbnailStreamUUID, Visibility: livestream.MemberOnly}, nil)
+
+	data, err := setup.UseCase.GetThumbnail(context.Background(), thumbnailStreamUUID, "", role.Guest)
+
+	assert.Nil(t, data)
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	setup.MockStore.AssertNotCalled(t, "ReadPoster", thumbnailStreamUUID)
+}
+
+func TestGetThumbnail_Link_ValidToken(t *testing.T) {
+	setup := setupThumbnail()
+	setup.MockLivestreamRepo.On("GetByID", thumbnailStreamUUID).Return(&livestream.Livestream{UUID: thumbnailStreamUUID, Visibility: livestream.Link}, nil)
+	claims := &dto.PlaybackClaims{LinkID: "link-1"}
+	claims.Subject = thumbnailStreamUUID
+	setup.MockJWTGenerator.On("ParsePlaybackToken", "signed", mock.Anything).Return(claims, nil)
+	setup.MockShareLinkRepo.On("GetByID", "link-1").Return(&livestream.ShareLink{ID: "link-1", LivestreamUUID: thumbnailStreamUUID, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+	setup.MockStore.On("ReadPoster", thumbnailStreamUUID).Return([]byte("poster"), nil)
+
+	data, err := setup.UseCase.GetThumbnail(context.Background(), thumbnailStreamUUID, "signed", role.Anonymous)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("poster"), data)
+}
+
+func TestGetThumbnail_InvalidUUID(t *testing.T) {
+	setup := setupThumbnail()
+
+	_, err := setup.UseCase.GetThumbnail(context.Background(), "../etc", "", role.Admin)
+
+	assert.Equal(t, errors.ErrInvalidInput, err)
+}
+
+func TestSetPoster(t *testing.T) {
+	setup := setupThumbnail()
+	setup.MockLivestreamRepo.On("GetByID", thumbnailStreamUUID).Return(&livestream.Livestream{UUID: thumbnailStreamUUID}, nil)
+	setup.MockStore.On("SetPoster", thumbnailStreamUUID, "1700000000000").Return(nil)
+
+	err := setup.UseCase.SetPoster(context.Background(), thumbnailStreamUUID, &livestreamDto.ThumbnailPosterDTO{ThumbnailID: "1700000000000"}, role.Admin)
+
+	assert.NoError(t, err)
+	setup.MockStore.AssertExpectations(t)
+}
+
+func TestSetPoster_Unauthorized(t *testing.T) {
+	setup := setupThumbnail()
+
+	err := setup.UseCase.SetPoster(context.Background(), thumbnailStreamUUID, &livestreamDto.ThumbnailPosterDTO{ThumbnailID: "1"}, role.User)
+
+	assert.Equal(t, errors.ErrUnauthorized, err)
+	setup.MockStore.AssertNotCalled(t, "SetPoster", thumbnailStreamUUID, "1")
+}
+
+func TestListThumbnails(t *testing.T) {
+	setup := setupThumbnail()
+	setup.MockLivestreamRepo.On("GetByID", thumbnailStreamUUID).Return(&livestream.Livestream{UUID: thumbnailStreamUUID}, nil)
+	setup.MockStore.On("List", thumbnailStreamUUID).Return([]livestream.Thumbnail{{ID: "2"}, {ID: "1"}}, nil)
+
+	thumbnails, err := setup.UseCase.ListThumbnails(context.Background(), thumbnailStreamUUID, role.Admin)
+
+	assert.NoError(t, err)
+	assert.Len(t, thumbnails, 2)
+}
@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"Go-Service/src/main/application/interface/stream"
+	"Go-Service/src/main/application/usecase"
+	"Go-Service/src/main/domain/entity/errors"
+	"Go-Service/src/main/domain/entity/livestream"
+	"Go-Service/src/main/domain/interface/libarary/ffmpeg"
+	"Go-Service/src/test/usecase/mock_data"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type TranscodeTestSetup struct {
+	MockLivestreamRepo *mock_data.MockLivestreamRepository
+	MockStreamService  *mock_data.MockLivestreamService
+	MockFfmpegLibrary  *mock_data.MockFfmpegLibrary
+	UseCase            *usecase.TranscodeUsecase
+}
+
+func setupTranscode() *TranscodeTestSetup {
+	mockLivestreamRepo := new(mock_data.MockLivestreamRepository)
+	mockStreamService := new(mock_data.MockLivestreamService)
+	mockFfmpegLibrary := new(mock_data.MockFfmpegLibrary)
+	return &TranscodeTestSetup{
+		MockLivestreamRepo: mockLivestreamRepo,
+		MockStreamService:  mockStreamService,
+		MockFfmpegLibrary:  mockFfmpegLibrary,
+		UseCase:            usecase.NewTranscodeUsecase(mockLivestreamRepo, new(mock_data.MockLogger), mockStreamService, mockFfmpegLibrary, "/srv/hls"),
+	}
+}
+
+func publishEvent(eventType stream.PublishEventType, sessionID string) stream.PublishEvent {
+	return stream.PublishEvent{Type: eventType, SessionID: sessionID, UUID: "livestream123"}
+}
+
+func TestTranscode_StartsLadderOnPublish(t *testing.T) {
+	setup := setupTranscode()
+	renditions := []livestream.Rendition{livestream.Rendition720p, livestream.RenditionAudioOnly}
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123", Renditions: renditions}, nil)
+	transcoder := new(mock_data.MockTranscoder)
+	setup.MockFfmpegLibrary.On("StartTranscode", mock.MatchedBy(func(job ffmpeg.TranscodeJob) bool {
+		return job.OutputDir == filepath.Join("/srv/hls", "livestream123") && assert.ObjectsAreEqual(renditions, job.Renditions)
+	})).Return(transcoder, nil)
+
+	setup.UseCase.HandlePublishEvent(publishEvent(stream.PublishStarted, "session-1"))
+
+	setup.MockFfmpegLibrary.AssertExpectations(t)
+	transcoder.AssertNotCalled(t, "Stop")
+}
+
+func TestTranscode_NoRenditionsSkipsLadder(t *testing.T) {
+	setup := setupTranscode()
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123"}, nil)
+
+	setup.UseCase.HandlePublishEvent(publishEvent(stream.PublishStarted, "session-1"))
+
+	setup.MockFfmpegLibrary.AssertNotCalled(t, "StartTranscode", mock.Anything)
+}
+
+func TestTranscode_StopsLadderWhenPublishEnds(t *testing.T) {
+	setup := setupTranscode()
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123", Renditions: []livestream.Rendition{livestream.Rendition480p}}, nil)
+	transcoder := new(mock_data.MockTranscoder)
+	transcoder.On("Stop").Return()
+	setup.MockFfmpegLibrary.On("StartTranscode", mock.Anything).Return(transcoder, nil)
+
+	setup.UseCase.HandlePublishEvent(publishEvent(stream.PublishStarted, "session-1"))
+	// A stop from another publish leaves the ladder running
+	setup.UseCase.HandlePublishEvent(publishEvent(stream.PublishStopped, "session-0"))
+	transcoder.AssertNotCalled(t, "Stop")
+
+	setup.UseCase.HandlePublishEvent(publishEvent(stream.PublishStopped, "session-1"))
+	transcoder.AssertNumberOfCalls(t, "Stop", 1)
+}
+
+func TestTranscode_NewPublishReplacesLadder(t *testing.T) {
+	setup := setupTranscode()
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123", Renditions: []livestream.Rendition{livestream.Rendition480p}}, nil)
+	first := new(mock_data.MockTranscoder)
+	first.On("Stop").Return()
+	second := new(mock_data.MockTranscoder)
+	setup.MockFfmpegLibrary.On("StartTranscode", mock.Anything).Return(first, nil).Once()
+	setup.MockFfmpegLibrary.On("StartTranscode", mock.Anything).Return(second, nil).Once()
+
+	setup.UseCase.HandlePublishEvent(publishEvent(stream.PublishStarted, "session-1"))
+	setup.UseCase.HandlePublishEvent(publishEvent(stream.PublishStarted, "session-2"))
+
+	first.AssertNumberOfCalls(t, "Stop", 1)
+	second.AssertNotCalled(t, "Stop")
+}
+
+func TestTranscode_SourceReadsFLVRelay(t *testing.T) {
+	setup := setupTranscode()
+	setup.MockLivestreamRepo.On("GetByID", "livestream123").Return(&livestream.Livestream{UUID: "livestream123", Renditions: []livestream.Rendition{livestream.Rendition720p}}, nil)
+	var job ffmpeg.TranscodeJob
+	setup.MockFfmpegLibrary.On("StartTranscode", mock.Anything).Run(func(args mock.Arguments) {
+		job = args.Get(0).(ffmpeg.TranscodeJob)
+	}).Return(new(mock_data.MockTranscoder), nil)
+
+	setup.UseCase.HandlePublishEvent(publishEvent(stream.PublishStarted, "session-1"))
+
+	data := make(chan []byte, 2)
+	data <- []byte("FLV")
+	data <- []byte("tags")
+	close(data)
+	subscription := new(mock_data.MockFlvSubscription)
+	subscription.On("Data").Return((<-chan []byte)(data))
+	subscription.On("Close").Return()
+	setup.MockStreamService.On("SubscribeFLV", "livestream123").Return(subscription, nil).Once()
+
+	source, err := job.Source()
+	assert.NoError(t, err)
+	body, err := io.ReadAll(source)
+	assert.NoError(t, err)
+	assert.Equal(t, "FLVtags", string(body))
+	assert.NoError(t, source.Close())
+	subscription.AssertCalled(t, "Close")
+
+	// Not publishing any more: ffmpeg is restarted later
+	setup.MockStreamService.On("SubscribeFLV", "livestream123").Return(nil, errors.ErrNotFound)
+	_, err = job.Source()
+	assert.Equal(t, errors.ErrNotFound, err)
+}